@@ -0,0 +1,186 @@
+// Package a11y checks rendered HTML for common accessibility problems.
+//
+// The checks are a subset of the Web Content Accessibility Guidelines (WCAG)
+// that can be found from the HTML alone, without a browser. Passing them
+// doesn't mean that a page is accessible, but failing them means that it
+// isn't.
+package a11y
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/a-h/templ"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Finding is an accessibility problem in the HTML.
+type Finding struct {
+	// Rule is the ID of the rule that failed, e.g. "image-alt".
+	Rule string `json:"rule"`
+	// WCAG is the success criterion that the rule checks, e.g. "1.1.1".
+	WCAG    string `json:"wcag"`
+	Message string `json:"message"`
+	// Selector is a CSS selector for the element, e.g. "main > img:nth-of-type(2)".
+	Selector string `json:"selector"`
+	// HTML is the start tag of the element.
+	HTML string `json:"html"`
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s: %s (WCAG %s)", f.Selector, f.Rule, f.Message, f.WCAG)
+}
+
+// Rule is an accessibility check.
+type Rule struct {
+	ID string
+	// WCAG is the success criterion that the rule checks.
+	WCAG        string
+	Description string
+	check       func(d *document, report func(n *html.Node, message string))
+}
+
+// Rules are the checks run by Audit and AuditHTML.
+var Rules = []Rule{
+	imageAlt,
+	label,
+	headingOrder,
+	buttonName,
+	linkName,
+	ariaValid,
+	htmlHasLang,
+	tabindex,
+}
+
+// Audit renders the component, and checks the HTML with the rules. If no rules
+// are given, all of the Rules are used.
+func Audit(ctx context.Context, c templ.Component, rules ...Rule) (findings []Finding, err error) {
+	var b bytes.Buffer
+	if err = c.Render(ctx, &b); err != nil {
+		return nil, fmt.Errorf("a11y: failed to render component: %w", err)
+	}
+	return AuditHTML(&b, rules...)
+}
+
+// AuditHTML checks the HTML with the rules. The HTML can be a complete document,
+// or a fragment. If no rules are given, all of the Rules are used.
+func AuditHTML(r io.Reader, rules ...Rule) (findings []Finding, err error) {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("a11y: failed to read HTML: %w", err)
+	}
+	root, err := html.Parse(bytes.NewReader(src))
+	if err != nil {
+		return nil, fmt.Errorf("a11y: failed to parse HTML: %w", err)
+	}
+	d := newDocument(root, isDocument(src))
+	if len(rules) == 0 {
+		rules = Rules
+	}
+	for _, rule := range rules {
+		rule.check(d, func(n *html.Node, message string) {
+			findings = append(findings, Finding{
+				Rule:     rule.ID,
+				WCAG:     rule.WCAG,
+				Message:  message,
+				Selector: d.selector(n),
+				HTML:     startTag(n),
+			})
+		})
+	}
+	return findings, nil
+}
+
+// isDocument returns true if the HTML has an <html> element. Fragments are
+// parsed into a document, but they don't need a lang attribute.
+func isDocument(src []byte) bool {
+	z := html.NewTokenizer(bytes.NewReader(src))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return false
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, _ := z.TagName()
+			return atom.Lookup(name) == atom.Html
+		}
+	}
+}
+
+// document is parsed HTML, with indexes used by the rules.
+type document struct {
+	root *html.Node
+	// fragment is true if the HTML didn't contain an <html> element.
+	fragment bool
+	ids      map[string]*html.Node
+	// idCounts is used to find ids that are unique, and can be used in
+	// selectors.
+	idCounts map[string]int
+	// labelled are the ids that are referenced by the for attribute of a
+	// label.
+	labelled map[string]bool
+}
+
+func newDocument(root *html.Node, isDocument bool) *document {
+	d := &document{
+		root:     root,
+		fragment: !isDocument,
+		ids:      map[string]*html.Node{},
+		idCounts: map[string]int{},
+		labelled: map[string]bool{},
+	}
+	d.elements(func(n *html.Node) {
+		if id, ok := attr(n, "id"); ok && id != "" {
+			if _, exists := d.ids[id]; !exists {
+				d.ids[id] = n
+			}
+			d.idCounts[id]++
+		}
+		if n.DataAtom == atom.Label {
+			if id, ok := attr(n, "for"); ok && strings.TrimSpace(id) != "" {
+				d.labelled[id] = true
+			}
+		}
+	})
+	return d
+}
+
+// elements calls f for each element in document order.
+func (d *document) elements(f func(n *html.Node)) {
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			f(n)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(d.root)
+}
+
+func attr(n *html.Node, name string) (value string, ok bool) {
+	for _, a := range n.Attr {
+		if a.Namespace == "" && a.Key == name {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+// startTag returns the start tag of the element.
+func startTag(n *html.Node) string {
+	var sb strings.Builder
+	sb.WriteString("<" + n.Data)
+	for _, a := range n.Attr {
+		sb.WriteString(" " + a.Key)
+		if a.Val != "" {
+			sb.WriteString(`="` + html.EscapeString(a.Val) + `"`)
+		}
+	}
+	sb.WriteString(">")
+	return sb.String()
+}
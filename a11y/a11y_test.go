@@ -0,0 +1,146 @@
+package a11y
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/a-h/templ"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAuditHTML(t *testing.T) {
+	tests := []struct {
+		name     string
+		html     string
+		expected []Finding
+	}{
+		{
+			name: "accessible HTML has no findings",
+			html: `<!DOCTYPE html><html lang="en"><body>
+				<h1>Title</h1><h2>Section</h2>
+				<img src="a.png" alt="A"><img src="b.png" alt="">
+				<label>Name <input name="name"></label>
+				<label for="email">Email</label><input id="email" type="email">
+				<input type="checkbox" aria-label="Agree" aria-checked="true">
+				<input type="submit"><input type="hidden" name="csrf">
+				<button><img src="x.svg" alt="Close"></button>
+				<a href="/"><span aria-hidden="true">→</span> Home</a>
+				<div role="checkbox" aria-checked="false" tabindex="0">Remember me</div>
+			</body></html>`,
+		},
+		{
+			name: "image-alt: images without alt are reported",
+			html: `<div><img src="a.png" alt="A"><img src="b.png"><img src="c.png" role="presentation"></div>`,
+			expected: []Finding{
+				{Rule: "image-alt", WCAG: "1.1.1", Message: "image has no alt attribute", Selector: "div > img:nth-of-type(2)", HTML: `<img src="b.png">`},
+			},
+		},
+		{
+			name: "elements within hidden elements are not reported",
+			html: `<div aria-hidden="true"><img src="x.png"></div><div hidden><button></button></div><section aria-hidden="true"><p><a href="/"></a></p></section>`,
+		},
+		{
+			name: "label: form controls without labels are reported",
+			html: `<form id="signup"><input name="name"><select name="country"></select><textarea aria-labelledby="missing"></textarea><input aria-label="Search" type="search"></form>`,
+			expected: []Finding{
+				{Rule: "label", WCAG: "4.1.2", Message: "form control has no label", Selector: "#signup > input:nth-of-type(1)", HTML: `<input name="name">`},
+				{Rule: "label", WCAG: "4.1.2", Message: "form control has no label", Selector: "#signup > select", HTML: `<select name="country">`},
+				{Rule: "label", WCAG: "4.1.2", Message: "form control has no label", Selector: "#signup > textarea", HTML: `<textarea aria-labelledby="missing">`},
+			},
+		},
+		{
+			name: "heading-order: skipped heading levels are reported",
+			html: `<h1>A</h1><h3>B</h3><h2>C</h2><h2>D</h2><div role="heading" aria-level="4">E</div>`,
+			expected: []Finding{
+				{Rule: "heading-order", WCAG: "1.3.1", Message: "heading level 3 follows heading level 1", Selector: "h3", HTML: `<h3>`},
+				{Rule: "heading-order", WCAG: "1.3.1", Message: "heading level 4 follows heading level 2", Selector: "div", HTML: `<div role="heading" aria-level="4">`},
+			},
+		},
+		{
+			name: "button-name: buttons without an accessible name are reported",
+			html: `<button></button><button aria-label="Close"></button><button><svg aria-hidden="true"></svg></button><input type="button"><span role="button" title="Help"></span>`,
+			expected: []Finding{
+				{Rule: "button-name", WCAG: "4.1.2", Message: "button has no accessible name", Selector: "button:nth-of-type(1)", HTML: `<button>`},
+				{Rule: "button-name", WCAG: "4.1.2", Message: "button has no accessible name", Selector: "button:nth-of-type(3)", HTML: `<button>`},
+				{Rule: "button-name", WCAG: "4.1.2", Message: "button has no accessible name", Selector: "input", HTML: `<input type="button">`},
+			},
+		},
+		{
+			name: "button-name: hidden elements referenced by aria-labelledby provide a name",
+			html: `<span id="l" hidden>Close</span><button aria-labelledby="l"></button><div id="m" aria-hidden="true"><span hidden>Open</span></div><button aria-labelledby="m"></button>`,
+		},
+		{
+			name: "link-name: links without an accessible name are reported",
+			html: `<nav><a href="/"></a><a href="/about">About</a><a name="anchor"></a><a href="/x"><img src="x.png" alt=""></a></nav>`,
+			expected: []Finding{
+				{Rule: "link-name", WCAG: "2.4.4", Message: "link has no accessible name", Selector: "nav > a:nth-of-type(1)", HTML: `<a href="/">`},
+				{Rule: "link-name", WCAG: "2.4.4", Message: "link has no accessible name", Selector: "nav > a:nth-of-type(4)", HTML: `<a href="/x">`},
+			},
+		},
+		{
+			name: "aria-valid: invalid roles and attributes are reported",
+			html: `<div role="buton">A</div><span aria-labeledby="x">B</span><div aria-checked="true">C</div><button aria-pressed="true">D</button><div role="slider" aria-label="Volume"></div>`,
+			expected: []Finding{
+				{Rule: "aria-valid", WCAG: "4.1.2", Message: `role "buton" is not a valid ARIA role`, Selector: "div:nth-of-type(1)", HTML: `<div role="buton">`},
+				{Rule: "aria-valid", WCAG: "4.1.2", Message: "aria-labeledby is not a valid ARIA attribute", Selector: "span", HTML: `<span aria-labeledby="x">`},
+				{Rule: "aria-valid", WCAG: "4.1.2", Message: `aria-checked is not supported by role "generic"`, Selector: "div:nth-of-type(2)", HTML: `<div aria-checked="true">`},
+				{Rule: "aria-valid", WCAG: "4.1.2", Message: `role "slider" requires the aria-valuenow attribute`, Selector: "div:nth-of-type(3)", HTML: `<div role="slider" aria-label="Volume">`},
+			},
+		},
+		{
+			name: "html-has-lang: documents without a lang attribute are reported",
+			html: `<!DOCTYPE html><html><head><title>Page</title></head><body><p>Text</p></body></html>`,
+			expected: []Finding{
+				{Rule: "html-has-lang", WCAG: "3.1.1", Message: "html element has no lang attribute", Selector: "html", HTML: `<html>`},
+			},
+		},
+		{
+			name: "html-has-lang: fragments don't need a lang attribute",
+			html: `<p>Text</p>`,
+		},
+		{
+			name: "tabindex: positive tabindex values are reported",
+			html: `<ul id="menu"><li tabindex="-1">A</li><li tabindex="0">B</li><li tabindex="3">C</li></ul>`,
+			expected: []Finding{
+				{Rule: "tabindex", WCAG: "2.4.3", Message: "tabindex is 3, which changes the focus order", Selector: "#menu > li:nth-of-type(3)", HTML: `<li tabindex="3">`},
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := AuditHTML(strings.NewReader(tt.html))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.expected, actual); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestAudit(t *testing.T) {
+	t.Run("components are rendered and audited", func(t *testing.T) {
+		c := templ.Raw(`<img src="logo.png">`)
+		findings, err := Audit(context.Background(), c, imageAlt)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(findings) != 1 || findings[0].Selector != "img" {
+			t.Errorf("expected one finding for the img element, got %v", findings)
+		}
+	})
+	t.Run("render errors are returned", func(t *testing.T) {
+		expected := errors.New("render failed")
+		c := templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+			return expected
+		})
+		if _, err := Audit(context.Background(), c); !errors.Is(err, expected) {
+			t.Errorf("expected render error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,62 @@
+// Package a11ystorybook audits the stories of a storybook for accessibility
+// problems. It's separate from the a11y package, so that audits of components
+// don't depend on the storybook package.
+package a11ystorybook
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/a-h/templ/a11y"
+	"github.com/a-h/templ/storybook"
+)
+
+// StoryFindings are the findings of a storybook story.
+type StoryFindings struct {
+	storybook.StoryPreview
+	Findings []a11y.Finding `json:"findings"`
+}
+
+// AuditStories renders every story of the storybook, and checks the HTML with
+// the rules. If no rules are given, all of the a11y.Rules are used.
+func AuditStories(ctx context.Context, sb *storybook.Storybook, rules ...a11y.Rule) (results []StoryFindings, err error) {
+	for _, story := range sb.Stories() {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, story.URL, nil).WithContext(ctx)
+		sb.ServeHTTP(w, r)
+		if w.Code != http.StatusOK {
+			return nil, fmt.Errorf("a11y: %s/%s: unexpected status %d: %s", story.Component, story.Story, w.Code, w.Body.String())
+		}
+		findings, err := a11y.AuditHTML(w.Body, rules...)
+		if err != nil {
+			return nil, fmt.Errorf("a11y: %s/%s: %w", story.Component, story.Story, err)
+		}
+		results = append(results, StoryFindings{StoryPreview: story, Findings: findings})
+	}
+	return results, nil
+}
+
+// TestStories audits every story of the storybook in a subtest, and fails the
+// subtests of stories that have findings.
+//
+//	func TestAccessibility(t *testing.T) {
+//		a11ystorybook.TestStories(t, newStorybook())
+//	}
+func TestStories(t *testing.T, sb *storybook.Storybook, rules ...a11y.Rule) {
+	t.Helper()
+	results, err := AuditStories(context.Background(), sb, rules...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, result := range results {
+		result := result
+		t.Run(result.Component+"/"+result.Story, func(t *testing.T) {
+			for _, f := range result.Findings {
+				t.Errorf("%s\n  %s", f, f.HTML)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package a11ystorybook
+
+import (
+	"context"
+	"testing"
+
+	"github.com/a-h/templ"
+	"github.com/a-h/templ/storybook"
+	"github.com/google/go-cmp/cmp"
+)
+
+func image(src, alt string) templ.Component {
+	if alt == "" {
+		return templ.Raw(`<img src="` + src + `">`)
+	}
+	return templ.Raw(`<img src="` + src + `" alt="` + alt + `">`)
+}
+
+func TestAuditStories(t *testing.T) {
+	sb := storybook.New()
+	sb.AddComponent("Image", image,
+		storybook.TextArg("src", "logo.png"),
+		storybook.TextArg("alt", "Logo"),
+	)
+	sb.Config["Image"].AddStory("Missing alt", storybook.TextArg("alt", ""))
+
+	results, err := AuditStories(context.Background(), sb)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var actual []string
+	for _, result := range results {
+		for _, f := range result.Findings {
+			actual = append(actual, result.Component+"/"+result.Story+": "+f.String())
+		}
+	}
+	expected := []string{
+		"Image/Missing alt: img: image-alt: image has no alt attribute (WCAG 1.1.1)",
+	}
+	if len(results) != 2 {
+		t.Errorf("expected 2 stories to be audited, got %d", len(results))
+	}
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Error(diff)
+	}
+}
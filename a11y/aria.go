@@ -0,0 +1,209 @@
+package a11y
+
+import (
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// roles are the non-abstract roles of WAI-ARIA 1.2.
+var roles = setOf(
+	"alert", "alertdialog", "application", "article", "banner", "blockquote",
+	"button", "caption", "cell", "checkbox", "code", "columnheader", "combobox",
+	"complementary", "contentinfo", "definition", "deletion", "dialog",
+	"directory", "document", "emphasis", "feed", "figure", "form", "generic",
+	"grid", "gridcell", "group", "heading", "img", "insertion", "link", "list",
+	"listbox", "listitem", "log", "main", "marquee", "math", "menu", "menubar",
+	"menuitem", "menuitemcheckbox", "menuitemradio", "meter", "navigation",
+	"none", "note", "option", "paragraph", "presentation", "progressbar",
+	"radio", "radiogroup", "region", "row", "rowgroup", "rowheader",
+	"scrollbar", "search", "searchbox", "separator", "slider", "spinbutton",
+	"status", "strong", "subscript", "superscript", "switch", "tab", "table",
+	"tablist", "tabpanel", "term", "textbox", "time", "timer", "toolbar",
+	"tooltip", "tree", "treegrid", "treeitem",
+)
+
+// globalAttrs are the ARIA attributes that are supported by all roles.
+var globalAttrs = setOf(
+	"aria-atomic", "aria-busy", "aria-controls", "aria-current",
+	"aria-describedby", "aria-description", "aria-details", "aria-disabled",
+	"aria-dropeffect", "aria-errormessage", "aria-flowto", "aria-grabbed",
+	"aria-haspopup", "aria-hidden", "aria-invalid", "aria-keyshortcuts",
+	"aria-label", "aria-labelledby", "aria-live", "aria-owns", "aria-relevant",
+	"aria-roledescription",
+)
+
+// roleAttrs are the roles that support each of the ARIA attributes that aren't
+// global.
+var roleAttrs = map[string]map[string]bool{
+	"aria-activedescendant": setOf("application", "combobox", "grid", "group", "listbox", "menu", "menubar", "radiogroup", "row", "searchbox", "spinbutton", "tablist", "textbox", "toolbar", "tree", "treegrid"),
+	"aria-autocomplete":     setOf("combobox", "searchbox", "textbox"),
+	"aria-checked":          setOf("checkbox", "menuitemcheckbox", "menuitemradio", "option", "radio", "switch", "treeitem"),
+	"aria-colcount":         setOf("grid", "table", "treegrid"),
+	"aria-colindex":         setOf("cell", "columnheader", "gridcell", "row", "rowheader"),
+	"aria-colspan":          setOf("cell", "columnheader", "gridcell", "rowheader"),
+	"aria-expanded":         setOf("application", "button", "checkbox", "combobox", "gridcell", "link", "listbox", "menuitem", "menuitemcheckbox", "menuitemradio", "row", "rowheader", "switch", "tab", "treeitem"),
+	"aria-level":            setOf("heading", "listitem", "row", "treeitem"),
+	"aria-modal":            setOf("alertdialog", "dialog"),
+	"aria-multiline":        setOf("searchbox", "textbox"),
+	"aria-multiselectable":  setOf("grid", "listbox", "tablist", "tree", "treegrid"),
+	"aria-orientation":      setOf("listbox", "menu", "menubar", "radiogroup", "scrollbar", "separator", "slider", "tablist", "toolbar", "tree", "treegrid"),
+	"aria-placeholder":      setOf("searchbox", "textbox"),
+	"aria-posinset":         setOf("article", "listitem", "menuitem", "menuitemcheckbox", "menuitemradio", "option", "radio", "row", "tab", "treeitem"),
+	"aria-pressed":          setOf("button"),
+	"aria-readonly":         setOf("checkbox", "combobox", "grid", "gridcell", "listbox", "radiogroup", "slider", "spinbutton", "textbox", "searchbox", "treegrid"),
+	"aria-required":         setOf("checkbox", "combobox", "gridcell", "listbox", "radiogroup", "spinbutton", "textbox", "searchbox", "tree", "treegrid"),
+	"aria-rowcount":         setOf("grid", "table", "treegrid"),
+	"aria-rowindex":         setOf("cell", "columnheader", "gridcell", "row", "rowheader"),
+	"aria-rowspan":          setOf("cell", "columnheader", "gridcell", "rowheader"),
+	"aria-selected":         setOf("columnheader", "gridcell", "option", "row", "rowheader", "tab", "treeitem"),
+	"aria-setsize":          setOf("article", "listitem", "menuitem", "menuitemcheckbox", "menuitemradio", "option", "radio", "row", "tab", "treeitem"),
+	"aria-sort":             setOf("columnheader", "rowheader"),
+	"aria-valuemax":         setOf("meter", "progressbar", "scrollbar", "separator", "slider", "spinbutton"),
+	"aria-valuemin":         setOf("meter", "progressbar", "scrollbar", "separator", "slider", "spinbutton"),
+	"aria-valuenow":         setOf("meter", "progressbar", "scrollbar", "separator", "slider", "spinbutton"),
+	"aria-valuetext":        setOf("meter", "progressbar", "scrollbar", "separator", "slider", "spinbutton"),
+}
+
+// requiredAttrs are the ARIA attributes that must be set on elements with an
+// explicit role.
+var requiredAttrs = map[string][]string{
+	"checkbox":         {"aria-checked"},
+	"combobox":         {"aria-expanded"},
+	"heading":          {"aria-level"},
+	"menuitemcheckbox": {"aria-checked"},
+	"menuitemradio":    {"aria-checked"},
+	"meter":            {"aria-valuenow"},
+	"radio":            {"aria-checked"},
+	"scrollbar":        {"aria-controls", "aria-valuenow"},
+	"slider":           {"aria-valuenow"},
+	"switch":           {"aria-checked"},
+}
+
+// nativelyProvides returns true if the element provides the state of a
+// required ARIA attribute, e.g. a checkbox input provides aria-checked.
+func nativelyProvides(n *html.Node, attr string) bool {
+	switch n.DataAtom {
+	case atom.Input:
+		switch inputType(n) {
+		case "checkbox", "radio":
+			return attr == "aria-checked"
+		case "range":
+			return attr == "aria-valuenow"
+		}
+	case atom.Meter:
+		return attr == "aria-valuenow"
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		return attr == "aria-level"
+	}
+	return false
+}
+
+// implicitRole returns the role of an element that doesn't have a role
+// attribute, or an empty string if it has no role that supports ARIA
+// attributes.
+func implicitRole(n *html.Node) string {
+	switch n.DataAtom {
+	case atom.A, atom.Area:
+		if hasAttr(n, "href") {
+			return "link"
+		}
+		return "generic"
+	case atom.Article:
+		return "article"
+	case atom.Aside:
+		return "complementary"
+	case atom.Button, atom.Summary:
+		return "button"
+	case atom.Details:
+		return "group"
+	case atom.Dialog:
+		return "dialog"
+	case atom.Fieldset, atom.Optgroup:
+		return "group"
+	case atom.Footer:
+		return "contentinfo"
+	case atom.Form:
+		return "form"
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		return "heading"
+	case atom.Header:
+		return "banner"
+	case atom.Hr:
+		return "separator"
+	case atom.Img:
+		if v, ok := attr(n, "alt"); ok && v == "" {
+			return "presentation"
+		}
+		return "img"
+	case atom.Input:
+		switch inputType(n) {
+		case "button", "image", "reset", "submit":
+			return "button"
+		case "checkbox":
+			return "checkbox"
+		case "radio":
+			return "radio"
+		case "range":
+			return "slider"
+		case "number":
+			return "spinbutton"
+		case "search":
+			if hasAttr(n, "list") {
+				return "combobox"
+			}
+			return "searchbox"
+		case "email", "tel", "text", "url":
+			if hasAttr(n, "list") {
+				return "combobox"
+			}
+			return "textbox"
+		}
+		// Other inputs, e.g. password and date, have no role, but support
+		// the same attributes as a textbox.
+		return "textbox"
+	case atom.Li:
+		return "listitem"
+	case atom.Main:
+		return "main"
+	case atom.Menu, atom.Ol, atom.Ul:
+		return "list"
+	case atom.Meter:
+		return "meter"
+	case atom.Nav:
+		return "navigation"
+	case atom.Option:
+		return "option"
+	case atom.Progress:
+		return "progressbar"
+	case atom.Section:
+		return "region"
+	case atom.Select:
+		if hasAttr(n, "multiple") {
+			return "listbox"
+		}
+		return "combobox"
+	case atom.Table:
+		return "table"
+	case atom.Tbody, atom.Tfoot, atom.Thead:
+		return "rowgroup"
+	case atom.Td:
+		return "cell"
+	case atom.Textarea:
+		return "textbox"
+	case atom.Th:
+		return "columnheader"
+	case atom.Tr:
+		return "row"
+	case atom.Div, atom.Span:
+		return "generic"
+	}
+	return ""
+}
+
+func setOf(values ...string) map[string]bool {
+	m := make(map[string]bool, len(values))
+	for _, v := range values {
+		m[v] = true
+	}
+	return m
+}
@@ -0,0 +1,371 @@
+package a11y
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var imageAlt = Rule{
+	ID:          "image-alt",
+	WCAG:        "1.1.1",
+	Description: "Images must have an alt attribute, or be marked as decorative.",
+	check: func(d *document, report func(n *html.Node, message string)) {
+		d.elements(func(n *html.Node) {
+			isImage := n.DataAtom == atom.Img || (n.DataAtom == atom.Input && inputType(n) == "image")
+			if !isImage || isHidden(n) {
+				return
+			}
+			if _, ok := attr(n, "alt"); ok {
+				return
+			}
+			if role := explicitRole(n); role == "presentation" || role == "none" {
+				return
+			}
+			if hasAttrValue(n, "aria-label") || hasAttrValue(n, "aria-labelledby") {
+				return
+			}
+			report(n, "image has no alt attribute")
+		})
+	},
+}
+
+// unlabelledInputTypes are the types of input that don't need a label, because
+// they're hidden, or named by their value or alt attribute.
+var unlabelledInputTypes = map[string]bool{
+	"hidden": true,
+	"submit": true,
+	"reset":  true,
+	"button": true,
+	"image":  true,
+}
+
+var label = Rule{
+	ID:          "label",
+	WCAG:        "4.1.2",
+	Description: "Form controls must have a label.",
+	check: func(d *document, report func(n *html.Node, message string)) {
+		d.elements(func(n *html.Node) {
+			switch n.DataAtom {
+			case atom.Input:
+				if unlabelledInputTypes[inputType(n)] {
+					return
+				}
+			case atom.Select, atom.Textarea:
+			default:
+				return
+			}
+			if isHidden(n) || d.hasLabel(n) {
+				return
+			}
+			report(n, "form control has no label")
+		})
+	},
+}
+
+// hasLabel returns true if a form control has a label element, or an ARIA
+// label.
+func (d *document) hasLabel(n *html.Node) bool {
+	if hasAttrValue(n, "aria-label") || hasAttrValue(n, "title") {
+		return true
+	}
+	if d.labelledBy(n) != "" {
+		return true
+	}
+	if id, ok := attr(n, "id"); ok && d.labelled[id] {
+		return true
+	}
+	for p := n.Parent; p != nil; p = p.Parent {
+		if p.Type == html.ElementNode && p.DataAtom == atom.Label {
+			return true
+		}
+	}
+	return false
+}
+
+var headingOrder = Rule{
+	ID:          "heading-order",
+	WCAG:        "1.3.1",
+	Description: "Heading levels must only increase by one.",
+	check: func(d *document, report func(n *html.Node, message string)) {
+		var previous int
+		d.elements(func(n *html.Node) {
+			level := headingLevel(n)
+			if level == 0 || isHidden(n) {
+				return
+			}
+			if previous > 0 && level > previous+1 {
+				report(n, fmt.Sprintf("heading level %d follows heading level %d", level, previous))
+			}
+			previous = level
+		})
+	},
+}
+
+// headingLevel returns the level of a heading element, or 0 if the element
+// isn't a heading.
+func headingLevel(n *html.Node) int {
+	switch n.DataAtom {
+	case atom.H1:
+		return 1
+	case atom.H2:
+		return 2
+	case atom.H3:
+		return 3
+	case atom.H4:
+		return 4
+	case atom.H5:
+		return 5
+	case atom.H6:
+		return 6
+	}
+	if explicitRole(n) == "heading" {
+		if level, err := strconv.Atoi(strings.TrimSpace(attrValue(n, "aria-level"))); err == nil && level > 0 {
+			return level
+		}
+		return 2
+	}
+	return 0
+}
+
+var buttonName = Rule{
+	ID:          "button-name",
+	WCAG:        "4.1.2",
+	Description: "Buttons must have an accessible name.",
+	check: func(d *document, report func(n *html.Node, message string)) {
+		d.elements(func(n *html.Node) {
+			isButton := n.DataAtom == atom.Button || explicitRole(n) == "button"
+			if n.DataAtom == atom.Input {
+				switch inputType(n) {
+				case "button":
+					isButton = true
+				case "submit", "reset":
+					// Submit and reset buttons have a default label.
+					return
+				}
+			}
+			if !isButton || isHidden(n) {
+				return
+			}
+			if d.accessibleName(n) == "" {
+				report(n, "button has no accessible name")
+			}
+		})
+	},
+}
+
+var linkName = Rule{
+	ID:          "link-name",
+	WCAG:        "2.4.4",
+	Description: "Links must have an accessible name.",
+	check: func(d *document, report func(n *html.Node, message string)) {
+		d.elements(func(n *html.Node) {
+			isLink := (n.DataAtom == atom.A && hasAttr(n, "href")) || explicitRole(n) == "link"
+			if !isLink || isHidden(n) {
+				return
+			}
+			if d.accessibleName(n) == "" {
+				report(n, "link has no accessible name")
+			}
+		})
+	},
+}
+
+var ariaValid = Rule{
+	ID:          "aria-valid",
+	WCAG:        "4.1.2",
+	Description: "ARIA roles and attributes must be valid, and supported by the element's role.",
+	check: func(d *document, report func(n *html.Node, message string)) {
+		d.elements(func(n *html.Node) {
+			role := explicitRole(n)
+			if role != "" && !roles[role] {
+				report(n, fmt.Sprintf("role %q is not a valid ARIA role", role))
+				role = ""
+			}
+			if role == "" {
+				role = implicitRole(n)
+			}
+			for _, a := range n.Attr {
+				if a.Namespace != "" || !strings.HasPrefix(a.Key, "aria-") {
+					continue
+				}
+				if globalAttrs[a.Key] {
+					continue
+				}
+				supported, ok := roleAttrs[a.Key]
+				if !ok {
+					report(n, fmt.Sprintf("%s is not a valid ARIA attribute", a.Key))
+					continue
+				}
+				if !supported[role] {
+					if role == "" {
+						report(n, fmt.Sprintf("%s is not supported by the element, because it has no role", a.Key))
+						continue
+					}
+					report(n, fmt.Sprintf("%s is not supported by role %q", a.Key, role))
+				}
+			}
+			if explicit := explicitRole(n); roles[explicit] {
+				for _, required := range requiredAttrs[explicit] {
+					if !hasAttr(n, required) && !nativelyProvides(n, required) {
+						report(n, fmt.Sprintf("role %q requires the %s attribute", explicit, required))
+					}
+				}
+			}
+		})
+	},
+}
+
+var htmlHasLang = Rule{
+	ID:          "html-has-lang",
+	WCAG:        "3.1.1",
+	Description: "The html element must have a lang attribute.",
+	check: func(d *document, report func(n *html.Node, message string)) {
+		if d.fragment {
+			return
+		}
+		d.elements(func(n *html.Node) {
+			if n.DataAtom == atom.Html && !hasAttrValue(n, "lang") {
+				report(n, "html element has no lang attribute")
+			}
+		})
+	},
+}
+
+var tabindex = Rule{
+	ID:          "tabindex",
+	WCAG:        "2.4.3",
+	Description: "Elements must not have a tabindex greater than zero.",
+	check: func(d *document, report func(n *html.Node, message string)) {
+		d.elements(func(n *html.Node) {
+			v, ok := attr(n, "tabindex")
+			if !ok {
+				return
+			}
+			if i, err := strconv.Atoi(strings.TrimSpace(v)); err == nil && i > 0 {
+				report(n, fmt.Sprintf("tabindex is %d, which changes the focus order", i))
+			}
+		})
+	},
+}
+
+// accessibleName returns the accessible name of an element, computed from its
+// ARIA attributes, content and title.
+func (d *document) accessibleName(n *html.Node) string {
+	if name := d.labelledBy(n); name != "" {
+		return name
+	}
+	if name := strings.TrimSpace(attrValue(n, "aria-label")); name != "" {
+		return name
+	}
+	if n.DataAtom == atom.Input {
+		if name := strings.TrimSpace(attrValue(n, "value")); name != "" {
+			return name
+		}
+	}
+	if name := strings.TrimSpace(textContent(n, false)); name != "" {
+		return name
+	}
+	return strings.TrimSpace(attrValue(n, "title"))
+}
+
+// labelledBy returns the text of the elements referenced by aria-labelledby.
+// A hidden element still provides its text, including the text of its hidden
+// descendants, as in the WAI-ARIA accessible name computation.
+func (d *document) labelledBy(n *html.Node) string {
+	var names []string
+	for _, id := range strings.Fields(attrValue(n, "aria-labelledby")) {
+		if label, ok := d.ids[id]; ok {
+			if name := strings.TrimSpace(textContent(label, isHidden(label))); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	return strings.Join(names, " ")
+}
+
+// textContent returns the text of an element that's available to assistive
+// technology, including the alt text of images. The text of hidden elements is
+// only included if includeHidden is true.
+func textContent(n *html.Node, includeHidden bool) string {
+	var sb strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			sb.WriteString(n.Data)
+			return
+		case html.ElementNode:
+			if !includeHidden && isHidden(n) {
+				return
+			}
+			switch n.DataAtom {
+			case atom.Img, atom.Area:
+				sb.WriteString(" " + attrValue(n, "alt") + " ")
+				return
+			case atom.Svg:
+				sb.WriteString(" " + attrValue(n, "aria-label") + " ")
+			case atom.Script, atom.Style, atom.Template:
+				return
+			}
+			if name := attrValue(n, "aria-label"); name != "" && n.DataAtom != atom.Svg {
+				sb.WriteString(" " + name + " ")
+				return
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
+
+// isHidden returns true if the element, or one of its ancestors, is hidden
+// from assistive technology.
+func isHidden(n *html.Node) bool {
+	if n.DataAtom == atom.Input && inputType(n) == "hidden" {
+		return true
+	}
+	for ; n != nil; n = n.Parent {
+		if n.Type == html.ElementNode && (hasAttr(n, "hidden") || attrValue(n, "aria-hidden") == "true") {
+			return true
+		}
+	}
+	return false
+}
+
+func inputType(n *html.Node) string {
+	t := strings.ToLower(strings.TrimSpace(attrValue(n, "type")))
+	if t == "" {
+		return "text"
+	}
+	return t
+}
+
+// explicitRole returns the first role in the role attribute. Fallback roles
+// are ignored, so that an invalid first role is reported.
+func explicitRole(n *html.Node) string {
+	fields := strings.Fields(strings.ToLower(attrValue(n, "role")))
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+func attrValue(n *html.Node, name string) string {
+	v, _ := attr(n, name)
+	return v
+}
+
+func hasAttr(n *html.Node, name string) bool {
+	_, ok := attr(n, name)
+	return ok
+}
+
+func hasAttrValue(n *html.Node, name string) bool {
+	return strings.TrimSpace(attrValue(n, name)) != ""
+}
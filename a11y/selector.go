@@ -0,0 +1,65 @@
+package a11y
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// selector returns a CSS selector that matches the element. The selector starts
+// at the nearest ancestor with a unique id. In fragments, the html, head and
+// body elements that were added by the parser are left out.
+func (d *document) selector(n *html.Node) string {
+	var parts []string
+	for e := n; e != nil && e.Type == html.ElementNode; e = e.Parent {
+		if id, ok := attr(e, "id"); ok && d.idCounts[id] == 1 && isIdent(id) {
+			parts = append(parts, "#"+id)
+			break
+		}
+		if d.fragment && e != n && isImplied(e) {
+			break
+		}
+		parts = append(parts, e.Data+nthOfType(e))
+	}
+	for i, j := 0, len(parts)-1; i < j; i, j = i+1, j-1 {
+		parts[i], parts[j] = parts[j], parts[i]
+	}
+	return strings.Join(parts, " > ")
+}
+
+// isImplied returns true for the elements that the parser adds to fragments.
+func isImplied(n *html.Node) bool {
+	return n.DataAtom == atom.Html || n.DataAtom == atom.Head || n.DataAtom == atom.Body
+}
+
+// nthOfType returns an :nth-of-type pseudo-class if the element has siblings of
+// the same type.
+func nthOfType(n *html.Node) string {
+	if n.Parent == nil {
+		return ""
+	}
+	var index, count int
+	for c := n.Parent.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode || c.Data != n.Data {
+			continue
+		}
+		count++
+		if c == n {
+			index = count
+		}
+	}
+	if count < 2 {
+		return ""
+	}
+	return fmt.Sprintf(":nth-of-type(%d)", index)
+}
+
+var identifier = regexp.MustCompile(`^-?[_a-zA-Z][_a-zA-Z0-9-]*$`)
+
+// isIdent returns true if the id can be used in a selector without escaping.
+func isIdent(id string) bool {
+	return identifier.MatchString(id)
+}
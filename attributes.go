@@ -0,0 +1,220 @@
+package templ
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Attributer is implemented by types that can be spread onto an element
+// using the { attrs... } syntax.
+type Attributer interface {
+	// Items returns the attributes in the order they should be rendered.
+	Items() []KeyValue[string, any]
+}
+
+// Attributes is an alias to map[string]any made for spread attributes.
+type Attributes map[string]any
+
+// Items returns the attributes sorted by key.
+func (a Attributes) Items() []KeyValue[string, any] {
+	items := make([]KeyValue[string, any], len(a))
+	for i, key := range sortedKeys(a) {
+		items[i] = KV(key, a[key])
+	}
+	return items
+}
+
+// OrderedAttributes is a list of attributes that are rendered in the order
+// they were added, rather than sorted by key.
+type OrderedAttributes []KeyValue[string, any]
+
+// Items returns the attributes in insertion order.
+func (a OrderedAttributes) Items() []KeyValue[string, any] {
+	return a
+}
+
+// SpreadItems returns the items of spread attributes, and is used by generated
+// code. A nil value has no items.
+func SpreadItems(attributes Attributer) []KeyValue[string, any] {
+	if attributes == nil {
+		return nil
+	}
+	return attributes.Items()
+}
+
+// sortedKeys returns the keys of a map in sorted order.
+func sortedKeys(m map[string]any) (keys []string) {
+	keys = make([]string, len(m))
+	var i int
+	for k := range m {
+		keys[i] = k
+		i++
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// MergeAttributes combines the attributes into a single set, keeping the
+// position of the first occurrence of each key.
+//
+// Values of the class attribute are concatenated, with duplicate class names
+// removed. Values of the style attribute are joined into a single declaration
+// list. For all other attributes, the last value wins.
+func MergeAttributes(attributes ...Attributer) (merged OrderedAttributes) {
+	keyToIndex := make(map[string]int)
+	for _, a := range attributes {
+		if a == nil {
+			continue
+		}
+		for _, item := range a.Items() {
+			key := strings.ToLower(item.Key)
+			i, exists := keyToIndex[key]
+			if !exists {
+				keyToIndex[key] = len(merged)
+				merged = append(merged, item)
+				continue
+			}
+			merged[i].Value = mergeAttributeValue(key, merged[i].Value, item.Value)
+		}
+	}
+	return merged
+}
+
+func mergeAttributeValue(key string, previous, next any) any {
+	if key != "class" && key != "style" {
+		return next
+	}
+	p, previousRendered := attributeValueString(previous)
+	n, nextRendered := attributeValueString(next)
+	if !previousRendered {
+		return next
+	}
+	if !nextRendered {
+		return previous
+	}
+	if key == "class" {
+		return Classes(strings.Fields(p), strings.Fields(n)).String()
+	}
+	var declarations []string
+	for _, s := range []string{p, n} {
+		if s = strings.TrimRight(strings.TrimSpace(s), ";"); s != "" {
+			declarations = append(declarations, s)
+		}
+	}
+	return strings.Join(declarations, "; ")
+}
+
+// attributeValueString returns the string value of an attribute, and whether
+// the attribute would be rendered at all. Boolean attributes have an empty value.
+func attributeValueString(value any) (s string, rendered bool) {
+	switch value := value.(type) {
+	case string:
+		return value, true
+	case *string:
+		if value != nil {
+			return *value, true
+		}
+	case bool:
+		return "", value
+	case *bool:
+		return "", value != nil && *value
+	case KeyValue[string, bool]:
+		if value.Value {
+			return value.Key, true
+		}
+	case KeyValue[bool, bool]:
+		return "", value.Value && value.Key
+	case func() bool:
+		return "", value()
+	case SafeURL:
+		return string(value), true
+	case ComponentScript:
+		return value.Call, true
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, uintptr:
+		return fmt.Sprint(value), true
+	case float32:
+		return strconv.FormatFloat(float64(value), 'f', -1, 32), true
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), true
+	case fmt.Stringer:
+		return value.String(), true
+	}
+	return "", false
+}
+
+// urlAttributes are attributes that contain URLs, and are sanitized with URL
+// unless a SafeURL is provided.
+var urlAttributes = map[string]struct{}{
+	"action":     {},
+	"background": {},
+	"cite":       {},
+	"formaction": {},
+	"href":       {},
+	"icon":       {},
+	"manifest":   {},
+	"poster":     {},
+	"src":        {},
+	"xlink:href": {},
+}
+
+// IsURLAttribute returns true if the value of the attribute is a URL.
+func IsURLAttribute(key string) bool {
+	_, ok := urlAttributes[strings.ToLower(key)]
+	return ok
+}
+
+func writeStrings(w io.Writer, ss ...string) (err error) {
+	for _, s := range ss {
+		if _, err = io.WriteString(w, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RenderAttributes renders the attributes to the writer, in the order
+// returned by the Items method of the attributes.
+func RenderAttributes(ctx context.Context, w io.Writer, attributes Attributer) (err error) {
+	if attributes == nil {
+		return nil
+	}
+	for _, item := range attributes.Items() {
+		if err = renderAttribute(w, item.Key, item.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func renderAttribute(w io.Writer, key string, value any) (err error) {
+	switch value := value.(type) {
+	case SafeURL:
+		return writeStrings(w, ` `, EscapeString(key), `="`, EscapeString(string(value)), `"`)
+	case ComponentScript:
+		// The Call value is already HTML escaped.
+		return writeStrings(w, ` `, EscapeString(key), `="`, value.Call, `"`)
+	}
+	s, rendered := attributeValueString(value)
+	if !rendered {
+		return nil
+	}
+	if isBoolAttributeValue(value) {
+		return writeStrings(w, ` `, EscapeString(key))
+	}
+	if IsURLAttribute(key) {
+		s = string(URL(s))
+	}
+	return writeStrings(w, ` `, EscapeString(key), `="`, EscapeString(s), `"`)
+}
+
+func isBoolAttributeValue(value any) bool {
+	switch value.(type) {
+	case bool, *bool, KeyValue[bool, bool], func() bool:
+		return true
+	}
+	return false
+}
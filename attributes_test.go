@@ -0,0 +1,198 @@
+package templ_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/a-h/templ"
+	"github.com/google/go-cmp/cmp"
+)
+
+type stringer string
+
+func (s stringer) String() string { return string(s) }
+
+func TestRenderAttributes(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    templ.Attributer
+		expected string
+	}{
+		{
+			name:     "nil attributes render nothing",
+			input:    nil,
+			expected: "",
+		},
+		{
+			name: "attributes are sorted by key",
+			input: templ.Attributes{
+				"b": "2",
+				"a": "1",
+			},
+			expected: ` a="1" b="2"`,
+		},
+		{
+			name: "ordered attributes are rendered in insertion order",
+			input: templ.OrderedAttributes{
+				templ.KV[string, any]("b", "2"),
+				templ.KV[string, any]("a", "1"),
+			},
+			expected: ` b="2" a="1"`,
+		},
+		{
+			name: "scalar values are rendered",
+			input: templ.OrderedAttributes{
+				templ.KV[string, any]("int", 1),
+				templ.KV[string, any]("uint8", uint8(2)),
+				templ.KV[string, any]("float32", float32(1.25)),
+				templ.KV[string, any]("float64", 0.1),
+				templ.KV[string, any]("stringer", stringer("<value>")),
+			},
+			expected: ` int="1" uint8="2" float32="1.25" float64="0.1" stringer="&lt;value&gt;"`,
+		},
+		{
+			name: "boolean values are rendered as boolean attributes",
+			input: templ.OrderedAttributes{
+				templ.KV[string, any]("checked", true),
+				templ.KV[string, any]("disabled", false),
+				templ.KV[string, any]("required", templ.KV(true, true)),
+				templ.KV[string, any]("hidden", func() bool { return false }),
+			},
+			expected: ` checked required`,
+		},
+		{
+			name: "URL attributes are sanitized",
+			input: templ.OrderedAttributes{
+				templ.KV[string, any]("href", "javascript:alert(1)"),
+				templ.KV[string, any]("src", "/image.png"),
+			},
+			expected: ` href="about:invalid#TemplFailedSanitizationURL" src="/image.png"`,
+		},
+		{
+			name: "safe URLs are not sanitized",
+			input: templ.OrderedAttributes{
+				templ.KV[string, any]("href", templ.SafeURL("javascript:alert(1)")),
+			},
+			expected: ` href="javascript:alert(1)"`,
+		},
+		{
+			name: "unsupported types are not rendered",
+			input: templ.OrderedAttributes{
+				templ.KV[string, any]("struct", struct{}{}),
+			},
+			expected: ``,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var sb strings.Builder
+			if err := templ.RenderAttributes(context.Background(), &sb, tt.input); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.expected, sb.String()); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestMergeAttributes(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []templ.Attributer
+		expected templ.OrderedAttributes
+	}{
+		{
+			name:     "no attributes",
+			input:    nil,
+			expected: nil,
+		},
+		{
+			name: "class values are concatenated without duplicates",
+			input: []templ.Attributer{
+				templ.OrderedAttributes{templ.KV[string, any]("class", "a b")},
+				templ.Attributes{"class": "b c"},
+			},
+			expected: templ.OrderedAttributes{templ.KV[string, any]("class", "a b c")},
+		},
+		{
+			name: "style values are combined",
+			input: []templ.Attributer{
+				templ.OrderedAttributes{templ.KV[string, any]("style", "color: red;")},
+				templ.OrderedAttributes{templ.KV[string, any]("style", "padding: 0")},
+			},
+			expected: templ.OrderedAttributes{templ.KV[string, any]("style", "color: red; padding: 0")},
+		},
+		{
+			name: "later values win, in the position of the first",
+			input: []templ.Attributer{
+				templ.OrderedAttributes{
+					templ.KV[string, any]("id", "a"),
+					templ.KV[string, any]("type", "button"),
+				},
+				templ.OrderedAttributes{templ.KV[string, any]("ID", "b")},
+			},
+			expected: templ.OrderedAttributes{
+				templ.KV[string, any]("id", "b"),
+				templ.KV[string, any]("type", "button"),
+			},
+		},
+		{
+			name: "class values that don't render are ignored",
+			input: []templ.Attributer{
+				templ.OrderedAttributes{templ.KV[string, any]("class", "a")},
+				templ.OrderedAttributes{templ.KV[string, any]("class", templ.KV("b", false))},
+			},
+			expected: templ.OrderedAttributes{templ.KV[string, any]("class", "a")},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			actual := templ.MergeAttributes(tt.input...)
+			if diff := cmp.Diff(tt.expected, actual); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestSpreadItems(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    templ.Attributer
+		expected []templ.KeyValue[string, any]
+	}{
+		{
+			name:     "nil has no items",
+			input:    nil,
+			expected: nil,
+		},
+		{
+			name:     "nil maps have no items",
+			input:    templ.Attributes(nil),
+			expected: []templ.KeyValue[string, any]{},
+		},
+		{
+			name:     "maps are sorted by key",
+			input:    templ.Attributes(map[string]any{"b": "2", "a": "1"}),
+			expected: []templ.KeyValue[string, any]{templ.KV[string, any]("a", "1"), templ.KV[string, any]("b", "2")},
+		},
+		{
+			name:     "attributers return their items",
+			input:    templ.OrderedAttributes{templ.KV[string, any]("b", "2")},
+			expected: []templ.KeyValue[string, any]{templ.KV[string, any]("b", "2")},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			actual := templ.SpreadItems(tt.input)
+			if diff := cmp.Diff(tt.expected, actual); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
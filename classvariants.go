@@ -0,0 +1,162 @@
+package templ
+
+import (
+	"sort"
+	"strings"
+)
+
+// ClassVariants defines the CSS classes of a component in terms of its base
+// classes, and the classes added by each value of its variant dimensions,
+// e.g. size or intent.
+//
+//	var buttonClasses = templ.ClassVariants{
+//		Base: "btn",
+//		Variants: map[string]map[string]string{
+//			"size":   {"sm": "p-2 text-sm", "lg": "p-4 text-lg"},
+//			"intent": {"primary": "bg-blue", "danger": "bg-red"},
+//		},
+//		DefaultVariants: map[string]string{"size": "sm", "intent": "primary"},
+//	}
+//
+//	<button class={ buttonClasses.Class(templ.Variant("size", p.Size)) }>
+type ClassVariants struct {
+	// Base classes are always included.
+	Base string
+	// Variants maps the name of each variant dimension to the classes added
+	// for each of its values. Dimensions are applied in alphabetical order.
+	Variants map[string]map[string]string
+	// CompoundVariants add classes when several variant values are selected
+	// together. They are applied after Variants, in order.
+	CompoundVariants []CompoundVariant
+	// DefaultVariants are the values used for dimensions that are not selected.
+	DefaultVariants map[string]string
+	// Resolver is an optional function used to remove conflicting classes
+	// from the result, e.g. NewClassConflictResolver.
+	Resolver ClassConflictResolver
+}
+
+// CompoundVariant adds Class when all of the Variants are selected.
+type CompoundVariant struct {
+	Variants map[string]string
+	Class    string
+}
+
+// VariantValue selects the value of a variant dimension.
+type VariantValue struct {
+	Name  string
+	Value string
+}
+
+// Variant selects the value of a variant dimension. The value can be any
+// string type, so that components can expose typed variant props.
+func Variant[T ~string](name string, value T) VariantValue {
+	return VariantValue{Name: name, Value: string(value)}
+}
+
+// Class returns the class names for the selected variants.
+//
+// Items can be VariantValue values, or any value supported by Classes. Class
+// names that aren't variants are added after the variant classes, so that
+// they take precedence when a Resolver is used.
+func (cv ClassVariants) Class(items ...any) string {
+	selected := make(map[string]string, len(cv.DefaultVariants))
+	for name, value := range cv.DefaultVariants {
+		selected[name] = value
+	}
+	var additional CSSClasses
+	for _, item := range items {
+		if v, ok := item.(VariantValue); ok {
+			if v.Value != "" {
+				selected[v.Name] = v.Value
+			}
+			continue
+		}
+		additional = append(additional, item)
+	}
+
+	classes := strings.Fields(cv.Base)
+	dimensions := make([]string, 0, len(cv.Variants))
+	for name := range cv.Variants {
+		dimensions = append(dimensions, name)
+	}
+	sort.Strings(dimensions)
+	for _, name := range dimensions {
+		classes = append(classes, strings.Fields(cv.Variants[name][selected[name]])...)
+	}
+	for _, compound := range cv.CompoundVariants {
+		if compound.matches(selected) {
+			classes = append(classes, strings.Fields(compound.Class)...)
+		}
+	}
+	if len(additional) > 0 {
+		classes = append(classes, strings.Fields(additional.String())...)
+	}
+
+	if cv.Resolver != nil {
+		classes = cv.Resolver(classes)
+	}
+	return Classes(classes).String()
+}
+
+func (cv CompoundVariant) matches(selected map[string]string) bool {
+	for name, value := range cv.Variants {
+		if selected[name] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// ClassConflictResolver removes conflicting class names from a list of
+// classes. Where classes conflict, later classes should win.
+type ClassConflictResolver func(classes []string) []string
+
+// NewClassConflictResolver creates a ClassConflictResolver for utility CSS
+// frameworks such as Tailwind. Classes conflict if they belong to the same
+// group and have the same modifiers, e.g. "p-2" and "p-4" with the group "p",
+// or "hover:bg-red" and "hover:bg-blue" with the group "bg".
+//
+// A class belongs to a group if it's equal to the group name, or starts with
+// the group name followed by a hyphen. If a class matches more than one
+// group, the longest group name is used. Classes that don't belong to a group
+// never conflict.
+func NewClassConflictResolver(groups ...string) ClassConflictResolver {
+	sorted := make([]string, len(groups))
+	copy(sorted, groups)
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+	return func(classes []string) []string {
+		seen := make(map[string]struct{}, len(classes))
+		kept := make([]string, 0, len(classes))
+		for i := len(classes) - 1; i >= 0; i-- {
+			key, ok := classConflictKey(sorted, classes[i])
+			if ok {
+				if _, conflicts := seen[key]; conflicts {
+					continue
+				}
+				seen[key] = struct{}{}
+			}
+			kept = append(kept, classes[i])
+		}
+		// Restore the original order.
+		for i, j := 0, len(kept)-1; i < j; i, j = i+1, j-1 {
+			kept[i], kept[j] = kept[j], kept[i]
+		}
+		return kept
+	}
+}
+
+func classConflictKey(groups []string, class string) (key string, ok bool) {
+	var modifiers string
+	utility := class
+	if i := strings.LastIndex(class, ":"); i >= 0 {
+		modifiers, utility = class[:i+1], class[i+1:]
+	}
+	utility = strings.TrimPrefix(utility, "!")
+	utility = strings.TrimPrefix(utility, "-")
+	for _, group := range groups {
+		if utility == group || strings.HasPrefix(utility, group+"-") {
+			return modifiers + group, true
+		}
+	}
+	return "", false
+}
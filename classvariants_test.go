@@ -0,0 +1,144 @@
+package templ_test
+
+import (
+	"testing"
+
+	"github.com/a-h/templ"
+	"github.com/google/go-cmp/cmp"
+)
+
+type buttonSize string
+
+const (
+	buttonSizeSmall buttonSize = "sm"
+	buttonSizeLarge buttonSize = "lg"
+)
+
+func TestClassVariants(t *testing.T) {
+	button := templ.ClassVariants{
+		Base: "btn rounded",
+		Variants: map[string]map[string]string{
+			"size": {
+				"sm": "p-2 text-sm",
+				"lg": "p-4 text-lg",
+			},
+			"intent": {
+				"primary": "bg-blue",
+				"danger":  "bg-red",
+			},
+		},
+		CompoundVariants: []templ.CompoundVariant{
+			{
+				Variants: map[string]string{"size": "lg", "intent": "danger"},
+				Class:    "uppercase",
+			},
+		},
+		DefaultVariants: map[string]string{
+			"size":   "sm",
+			"intent": "primary",
+		},
+	}
+	resolved := button
+	resolved.Resolver = templ.NewClassConflictResolver("p", "bg")
+
+	tests := []struct {
+		name     string
+		variants templ.ClassVariants
+		input    []any
+		expected string
+	}{
+		{
+			name:     "defaults are used when no variants are selected",
+			variants: button,
+			expected: "btn rounded bg-blue p-2 text-sm",
+		},
+		{
+			name:     "typed variant values can be selected",
+			variants: button,
+			input:    []any{templ.Variant("size", buttonSizeLarge)},
+			expected: "btn rounded bg-blue p-4 text-lg",
+		},
+		{
+			name:     "empty variant values use the default",
+			variants: button,
+			input:    []any{templ.Variant("size", buttonSize(""))},
+			expected: "btn rounded bg-blue p-2 text-sm",
+		},
+		{
+			name:     "unknown variant values add no classes",
+			variants: button,
+			input:    []any{templ.Variant("intent", "unknown")},
+			expected: "btn rounded p-2 text-sm",
+		},
+		{
+			name:     "compound variants are added when all variants match",
+			variants: button,
+			input:    []any{templ.Variant("size", buttonSizeLarge), templ.Variant("intent", "danger")},
+			expected: "btn rounded bg-red p-4 text-lg uppercase",
+		},
+		{
+			name:     "additional classes are added after variants",
+			variants: button,
+			input:    []any{templ.Variant("size", buttonSizeSmall), "p-8", templ.KV("hidden", false)},
+			expected: "btn rounded bg-blue p-2 text-sm p-8",
+		},
+		{
+			name:     "the resolver removes conflicting classes",
+			variants: resolved,
+			input:    []any{"p-8", "bg-green"},
+			expected: "btn rounded text-sm p-8 bg-green",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			actual := tt.variants.Class(tt.input...)
+			if diff := cmp.Diff(tt.expected, actual); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestClassConflictResolver(t *testing.T) {
+	resolve := templ.NewClassConflictResolver("p", "px", "bg", "m")
+	tests := []struct {
+		name     string
+		input    []string
+		expected []string
+	}{
+		{
+			name:     "later classes in the same group win",
+			input:    []string{"p-2", "font-bold", "p-4"},
+			expected: []string{"font-bold", "p-4"},
+		},
+		{
+			name:     "the longest group is used",
+			input:    []string{"px-2", "p-2", "px-4"},
+			expected: []string{"p-2", "px-4"},
+		},
+		{
+			name:     "modifiers are part of the group",
+			input:    []string{"bg-red", "hover:bg-red", "bg-blue"},
+			expected: []string{"hover:bg-red", "bg-blue"},
+		},
+		{
+			name:     "negative and important values conflict with positive values",
+			input:    []string{"-m-2", "m-4", "!m-8"},
+			expected: []string{"!m-8"},
+		},
+		{
+			name:     "classes that aren't in a group are kept",
+			input:    []string{"btn", "btn"},
+			expected: []string{"btn", "btn"},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.expected, resolve(tt.input)); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
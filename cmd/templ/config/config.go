@@ -0,0 +1,395 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/a-h/templ/parser/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// FileNames are the names of the project config file, in order of precedence.
+var FileNames = []string{".templ.yaml", "templ.yaml"}
+
+// Config is the templ project configuration.
+//
+// Options that aren't set in the config file are nil or empty, so that the
+// CLI can tell the difference between a value that's unset, and one that's
+// explicitly set to the default.
+type Config struct {
+	// Path of the config file, or empty if no config file was found.
+	Path string `yaml:"-" json:"path,omitempty"`
+	// Generate options for `templ generate`.
+	Generate Generate `yaml:"generate" json:"generate"`
+	// Watch options for `templ generate -watch`.
+	Watch Watch `yaml:"watch" json:"watch"`
+	// Proxy options for `templ generate -watch -proxy`.
+	Proxy Proxy `yaml:"proxy" json:"proxy"`
+	// Commands to run after generating code.
+	Commands []string `yaml:"commands" json:"commands,omitempty"`
+	// Processes to run after generating code.
+	Processes []Process `yaml:"processes" json:"processes,omitempty"`
+	// Fmt options for `templ fmt`.
+	Fmt Fmt `yaml:"fmt" json:"fmt"`
+	// Lint rules applied to the diagnostics reported during generation.
+	Lint Lint `yaml:"lint" json:"lint"`
+}
+
+// Options are generator options that can be set for the whole project, or for
+// a directory.
+type Options struct {
+	IncludeVersion   *bool `yaml:"include-version" json:"include-version,omitempty"`
+	IncludeTimestamp *bool `yaml:"include-timestamp" json:"include-timestamp,omitempty"`
+	Minify           *bool `yaml:"minify" json:"minify,omitempty"`
+}
+
+type Generate struct {
+	Options `yaml:",inline"`
+	Workers int   `yaml:"workers" json:"workers,omitempty"`
+	Lazy    *bool `yaml:"lazy" json:"lazy,omitempty"`
+	// Cache skips templates that haven't changed since they were last generated.
+	Cache                   *bool `yaml:"cache" json:"cache,omitempty"`
+	KeepOrphanedFiles       *bool `yaml:"keep-orphaned-files" json:"keep-orphaned-files,omitempty"`
+	SourceMapVisualisations *bool `yaml:"source-map-visualisations" json:"source-map-visualisations,omitempty"`
+	// Directories override the generator options for templates within them.
+	Directories []Directory `yaml:"directories" json:"directories,omitempty"`
+}
+
+type Directory struct {
+	// Path of the directory. Relative paths are relative to the config file.
+	Path    string `yaml:"path" json:"path"`
+	Options `yaml:",inline"`
+}
+
+type Watch struct {
+	// Include is a list of glob patterns of additional files to watch, and
+	// the action to take when they change.
+	Include []WatchPattern `yaml:"include" json:"include,omitempty"`
+	// Exclude is a list of glob patterns of files and directories to ignore.
+	Exclude []string `yaml:"exclude" json:"exclude,omitempty"`
+}
+
+// WatchPattern maps a glob pattern of files to the action to take when they change.
+//
+// In the config file, a pattern can be written as a string, in which case the
+// action is "reload".
+type WatchPattern struct {
+	Pattern string      `yaml:"pattern" json:"pattern"`
+	Action  WatchAction `yaml:"action" json:"action"`
+}
+
+func (wp *WatchPattern) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		wp.Pattern = value.Value
+		wp.Action = WatchActionReload
+		return nil
+	}
+	type watchPattern WatchPattern
+	if err := value.Decode((*watchPattern)(wp)); err != nil {
+		return err
+	}
+	if wp.Action == "" {
+		wp.Action = WatchActionReload
+	}
+	return nil
+}
+
+// WatchAction is the action taken when a watched file changes.
+type WatchAction string
+
+const (
+	// WatchActionGenerate regenerates all templates, then reruns the commands
+	// and reloads the browser.
+	WatchActionGenerate WatchAction = "generate"
+	// WatchActionRun reruns the commands, then reloads the browser.
+	WatchActionRun WatchAction = "run"
+	// WatchActionReload reloads the browser. CSS files are swapped in place.
+	WatchActionReload WatchAction = "reload"
+)
+
+type Proxy struct {
+	URL         string `yaml:"url" json:"url,omitempty"`
+	Port        int    `yaml:"port" json:"port,omitempty"`
+	Bind        string `yaml:"bind" json:"bind,omitempty"`
+	OpenBrowser *bool  `yaml:"open-browser" json:"open-browser,omitempty"`
+	// InsecureSkipVerify disables verification of the certificate of HTTPS targets.
+	InsecureSkipVerify bool `yaml:"insecure-skip-verify" json:"insecure-skip-verify,omitempty"`
+	// CAFile is a PEM file of certificate authorities trusted for HTTPS targets.
+	// Relative paths are relative to the config file.
+	CAFile string `yaml:"ca-file" json:"ca-file,omitempty"`
+	// Prefix is the path prefix of the proxy's endpoints. Defaults to /_templ.
+	Prefix string `yaml:"prefix" json:"prefix,omitempty"`
+	// TLS serves the proxy over HTTPS, using a generated local certificate.
+	TLS bool `yaml:"tls" json:"tls,omitempty"`
+}
+
+// Process is a named process, with an optional build step and readiness check.
+type Process struct {
+	Name string `yaml:"name" json:"name,omitempty"`
+	// Build is run to completion before Run is started.
+	Build string `yaml:"build" json:"build,omitempty"`
+	Run   string `yaml:"run" json:"run,omitempty"`
+	// Ready is a readiness check, e.g. "tcp://localhost:8080" or "http://localhost:8080/health".
+	Ready string `yaml:"ready" json:"ready,omitempty"`
+}
+
+type Fmt struct {
+	Workers int `yaml:"workers" json:"workers,omitempty"`
+	// MaxLineWidth wraps the attributes of open tags that are wider, one per
+	// line. Zero disables wrapping.
+	MaxLineWidth int `yaml:"max-line-width" json:"max-line-width,omitempty"`
+	// IndentStyle is "tab" or "space". Defaults to "tab".
+	IndentStyle IndentStyle `yaml:"indent-style" json:"indent-style,omitempty"`
+	// IndentSize is the number of spaces per indent, and the width of a tab.
+	// Defaults to 4.
+	IndentSize int `yaml:"indent-size" json:"indent-size,omitempty"`
+	// AttributeOrder is "alphabetical" or "canonical". By default, attributes
+	// aren't reordered.
+	AttributeOrder parser.AttributeOrder `yaml:"attribute-order" json:"attribute-order,omitempty"`
+	// SortClasses sorts the classes in constant class attributes.
+	SortClasses bool `yaml:"sort-classes" json:"sort-classes,omitempty"`
+}
+
+// IndentStyle of formatted templates.
+type IndentStyle string
+
+const (
+	IndentStyleTab   IndentStyle = "tab"
+	IndentStyleSpace IndentStyle = "space"
+)
+
+// FormatOptions returns the parser options used to format templates.
+func (f Fmt) FormatOptions() parser.FormatOptions {
+	opts := parser.FormatOptions{
+		MaxLineWidth:   f.MaxLineWidth,
+		TabWidth:       f.IndentSize,
+		AttributeOrder: f.AttributeOrder,
+		SortClasses:    f.SortClasses,
+	}
+	if f.IndentStyle == IndentStyleSpace {
+		size := f.IndentSize
+		if size == 0 {
+			size = 4
+		}
+		opts.Indent = strings.Repeat(" ", size)
+	}
+	return opts
+}
+
+type Lint struct {
+	// Rules maps the name of a diagnostic rule to its severity.
+	Rules map[string]Severity `yaml:"rules" json:"rules,omitempty"`
+}
+
+// Severity of a lint rule.
+type Severity string
+
+const (
+	SeverityOff   Severity = "off"
+	SeverityWarn  Severity = "warn"
+	SeverityError Severity = "error"
+)
+
+// Find walks up the directory tree, starting at dir, until it finds a
+// directory containing a config file. If no config file is found, an empty
+// string is returned.
+func Find(dir string) (fileName string, err error) {
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	if fi, err := os.Stat(dir); err == nil && !fi.IsDir() {
+		dir = filepath.Dir(dir)
+	}
+	for {
+		for _, name := range FileNames {
+			fileName = filepath.Join(dir, name)
+			_, err := os.Stat(fileName)
+			if err == nil {
+				return fileName, nil
+			}
+			if !os.IsNotExist(err) {
+				return "", fmt.Errorf("failed to stat config file: %w", err)
+			}
+		}
+		// Move up.
+		prev := dir
+		dir = filepath.Dir(dir)
+		if dir == prev {
+			return "", nil
+		}
+	}
+}
+
+// Load finds the config file for dir and reads it. If no config file is
+// found, an empty config is returned.
+func Load(dir string) (c Config, err error) {
+	fileName, err := Find(dir)
+	if err != nil || fileName == "" {
+		return c, err
+	}
+	f, err := os.Open(fileName)
+	if err != nil {
+		return c, fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer f.Close()
+	c, err = Parse(f)
+	if err != nil {
+		return c, fmt.Errorf("%s: %w", fileName, err)
+	}
+	c.Path = fileName
+	for i, d := range c.Generate.Directories {
+		if !filepath.IsAbs(d.Path) {
+			c.Generate.Directories[i].Path = filepath.Join(filepath.Dir(fileName), d.Path)
+		}
+	}
+	if c.Proxy.CAFile != "" && !filepath.IsAbs(c.Proxy.CAFile) {
+		c.Proxy.CAFile = filepath.Join(filepath.Dir(fileName), c.Proxy.CAFile)
+	}
+	return c, nil
+}
+
+// Parse a config file.
+func Parse(r io.Reader) (c Config, err error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return c, fmt.Errorf("failed to read config: %w", err)
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err = dec.Decode(&c); err != nil && !errors.Is(err, io.EOF) {
+		return c, fmt.Errorf("failed to parse config: %w", err)
+	}
+	return c, c.Validate()
+}
+
+// Validate the config.
+func (c Config) Validate() (err error) {
+	for i, d := range c.Generate.Directories {
+		if d.Path == "" {
+			err = errors.Join(err, fmt.Errorf("generate.directories[%d]: path is required", i))
+		}
+	}
+	for i, wp := range c.Watch.Include {
+		if _, matchErr := path.Match(wp.Pattern, ""); matchErr != nil {
+			err = errors.Join(err, fmt.Errorf("watch.include[%d]: invalid pattern %q: %w", i, wp.Pattern, matchErr))
+		}
+		switch wp.Action {
+		case WatchActionGenerate, WatchActionRun, WatchActionReload:
+		default:
+			err = errors.Join(err, fmt.Errorf("watch.include[%d]: invalid action %q, expected %q, %q or %q", i, wp.Action, WatchActionGenerate, WatchActionRun, WatchActionReload))
+		}
+	}
+	for i, pattern := range c.Watch.Exclude {
+		if _, matchErr := path.Match(pattern, ""); matchErr != nil {
+			err = errors.Join(err, fmt.Errorf("watch.exclude[%d]: invalid pattern %q: %w", i, pattern, matchErr))
+		}
+	}
+	for i, p := range c.Processes {
+		if p.Build == "" && p.Run == "" {
+			err = errors.Join(err, fmt.Errorf("processes[%d]: build or run is required", i))
+		}
+	}
+	if c.Fmt.MaxLineWidth < 0 {
+		err = errors.Join(err, fmt.Errorf("fmt.max-line-width: must not be negative"))
+	}
+	switch c.Fmt.IndentStyle {
+	case "", IndentStyleTab, IndentStyleSpace:
+	default:
+		err = errors.Join(err, fmt.Errorf("fmt.indent-style: invalid style %q, expected %q or %q", c.Fmt.IndentStyle, IndentStyleTab, IndentStyleSpace))
+	}
+	if c.Fmt.IndentSize < 0 {
+		err = errors.Join(err, fmt.Errorf("fmt.indent-size: must not be negative"))
+	}
+	switch c.Fmt.AttributeOrder {
+	case parser.AttributeOrderNone, parser.AttributeOrderAlphabetical, parser.AttributeOrderCanonical:
+	default:
+		err = errors.Join(err, fmt.Errorf("fmt.attribute-order: invalid order %q, expected %q or %q", c.Fmt.AttributeOrder, parser.AttributeOrderAlphabetical, parser.AttributeOrderCanonical))
+	}
+	for rule, severity := range c.Lint.Rules {
+		switch severity {
+		case SeverityOff, SeverityWarn, SeverityError:
+		default:
+			err = errors.Join(err, fmt.Errorf("lint.rules.%s: invalid severity %q, expected %q, %q or %q", rule, severity, SeverityOff, SeverityWarn, SeverityError))
+		}
+	}
+	return err
+}
+
+// WithDefaults returns a copy of the config, with unset options set to the
+// defaults used by the templ CLI.
+func (c Config) WithDefaults() Config {
+	c.Generate.Options = c.Generate.Options.withDefaults(Options{
+		IncludeVersion:   ptr(true),
+		IncludeTimestamp: ptr(false),
+		Minify:           ptr(false),
+	})
+	if c.Generate.Workers == 0 {
+		c.Generate.Workers = runtime.NumCPU()
+	}
+	c.Generate.Lazy = valueOrDefault(c.Generate.Lazy, false)
+	c.Generate.Cache = valueOrDefault(c.Generate.Cache, false)
+	c.Generate.KeepOrphanedFiles = valueOrDefault(c.Generate.KeepOrphanedFiles, false)
+	c.Generate.SourceMapVisualisations = valueOrDefault(c.Generate.SourceMapVisualisations, false)
+	c.Generate.Directories = append([]Directory{}, c.Generate.Directories...)
+	for i, d := range c.Generate.Directories {
+		c.Generate.Directories[i].Options = d.Options.withDefaults(c.Generate.Options)
+	}
+	if c.Proxy.Port == 0 {
+		c.Proxy.Port = 7331
+	}
+	if c.Proxy.Bind == "" {
+		c.Proxy.Bind = "127.0.0.1"
+	}
+	c.Proxy.OpenBrowser = valueOrDefault(c.Proxy.OpenBrowser, true)
+	if c.Fmt.Workers == 0 {
+		c.Fmt.Workers = runtime.NumCPU()
+	}
+	return c
+}
+
+// GenerateOptions returns the generator options for a template file. The
+// options of the innermost directory that contains the file are used, and
+// unset options are set to the defaults.
+func (c Config) GenerateOptions(fileName string) Options {
+	c = c.WithDefaults()
+	opts := c.Generate.Options
+	var matched string
+	for _, d := range c.Generate.Directories {
+		rel, err := filepath.Rel(d.Path, fileName)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if len(d.Path) < len(matched) {
+			continue
+		}
+		matched = d.Path
+		opts = d.Options
+	}
+	return opts
+}
+
+func (o Options) withDefaults(defaults Options) Options {
+	o.IncludeVersion = valueOrDefault(o.IncludeVersion, *defaults.IncludeVersion)
+	o.IncludeTimestamp = valueOrDefault(o.IncludeTimestamp, *defaults.IncludeTimestamp)
+	o.Minify = valueOrDefault(o.Minify, *defaults.Minify)
+	return o
+}
+
+func valueOrDefault(v *bool, def bool) *bool {
+	if v == nil {
+		return &def
+	}
+	return v
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
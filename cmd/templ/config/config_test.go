@@ -0,0 +1,333 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/a-h/templ/parser/v2"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		expected      Config
+		expectedError string
+	}{
+		{
+			name:     "empty files are valid",
+			input:    "",
+			expected: Config{},
+		},
+		{
+			name: "all options can be set",
+			input: `generate:
+  include-version: false
+  minify: true
+  workers: 4
+  lazy: true
+  cache: true
+  directories:
+    - path: components
+      minify: false
+watch:
+  include:
+    - "*.css"
+    - pattern: "**/*.go"
+      action: run
+  exclude: ["tmp"]
+proxy:
+  url: http://localhost:8080
+  port: 7332
+  open-browser: false
+  insecure-skip-verify: true
+  ca-file: ca.pem
+  prefix: /__dev
+  tls: true
+commands:
+  - go run .
+processes:
+  - name: web
+    build: go build -o ./tmp/web .
+    run: ./tmp/web
+    ready: http://localhost:8080/health
+fmt:
+  workers: 2
+  max-line-width: 100
+  indent-style: space
+  indent-size: 2
+  attribute-order: canonical
+  sort-classes: true
+lint:
+  rules:
+    legacy-call-syntax: error
+`,
+			expected: Config{
+				Generate: Generate{
+					Options: Options{
+						IncludeVersion: ptr(false),
+						Minify:         ptr(true),
+					},
+					Workers: 4,
+					Lazy:    ptr(true),
+					Cache:   ptr(true),
+					Directories: []Directory{
+						{Path: "components", Options: Options{Minify: ptr(false)}},
+					},
+				},
+				Watch: Watch{
+					Include: []WatchPattern{
+						{Pattern: "*.css", Action: WatchActionReload},
+						{Pattern: "**/*.go", Action: WatchActionRun},
+					},
+					Exclude: []string{"tmp"},
+				},
+				Proxy: Proxy{
+					URL:                "http://localhost:8080",
+					Port:               7332,
+					OpenBrowser:        ptr(false),
+					InsecureSkipVerify: true,
+					CAFile:             "ca.pem",
+					Prefix:             "/__dev",
+					TLS:                true,
+				},
+				Commands: []string{"go run ."},
+				Processes: []Process{
+					{Name: "web", Build: "go build -o ./tmp/web .", Run: "./tmp/web", Ready: "http://localhost:8080/health"},
+				},
+				Fmt: Fmt{
+					Workers:        2,
+					MaxLineWidth:   100,
+					IndentStyle:    IndentStyleSpace,
+					IndentSize:     2,
+					AttributeOrder: parser.AttributeOrderCanonical,
+					SortClasses:    true,
+				},
+				Lint: Lint{
+					Rules: map[string]Severity{"legacy-call-syntax": SeverityError},
+				},
+			},
+		},
+		{
+			name:          "unknown fields are rejected",
+			input:         "generate:\n  minfy: true\n",
+			expectedError: "field minfy not found",
+		},
+		{
+			name:          "directories require a path",
+			input:         "generate:\n  directories:\n    - minify: true\n",
+			expectedError: "generate.directories[0]: path is required",
+		},
+		{
+			name:          "invalid watch patterns are rejected",
+			input:         "watch:\n  exclude: [\"[\"]\n",
+			expectedError: `watch.exclude[0]: invalid pattern "["`,
+		},
+		{
+			name:          "invalid watch actions are rejected",
+			input:         "watch:\n  include:\n    - pattern: \"*.go\"\n      action: restart\n",
+			expectedError: `watch.include[0]: invalid action "restart"`,
+		},
+		{
+			name:          "processes require a command",
+			input:         "processes:\n  - name: web\n",
+			expectedError: "processes[0]: build or run is required",
+		},
+		{
+			name:          "invalid indent styles are rejected",
+			input:         "fmt:\n  indent-style: tabs\n",
+			expectedError: `fmt.indent-style: invalid style "tabs"`,
+		},
+		{
+			name:          "invalid attribute orders are rejected",
+			input:         "fmt:\n  attribute-order: random\n",
+			expectedError: `fmt.attribute-order: invalid order "random"`,
+		},
+		{
+			name:          "invalid lint severities are rejected",
+			input:         "lint:\n  rules:\n    legacy-call-syntax: fatal\n",
+			expectedError: `lint.rules.legacy-call-syntax: invalid severity "fatal"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := Parse(strings.NewReader(tt.input))
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.expected, actual); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	root := t.TempDir()
+	sub := filepath.Join(root, "a", "b")
+	if err := os.MkdirAll(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	cfgFileName := filepath.Join(root, "templ.yaml")
+	cfgFile := "generate:\n  minify: true\n  directories:\n    - path: a\n"
+	if err := os.WriteFile(cfgFileName, []byte(cfgFile), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("the config file is found by walking up from the directory", func(t *testing.T) {
+		c, err := Load(sub)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if c.Path != cfgFileName {
+			t.Errorf("expected path %q, got %q", cfgFileName, c.Path)
+		}
+		if c.Generate.Minify == nil || !*c.Generate.Minify {
+			t.Error("expected minify to be set")
+		}
+	})
+	t.Run("directory paths are relative to the config file", func(t *testing.T) {
+		c, err := Load(sub)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expected := filepath.Join(root, "a")
+		if c.Generate.Directories[0].Path != expected {
+			t.Errorf("expected %q, got %q", expected, c.Generate.Directories[0].Path)
+		}
+	})
+	t.Run(".templ.yaml takes precedence over templ.yaml", func(t *testing.T) {
+		hidden := filepath.Join(sub, ".templ.yaml")
+		if err := os.WriteFile(hidden, []byte("generate:\n  lazy: true\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(sub, "templ.yaml"), []byte("generate:\n  lazy: false\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		c, err := Load(sub)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if c.Path != hidden {
+			t.Errorf("expected path %q, got %q", hidden, c.Path)
+		}
+	})
+	t.Run("an empty config is returned if no file is found", func(t *testing.T) {
+		c, err := Load(t.TempDir())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if diff := cmp.Diff(Config{}, c); diff != "" {
+			t.Error(diff)
+		}
+	})
+}
+
+func TestWithDefaults(t *testing.T) {
+	c := Config{
+		Generate: Generate{
+			Options: Options{Minify: ptr(true)},
+			Directories: []Directory{
+				{Path: "a", Options: Options{IncludeVersion: ptr(false)}},
+			},
+		},
+	}.WithDefaults()
+	d := c.Generate.Directories[0]
+	if *d.IncludeVersion {
+		t.Error("expected the directory include-version to be kept")
+	}
+	if !*d.Minify {
+		t.Error("expected the directory minify option to be inherited")
+	}
+	if !*c.Generate.IncludeVersion {
+		t.Error("expected include-version to default to true")
+	}
+	if c.Proxy.Port != 7331 || c.Proxy.Bind != "127.0.0.1" || !*c.Proxy.OpenBrowser {
+		t.Errorf("unexpected proxy defaults: %#v", c.Proxy)
+	}
+}
+
+func TestGenerateOptions(t *testing.T) {
+	c := Config{
+		Generate: Generate{
+			Options: Options{Minify: ptr(true)},
+			Directories: []Directory{
+				{Path: filepath.Join("app", "components"), Options: Options{Minify: ptr(false)}},
+				{Path: filepath.Join("app", "components", "email"), Options: Options{IncludeVersion: ptr(false)}},
+			},
+		},
+	}
+	tests := []struct {
+		fileName       string
+		minify         bool
+		includeVersion bool
+	}{
+		{fileName: filepath.Join("app", "page.templ"), minify: true, includeVersion: true},
+		{fileName: filepath.Join("app", "components", "button.templ"), minify: false, includeVersion: true},
+		{fileName: filepath.Join("app", "components", "email", "welcome.templ"), minify: true, includeVersion: false},
+		{fileName: filepath.Join("app", "components-old", "button.templ"), minify: true, includeVersion: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fileName, func(t *testing.T) {
+			opts := c.GenerateOptions(tt.fileName)
+			if *opts.Minify != tt.minify {
+				t.Errorf("expected minify %t, got %t", tt.minify, *opts.Minify)
+			}
+			if *opts.IncludeVersion != tt.includeVersion {
+				t.Errorf("expected include-version %t, got %t", tt.includeVersion, *opts.IncludeVersion)
+			}
+		})
+	}
+}
+
+func TestFmtFormatOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		fmt      Fmt
+		expected parser.FormatOptions
+	}{
+		{
+			name:     "tabs are the default",
+			fmt:      Fmt{IndentSize: 2},
+			expected: parser.FormatOptions{TabWidth: 2},
+		},
+		{
+			name:     "spaces default to an indent of 4",
+			fmt:      Fmt{IndentStyle: IndentStyleSpace},
+			expected: parser.FormatOptions{Indent: "    "},
+		},
+		{
+			name: "all options are set",
+			fmt: Fmt{
+				MaxLineWidth:   80,
+				IndentStyle:    IndentStyleSpace,
+				IndentSize:     2,
+				AttributeOrder: parser.AttributeOrderAlphabetical,
+				SortClasses:    true,
+			},
+			expected: parser.FormatOptions{
+				MaxLineWidth:   80,
+				Indent:         "  ",
+				TabWidth:       2,
+				AttributeOrder: parser.AttributeOrderAlphabetical,
+				SortClasses:    true,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.expected, tt.fmt.FormatOptions()); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
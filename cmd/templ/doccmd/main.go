@@ -0,0 +1,69 @@
+package doccmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/natefinch/atomic"
+)
+
+type Arguments struct {
+	// Path is the directory that contains the templ files.
+	Path string
+	// OutputDir is where index.html and components.json are written.
+	OutputDir string
+}
+
+// Run writes an HTML and a JSON reference of the templ components in the path.
+func Run(ctx context.Context, log *slog.Logger, args Arguments) (err error) {
+	start := time.Now()
+	ref, err := Load(ctx, args.Path)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(args.OutputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	b, err := json.MarshalIndent(ref, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal reference: %w", err)
+	}
+	jsonFileName := filepath.Join(args.OutputDir, "components.json")
+	if err = atomic.WriteFile(jsonFileName, bytes.NewReader(b)); err != nil {
+		return fmt.Errorf("failed to write %q: %w", jsonFileName, err)
+	}
+
+	var html bytes.Buffer
+	if err = page(ref).Render(ctx, &html); err != nil {
+		return fmt.Errorf("failed to render reference: %w", err)
+	}
+	htmlFileName := filepath.Join(args.OutputDir, "index.html")
+	if err = atomic.WriteFile(htmlFileName, &html); err != nil {
+		return fmt.Errorf("failed to write %q: %w", htmlFileName, err)
+	}
+
+	var count int
+	for _, pkg := range ref.Packages {
+		count += len(pkg.Components)
+	}
+	log.Info("Documentation complete", slog.Int("packages", len(ref.Packages)), slog.Int("components", count), slog.String("output", args.OutputDir), slog.Duration("duration", time.Since(start)))
+	return nil
+}
+
+func packageName(pkg Package) string {
+	if pkg.ImportPath != "" {
+		return pkg.ImportPath
+	}
+	return pkg.Name
+}
+
+func anchor(pkg Package, c Component) string {
+	return packageName(pkg) + "." + c.ID()
+}
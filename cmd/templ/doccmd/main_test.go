@@ -0,0 +1,115 @@
+package doccmd
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLoad(t *testing.T) {
+	ref, err := Load(context.Background(), "testdata")
+	if err != nil {
+		t.Fatalf("failed to load reference: %v", err)
+	}
+	// Paths are relative to the loaded directory.
+	dir := "components"
+	fileName := "components/components.templ"
+	expected := Reference{
+		Packages: []Package{
+			{
+				Name:       "components",
+				ImportPath: "github.com/a-h/templ/cmd/templ/doccmd/testdata/components",
+				Dir:        dir,
+				Components: []Component{
+					{
+						Name:      "Button",
+						Signature: "Button(text string, attrs templ.Attributes)",
+						Doc:       "Button renders a button.\n\nThe text is escaped.\n",
+						File:      fileName,
+						Line:      6,
+						Params: []Param{
+							{Name: "text", Type: "string"},
+							{Name: "attrs", Type: "github.com/a-h/templ.Attributes"},
+						},
+						Examples: []Example{
+							{
+								Code:   "// Render a button.\nButton(\"Save\", nil).Render(context.Background(), os.Stdout)",
+								Output: "<button>Save</button>",
+							},
+							{
+								Suffix: "disabled",
+								Doc:    "A disabled button.\n",
+								Code:   "Button(\"Save\", templ.Attributes{\"disabled\": true}).Render(context.Background(), os.Stdout)",
+								Output: "<button disabled>Save</button>",
+							},
+						},
+					},
+					{
+						Name:      "Card",
+						Signature: "Card(title string, items ...Item)",
+						Doc:       "Card renders its children below the title.\n",
+						File:      fileName,
+						Line:      11,
+						Params: []Param{
+							{Name: "title", Type: "string"},
+							{Name: "items", Type: "...Item"},
+						},
+						Children: true,
+					},
+					{
+						Name:      "Title",
+						Receiver:  "*Page",
+						Signature: "(p *Page) Title()",
+						Doc:       "Title is a method.\n",
+						File:      fileName,
+						Line:      19,
+					},
+				},
+			},
+		},
+	}
+	if diff := cmp.Diff(expected, ref); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestRun(t *testing.T) {
+	log := slog.New(slog.NewJSONHandler(io.Discard, nil))
+	outputDir := t.TempDir()
+	if err := Run(context.Background(), log, Arguments{Path: "testdata", OutputDir: outputDir}); err != nil {
+		t.Fatalf("failed to run doc command: %v", err)
+	}
+
+	b, err := os.ReadFile(filepath.Join(outputDir, "components.json"))
+	if err != nil {
+		t.Fatalf("failed to read JSON reference: %v", err)
+	}
+	var ref Reference
+	if err = json.Unmarshal(b, &ref); err != nil {
+		t.Fatalf("failed to unmarshal JSON reference: %v", err)
+	}
+	if len(ref.Packages) != 1 || len(ref.Packages[0].Components) != 3 {
+		t.Errorf("expected 3 components in 1 package, got %+v", ref)
+	}
+
+	b, err = os.ReadFile(filepath.Join(outputDir, "index.html"))
+	if err != nil {
+		t.Fatalf("failed to read HTML reference: %v", err)
+	}
+	for _, expected := range []string{
+		`<section id="github.com/a-h/templ/cmd/templ/doccmd/testdata/components.Page_Title">`,
+		`<td><code>items</code></td><td><code>...Item</code></td>`,
+		`<iframe srcdoc="&lt;button&gt;Save&lt;/button&gt;" sandbox=""></iframe>`,
+	} {
+		if !strings.Contains(string(b), expected) {
+			t.Errorf("expected HTML to contain %q", expected)
+		}
+	}
+}
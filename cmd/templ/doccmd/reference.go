@@ -0,0 +1,386 @@
+package doccmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/doc"
+	"go/format"
+	"go/importer"
+	goparser "go/parser"
+	"go/printer"
+	"go/token"
+	"go/types"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/a-h/templ/cmd/templ/processor"
+	"github.com/a-h/templ/generator"
+	parser "github.com/a-h/templ/parser/v2"
+	"golang.org/x/mod/modfile"
+)
+
+// Reference lists the templ components in a directory tree.
+type Reference struct {
+	Packages []Package `json:"packages"`
+}
+
+// Package is a Go package that contains templ components. The Dir of the
+// package, and the File of each component, are relative to the loaded
+// directory, using forward slashes.
+type Package struct {
+	Name       string      `json:"name"`
+	ImportPath string      `json:"importPath,omitempty"`
+	Dir        string      `json:"dir"`
+	Components []Component `json:"components"`
+}
+
+// Component is a templ template.
+type Component struct {
+	Name string `json:"name"`
+	// Receiver is the type of the method's receiver, if the component is a
+	// method, e.g. "*Page".
+	Receiver string `json:"receiver,omitempty"`
+	// Signature is the declaration of the template, e.g. "Button(text string)".
+	Signature string `json:"signature"`
+	Doc       string `json:"doc,omitempty"`
+	File      string `json:"file"`
+	// Line number of the declaration, starting at 1.
+	Line   int     `json:"line"`
+	Params []Param `json:"params"`
+	// Children is true if the component renders the children passed to it.
+	Children bool      `json:"children"`
+	Examples []Example `json:"examples,omitempty"`
+}
+
+// ID is a unique name for the component within its package.
+func (c Component) ID() string {
+	if c.Receiver == "" {
+		return c.Name
+	}
+	return strings.TrimPrefix(c.Receiver, "*") + "_" + c.Name
+}
+
+// Param is a parameter of a component.
+type Param struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// Example is an Example function from a _test.go file, e.g. ExampleButton.
+type Example struct {
+	// Suffix distinguishes the examples of a component, e.g. "primary" for
+	// ExampleButton_primary.
+	Suffix string `json:"suffix,omitempty"`
+	Doc    string `json:"doc,omitempty"`
+	Code   string `json:"code"`
+	// Output is the HTML rendered by the example, from its "// Output:"
+	// comment.
+	Output string `json:"output,omitempty"`
+}
+
+// Load reads the templ files in the directory tree, and the Go type information
+// of their packages.
+func Load(ctx context.Context, dir string) (ref Reference, err error) {
+	if dir, err = filepath.Abs(dir); err != nil {
+		return ref, fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	fileNames := make(chan string)
+	var findErr error
+	go func() {
+		defer close(fileNames)
+		findErr = processor.FindTemplates(dir, fileNames)
+	}()
+	byDir := map[string][]string{}
+	for fileName := range fileNames {
+		byDir[filepath.Dir(fileName)] = append(byDir[filepath.Dir(fileName)], fileName)
+	}
+	if findErr != nil {
+		return ref, fmt.Errorf("failed to find templates: %w", findErr)
+	}
+
+	fset := token.NewFileSet()
+	imp := importer.ForCompiler(fset, "source", nil)
+	for pkgDir, templateFileNames := range byDir {
+		if err = ctx.Err(); err != nil {
+			return ref, err
+		}
+		slices.Sort(templateFileNames)
+		pkg := Package{Dir: pkgDir, ImportPath: importPath(pkgDir)}
+		generated := map[string][]byte{}
+		for _, fileName := range templateFileNames {
+			src, err := os.ReadFile(fileName)
+			if err != nil {
+				return ref, fmt.Errorf("failed to read %q: %w", fileName, err)
+			}
+			tf, err := parser.ParseString(string(src))
+			if err != nil {
+				return ref, fmt.Errorf("%s: %w", fileName, err)
+			}
+			tf.Filepath = fileName
+			pkg.Name = strings.TrimSpace(strings.TrimPrefix(tf.Package.Expression.Value, "package"))
+			pkg.Components = append(pkg.Components, components(tf, fileName, string(src))...)
+			// Type check the templates as they are now, even if the generated
+			// code is out of date.
+			var b bytes.Buffer
+			if _, _, err = generator.Generate(tf, &b, generator.WithFileName(filepath.Base(fileName))); err == nil {
+				generated[strings.TrimSuffix(fileName, ".templ")+"_templ.go"] = b.Bytes()
+			}
+		}
+		addTypes(&pkg, typeCheck(fset, imp, pkg, generated))
+		examples, err := loadExamples(pkgDir)
+		if err != nil {
+			return ref, err
+		}
+		// Relative paths are the same on every machine.
+		pkg.Dir = relativePath(dir, pkg.Dir)
+		for i, c := range pkg.Components {
+			pkg.Components[i].Examples = examples[c.ID()]
+			pkg.Components[i].File = relativePath(dir, c.File)
+		}
+		ref.Packages = append(ref.Packages, pkg)
+	}
+	slices.SortFunc(ref.Packages, func(a, b Package) int { return strings.Compare(a.Dir, b.Dir) })
+	return ref, nil
+}
+
+// relativePath returns the path of fileName relative to dir, using forward slashes.
+func relativePath(dir, fileName string) string {
+	rel, err := filepath.Rel(dir, fileName)
+	if err != nil {
+		return filepath.ToSlash(fileName)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// typeCheck returns the type information of the package. The generated code of
+// the templates replaces any _templ.go files on disk. Type errors are ignored,
+// so that the types of valid templates are still available.
+func typeCheck(fset *token.FileSet, imp types.Importer, pkg Package, generated map[string][]byte) *types.Package {
+	entries, err := os.ReadDir(pkg.Dir)
+	if err != nil {
+		return nil
+	}
+	var files []*ast.File
+	for _, entry := range entries {
+		name := entry.Name()
+		fileName := filepath.Join(pkg.Dir, name)
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") || generated[fileName] != nil {
+			continue
+		}
+		if match, err := build.Default.MatchFile(pkg.Dir, name); err != nil || !match {
+			continue
+		}
+		if f, err := goparser.ParseFile(fset, fileName, nil, goparser.SkipObjectResolution); err == nil {
+			files = append(files, f)
+		}
+	}
+	for fileName, src := range generated {
+		if f, err := goparser.ParseFile(fset, fileName, src, goparser.SkipObjectResolution); err == nil {
+			files = append(files, f)
+		}
+	}
+	conf := types.Config{Importer: imp, Error: func(error) {}}
+	tp, _ := conf.Check(pkg.ImportPath, fset, files, nil)
+	return tp
+}
+
+// importPath returns the import path of the package in dir, using the module
+// path of the nearest go.mod file.
+func importPath(dir string) string {
+	for parent := dir; ; parent = filepath.Dir(parent) {
+		if b, err := os.ReadFile(filepath.Join(parent, "go.mod")); err == nil {
+			rel, err := filepath.Rel(parent, dir)
+			if err != nil || rel == "." {
+				return modfile.ModulePath(b)
+			}
+			return path.Join(modfile.ModulePath(b), filepath.ToSlash(rel))
+		}
+		if filepath.Dir(parent) == parent {
+			return filepath.Base(dir)
+		}
+	}
+}
+
+// components returns the templ templates in the file, with their doc comments
+// and parameters, as written in the source.
+func components(tf parser.TemplateFile, fileName, src string) (components []Component) {
+	for i, n := range tf.Nodes {
+		t, ok := n.(parser.HTMLTemplate)
+		if !ok {
+			continue
+		}
+		decl, err := parseSignature(t.Expression.Value)
+		if err != nil {
+			continue
+		}
+		c := Component{
+			Name:      decl.Name.Name,
+			Signature: t.Expression.Value,
+			File:      fileName,
+			Line:      int(t.Range.From.Line) + 1,
+			Children:  usesChildren(t),
+		}
+		if decl.Recv != nil && len(decl.Recv.List) > 0 {
+			c.Receiver = types.ExprString(decl.Recv.List[0].Type)
+		}
+		for _, field := range decl.Type.Params.List {
+			typ := types.ExprString(field.Type)
+			if len(field.Names) == 0 {
+				c.Params = append(c.Params, Param{Type: typ})
+			}
+			for _, name := range field.Names {
+				c.Params = append(c.Params, Param{Name: name.Name, Type: typ})
+			}
+		}
+		if i > 0 {
+			if goCode, ok := tf.Nodes[i-1].(parser.TemplateFileGoExpression); ok {
+				// Include the whitespace between the code and the template.
+				c.Doc = docComment(src[goCode.Expression.Range.From.Index:t.Range.From.Index])
+			}
+		}
+		components = append(components, c)
+	}
+	return components
+}
+
+func parseSignature(signature string) (*ast.FuncDecl, error) {
+	f, err := goparser.ParseFile(token.NewFileSet(), "", "package p\nfunc "+signature+" {}\n", goparser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+	return f.Decls[0].(*ast.FuncDecl), nil
+}
+
+// docComment returns the comment at the end of the Go code that precedes a
+// template, if there's no blank line between the comment and the template.
+func docComment(goCode string) string {
+	f, err := goparser.ParseFile(token.NewFileSet(), "", "package p\n"+goCode+"func _() {}\n", goparser.ParseComments|goparser.SkipObjectResolution)
+	if err != nil {
+		return ""
+	}
+	return f.Decls[len(f.Decls)-1].(*ast.FuncDecl).Doc.Text()
+}
+
+func usesChildren(t parser.HTMLTemplate) (children bool) {
+	parser.Inspect(t, func(n any) bool {
+		switch n := n.(type) {
+		case parser.ChildrenExpression:
+			children = true
+		case parser.GoCode:
+			children = children || strings.Contains(n.Expression.Value, "templ.GetChildren(")
+		}
+		return !children
+	})
+	return children
+}
+
+// addTypes replaces the parameter types written in the signatures with the
+// types from the type checker, which are resolved, and qualified relative to
+// the package.
+func addTypes(pkg *Package, tp *types.Package) {
+	if tp == nil {
+		return
+	}
+	qualifier := types.RelativeTo(tp)
+	for i := range pkg.Components {
+		c := &pkg.Components[i]
+		sig := signature(tp, *c)
+		if sig == nil || sig.Params().Len() != len(c.Params) {
+			continue
+		}
+		for j := 0; j < sig.Params().Len(); j++ {
+			t := sig.Params().At(j).Type()
+			if sig.Variadic() && j == sig.Params().Len()-1 {
+				c.Params[j].Type = "..." + types.TypeString(t.(*types.Slice).Elem(), qualifier)
+				continue
+			}
+			c.Params[j].Type = types.TypeString(t, qualifier)
+		}
+	}
+}
+
+func signature(tp *types.Package, c Component) *types.Signature {
+	if c.Receiver == "" {
+		if f, ok := tp.Scope().Lookup(c.Name).(*types.Func); ok {
+			return f.Type().(*types.Signature)
+		}
+		return nil
+	}
+	recv, ok := tp.Scope().Lookup(strings.TrimPrefix(c.Receiver, "*")).(*types.TypeName)
+	if !ok {
+		return nil
+	}
+	obj, _, _ := types.LookupFieldOrMethod(types.NewPointer(recv.Type()), true, tp, c.Name)
+	if f, ok := obj.(*types.Func); ok {
+		return f.Type().(*types.Signature)
+	}
+	return nil
+}
+
+// loadExamples returns the Example functions in the _test.go files of the
+// directory, by the ID of the component that they demonstrate.
+func loadExamples(dir string) (examples map[string][]Example, err error) {
+	fileNames, err := filepath.Glob(filepath.Join(dir, "*_test.go"))
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, fileName := range fileNames {
+		src, err := os.ReadFile(fileName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %q: %w", fileName, err)
+		}
+		f, err := goparser.ParseFile(fset, fileName, src, goparser.ParseComments)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %q: %w", fileName, err)
+		}
+		files = append(files, f)
+	}
+	examples = map[string][]Example{}
+	for _, ex := range doc.Examples(files...) {
+		// ExampleButton_primary is the "primary" example of Button.
+		name, suffix := ex.Name, ""
+		if i := strings.LastIndexByte(name, '_'); i >= 0 && i+1 < len(name) && unicode.IsLower(rune(name[i+1])) {
+			name, suffix = name[:i], name[i+1:]
+		}
+		examples[name] = append(examples[name], Example{
+			Suffix: suffix,
+			Doc:    ex.Doc,
+			Code:   exampleCode(fset, ex),
+			Output: strings.TrimSpace(ex.Output),
+		})
+	}
+	return examples, nil
+}
+
+var outputPrefix = regexp.MustCompile(`(?i)^[[:space:]]*(unordered )?output:`)
+
+// exampleCode returns the body of the example function, without its braces,
+// its output comment, and outdented.
+func exampleCode(fset *token.FileSet, ex *doc.Example) string {
+	var comments []*ast.CommentGroup
+	for _, cg := range ex.Comments {
+		if !outputPrefix.MatchString(cg.Text()) {
+			comments = append(comments, cg)
+		}
+	}
+	var b bytes.Buffer
+	if err := format.Node(&b, fset, &printer.CommentedNode{Node: ex.Code, Comments: comments}); err != nil {
+		return ""
+	}
+	code := strings.TrimSpace(b.String())
+	if _, isBlock := ex.Code.(*ast.BlockStmt); isBlock {
+		code = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(code, "{"), "}"))
+		code = strings.ReplaceAll(code, "\n\t", "\n")
+	}
+	return code
+}
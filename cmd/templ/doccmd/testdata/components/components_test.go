@@ -0,0 +1,20 @@
+package components
+
+import (
+	"context"
+	"os"
+
+	"github.com/a-h/templ"
+)
+
+func ExampleButton() {
+	// Render a button.
+	Button("Save", nil).Render(context.Background(), os.Stdout)
+	// Output: <button>Save</button>
+}
+
+// A disabled button.
+func ExampleButton_disabled() {
+	Button("Save", templ.Attributes{"disabled": true}).Render(context.Background(), os.Stdout)
+	// Output: <button disabled>Save</button>
+}
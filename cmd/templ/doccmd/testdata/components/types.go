@@ -0,0 +1,9 @@
+package components
+
+type Item struct {
+	Name string
+}
+
+type Page struct {
+	Name string
+}
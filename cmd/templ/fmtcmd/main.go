@@ -22,12 +22,14 @@ type Arguments struct {
 	StdinFilepath string
 	Files         []string
 	WorkerCount   int
+	// FormatOptions control the layout of the formatted templates.
+	FormatOptions parser.FormatOptions
 }
 
 func Run(log *slog.Logger, stdin io.Reader, stdout io.Writer, args Arguments) (err error) {
 	// If no files are provided, read from stdin and write to stdout.
 	if len(args.Files) == 0 {
-		out, _ := format(writeToWriter(stdout), readFromReader(stdin, args.StdinFilepath), true)
+		out, _ := format(writeToWriter(stdout), readFromReader(stdin, args.StdinFilepath), true, args.FormatOptions)
 		return out
 	}
 	process := func(fileName string) (error, bool) {
@@ -37,7 +39,7 @@ func Run(log *slog.Logger, stdin io.Reader, stdout io.Writer, args Arguments) (e
 			write = writeToWriter(stdout)
 		}
 		writeIfUnchanged := args.ToStdout
-		return format(write, read, writeIfUnchanged)
+		return format(write, read, writeIfUnchanged, args.FormatOptions)
 	}
 	dir := args.Files[0]
 	return NewFormatter(log, dir, process, args.WorkerCount, args.FailIfChanged).Run()
@@ -138,7 +140,7 @@ func writeToFile(fileName, tgt string) error {
 	return atomic.WriteFile(fileName, bytes.NewBufferString(tgt))
 }
 
-func format(write writer, read reader, writeIfUnchanged bool) (err error, fileChanged bool) {
+func format(write writer, read reader, writeIfUnchanged bool, opts parser.FormatOptions) (err error, fileChanged bool) {
 	fileName, src, err := read()
 	if err != nil {
 		return err, false
@@ -153,7 +155,7 @@ func format(write writer, read reader, writeIfUnchanged bool) (err error, fileCh
 		return err, false
 	}
 	w := new(bytes.Buffer)
-	if err = t.Write(w); err != nil {
+	if err = t.WriteWithOptions(w, opts); err != nil {
 		return fmt.Errorf("formatting error: %w", err), false
 	}
 
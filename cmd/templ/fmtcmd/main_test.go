@@ -10,6 +10,7 @@ import (
 	"strings"
 	"testing"
 
+	parser "github.com/a-h/templ/parser/v2"
 	"github.com/google/go-cmp/cmp"
 	"golang.org/x/tools/txtar"
 )
@@ -160,4 +161,23 @@ func TestFormat(t *testing.T) {
 			t.Error(diff)
 		}
 	})
+
+	t.Run("format options are applied", func(t *testing.T) {
+		stdin := strings.NewReader("package main\n\ntempl a() {\n<div title=\"t\" class=\"b a\" id=\"x\"><span>A</span></div>\n}\n")
+		stdout := new(strings.Builder)
+		if err := Run(log, stdin, stdout, Arguments{
+			ToStdout: true,
+			FormatOptions: parser.FormatOptions{
+				Indent:         "  ",
+				AttributeOrder: parser.AttributeOrderCanonical,
+				SortClasses:    true,
+			},
+		}); err != nil {
+			t.Fatalf("failed to run format command: %v", err)
+		}
+		expected := "package main\n\ntempl a() {\n  <div id=\"x\" class=\"a b\" title=\"t\"><span>A</span></div>\n}\n"
+		if diff := cmp.Diff(expected, stdout.String()); diff != "" {
+			t.Error(diff)
+		}
+	})
 }
@@ -0,0 +1,206 @@
+package generatecmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/a-h/templ"
+	"github.com/a-h/templ/parser/v2"
+)
+
+// cacheFormat is incremented when the format of the cache file changes.
+const cacheFormat = 1
+
+// CacheFileName returns the name of the generation cache file for templates
+// within the module at dir.
+func CacheFileName(moduleDir string) string {
+	return filepath.Join(moduleDir, ".templ", "cache", "generate.json")
+}
+
+// Cache records the inputs and outputs of generated templates, so that
+// templates that haven't changed since the last run are skipped, even in a
+// fresh checkout, e.g. in CI.
+//
+// Entries are discarded when the version of templ changes.
+type Cache struct {
+	fileName string
+	// dir is the module directory. Template file names are relative to it.
+	dir   string
+	m     sync.Mutex
+	data  cacheData
+	dirty bool
+}
+
+type cacheData struct {
+	Format  int    `json:"format"`
+	Version string `json:"version"`
+	// Entries by template file name, then by generator options.
+	Entries map[string]map[string]cacheEntry `json:"entries"`
+}
+
+type cacheEntry struct {
+	// InputHash is the hash of the template source, and the file name used in
+	// the generated code.
+	InputHash string `json:"inputHash"`
+	// GoHash is the hash of the generated _templ.go file.
+	GoHash string `json:"goHash"`
+	// TextHash is the hash of the generated _templ.txt file, if there is one.
+	TextHash    string              `json:"textHash,omitempty"`
+	Diagnostics []parser.Diagnostic `json:"diagnostics,omitempty"`
+}
+
+// LoadCache reads the cache for the module at moduleDir. If the cache doesn't
+// exist, or was written by another version of templ, an empty cache is returned.
+// If the cache can't be read, an empty cache is returned with the error.
+func LoadCache(moduleDir string) (c *Cache, err error) {
+	c = &Cache{
+		fileName: CacheFileName(moduleDir),
+		dir:      moduleDir,
+		data:     newCacheData(),
+	}
+	b, err := os.ReadFile(c.fileName)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return c, fmt.Errorf("failed to read cache: %w", err)
+	}
+	var data cacheData
+	if err = json.Unmarshal(b, &data); err != nil {
+		c.dirty = true
+		return c, fmt.Errorf("failed to read cache %q: %w", c.fileName, err)
+	}
+	if data.Format != cacheFormat || data.Version != templ.Version() || data.Entries == nil {
+		c.dirty = true
+		return c, nil
+	}
+	c.data = data
+	return c, nil
+}
+
+func newCacheData() cacheData {
+	return cacheData{
+		Format:  cacheFormat,
+		Version: templ.Version(),
+		Entries: make(map[string]map[string]cacheEntry),
+	}
+}
+
+// relativePath returns the name of the template relative to the module, or
+// false if it's outside of the module.
+func (c *Cache) relativePath(absFilePath string) (rel string, ok bool) {
+	rel, err := filepath.Rel(c.dir, absFilePath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// get returns the entry for the template, if the inputs haven't changed and the
+// outputs on disk match the outputs that were generated.
+func (c *Cache) get(absFilePath, optsKey, inputHash string) (e cacheEntry, ok bool) {
+	rel, ok := c.relativePath(absFilePath)
+	if !ok {
+		return e, false
+	}
+	c.m.Lock()
+	e, ok = c.data.Entries[rel][optsKey]
+	c.m.Unlock()
+	if !ok || e.InputHash != inputHash {
+		return e, false
+	}
+	basePath := strings.TrimSuffix(absFilePath, ".templ")
+	if !fileHashMatches(basePath+"_templ.go", e.GoHash) {
+		return e, false
+	}
+	if e.TextHash != "" && !fileHashMatches(basePath+"_templ.txt", e.TextHash) {
+		return e, false
+	}
+	return e, true
+}
+
+func (c *Cache) set(absFilePath, optsKey string, e cacheEntry) {
+	rel, ok := c.relativePath(absFilePath)
+	if !ok {
+		return
+	}
+	c.m.Lock()
+	defer c.m.Unlock()
+	if c.data.Entries[rel] == nil {
+		c.data.Entries[rel] = make(map[string]cacheEntry)
+	}
+	c.data.Entries[rel][optsKey] = e
+	c.dirty = true
+}
+
+// Save writes the cache to disk, if it has changed. Entries for templates that
+// no longer exist are removed.
+func (c *Cache) Save() error {
+	c.m.Lock()
+	defer c.m.Unlock()
+	for rel := range c.data.Entries {
+		if _, err := os.Stat(filepath.Join(c.dir, filepath.FromSlash(rel))); errors.Is(err, os.ErrNotExist) {
+			delete(c.data.Entries, rel)
+			c.dirty = true
+		}
+	}
+	if !c.dirty {
+		return nil
+	}
+	b, err := json.MarshalIndent(c.data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode cache: %w", err)
+	}
+	if err = os.MkdirAll(filepath.Dir(c.fileName), 0o755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	// Write to a temporary file, then rename it, so that an interrupted write
+	// doesn't leave a corrupt cache.
+	tmp, err := os.CreateTemp(filepath.Dir(c.fileName), filepath.Base(c.fileName)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create cache file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(b); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	if err = os.Rename(tmp.Name(), c.fileName); err != nil {
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	c.dirty = false
+	return nil
+}
+
+func hashString(b []byte) string {
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:])
+}
+
+// inputHash returns the hash of everything that the generated code of a
+// template depends on, other than the generator options.
+func inputHash(fileName string, src []byte) string {
+	h := sha256.New()
+	h.Write([]byte(fileName))
+	h.Write([]byte{0})
+	h.Write(src)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func fileHashMatches(fileName, hash string) bool {
+	b, err := os.ReadFile(fileName)
+	if err != nil {
+		return false
+	}
+	return hashString(b) == hash
+}
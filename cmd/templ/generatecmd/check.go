@@ -0,0 +1,140 @@
+package generatecmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/a-h/templ/cmd/templ/textdiff"
+)
+
+// CheckResult lists the generated files that aren't up-to-date.
+type CheckResult struct {
+	OutOfDate []OutOfDateFile `json:"outOfDate"`
+	// Orphaned files don't have a corresponding template, and would be removed
+	// by templ generate.
+	Orphaned []string `json:"orphaned"`
+}
+
+// OutOfDateFile is a generated file that doesn't match the generated code.
+type OutOfDateFile struct {
+	FileName string `json:"fileName"`
+	// Missing is true if the file doesn't exist.
+	Missing bool `json:"missing,omitempty"`
+	// Diff is a unified diff from the file on disk to the generated code.
+	Diff string `json:"diff"`
+}
+
+// OK returns true if all of the generated files are up-to-date.
+func (r CheckResult) OK() bool {
+	return len(r.OutOfDate) == 0 && len(r.Orphaned) == 0
+}
+
+// checker compares generated code with the files on disk, instead of writing it.
+type checker struct {
+	dir    string
+	m      sync.Mutex
+	result CheckResult
+}
+
+func newChecker(dir string) *checker {
+	return &checker{
+		dir: dir,
+		result: CheckResult{
+			OutOfDate: []OutOfDateFile{},
+			Orphaned:  []string{},
+		},
+	}
+}
+
+// Write is a FileWriterFunc that records the difference between the generated
+// code and the file on disk.
+func (c *checker) Write(fileName string, contents []byte) error {
+	existing, err := os.ReadFile(fileName)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read %q: %w", fileName, err)
+	}
+	missing := errors.Is(err, os.ErrNotExist)
+	if !missing && string(existing) == string(contents) {
+		return nil
+	}
+	name := c.relativePath(fileName)
+	aName := "a/" + name
+	if missing {
+		aName = "/dev/null"
+	}
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.result.OutOfDate = append(c.result.OutOfDate, OutOfDateFile{
+		FileName: name,
+		Missing:  missing,
+		Diff:     textdiff.Unified(aName, "b/"+name, string(existing), string(contents)),
+	})
+	return nil
+}
+
+// Remove records files that would be removed. Text files left over from watch
+// mode aren't recorded, because they're not used by the generated code.
+func (c *checker) Remove(fileName string) error {
+	if strings.HasSuffix(fileName, "_templ.txt") {
+		return nil
+	}
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.result.Orphaned = append(c.result.Orphaned, c.relativePath(fileName))
+	return nil
+}
+
+func (c *checker) relativePath(fileName string) string {
+	absFilePath, err := filepath.Abs(fileName)
+	if err != nil {
+		return filepath.ToSlash(fileName)
+	}
+	rel, err := filepath.Rel(c.dir, absFilePath)
+	if err != nil {
+		return filepath.ToSlash(fileName)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// Result returns the files that aren't up-to-date, sorted by file name.
+func (c *checker) Result() CheckResult {
+	c.m.Lock()
+	defer c.m.Unlock()
+	r := CheckResult{
+		OutOfDate: slices.Clone(c.result.OutOfDate),
+		Orphaned:  slices.Clone(c.result.Orphaned),
+	}
+	slices.SortFunc(r.OutOfDate, func(a, b OutOfDateFile) int {
+		return strings.Compare(a.FileName, b.FileName)
+	})
+	slices.Sort(r.Orphaned)
+	return r
+}
+
+// writeCheckResult writes the result as JSON, or as unified diffs followed by
+// a list of orphaned files.
+func writeCheckResult(w io.Writer, r CheckResult, asJSON bool) error {
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	}
+	for _, f := range r.OutOfDate {
+		if _, err := io.WriteString(w, f.Diff); err != nil {
+			return err
+		}
+	}
+	for _, fileName := range r.Orphaned {
+		if _, err := fmt.Fprintf(w, "orphaned: %s\n", fileName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
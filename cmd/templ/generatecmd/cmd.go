@@ -2,23 +2,30 @@ package generatecmd
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"net/url"
+	"os"
 	"path"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/a-h/templ"
+	"github.com/a-h/templ/cmd/templ/config"
 	"github.com/a-h/templ/cmd/templ/generatecmd/modcheck"
 	"github.com/a-h/templ/cmd/templ/generatecmd/proxy"
 	"github.com/a-h/templ/cmd/templ/generatecmd/run"
 	"github.com/a-h/templ/cmd/templ/generatecmd/watcher"
+	"github.com/a-h/templ/cmd/templ/visualize"
 	"github.com/a-h/templ/generator"
 	"github.com/cenkalti/backoff/v4"
 	"github.com/cli/browser"
@@ -39,25 +46,44 @@ func NewGenerate(log *slog.Logger, args Arguments) (g *Generate) {
 type Generate struct {
 	Log  *slog.Logger
 	Args *Arguments
+	// sourceMapViewer is served by the proxy in watch mode.
+	sourceMapViewer *visualize.Viewer
 }
 
 type GenerationEvent struct {
 	Event       fsnotify.Event
 	GoUpdated   bool
 	TextUpdated bool
+	// AssetUpdated is true if a watched file that only requires a browser
+	// reload was updated.
+	AssetUpdated bool
+	// CSSUpdated is true if a watched CSS file was updated. The stylesheets
+	// are swapped in the browser, without reloading the page.
+	CSSUpdated bool
+	// ErrorsUpdated is true if a file failed to generate, or a previously
+	// failing file was generated successfully.
+	ErrorsUpdated bool
 }
 
 func (cmd Generate) Run(ctx context.Context) (err error) {
 	if cmd.Args.NotifyProxy {
-		return proxy.NotifyProxy(cmd.Args.ProxyBind, cmd.Args.ProxyPort)
+		return proxy.NotifyProxy(cmd.Args.ProxyBind, cmd.Args.ProxyPort, cmd.Args.ProxyPrefix, cmd.Args.ProxyTLS)
 	}
-	if cmd.Args.Watch && cmd.Args.FileName != "" {
+	if cmd.Args.Watch && (cmd.Args.FileName != "" || len(cmd.Args.Files) > 0) {
 		return fmt.Errorf("cannot watch a single file, remove the -f or -watch flag")
 	}
 	writingToWriter := cmd.Args.FileWriter != nil
 	if cmd.Args.FileName == "" && writingToWriter {
 		return fmt.Errorf("only a single file can be output to stdout, add the -f flag to specify the file to generate code for")
 	}
+	if cmd.Args.Check && (cmd.Args.Watch || writingToWriter) {
+		return fmt.Errorf("cannot check generated files in watch mode, or when writing to stdout, remove the -watch or -stdout flag")
+	}
+	if cmd.Args.Check {
+		// Every file is checked, even if it looks up-to-date.
+		cmd.Args.Lazy = false
+		cmd.Args.Cache = false
+	}
 	// Default to writing to files.
 	if cmd.Args.FileWriter == nil {
 		cmd.Args.FileWriter = FileWriter
@@ -76,13 +102,23 @@ func (cmd Generate) Run(ctx context.Context) (err error) {
 		}
 	}
 
-	// Configure generator.
-	var opts []generator.GenerateOpt
-	if cmd.Args.IncludeVersion {
-		opts = append(opts, generator.WithVersion(templ.Version()))
+	// In check mode, generated code is compared with the files on disk, instead of being written.
+	var chk *checker
+	if cmd.Args.Check {
+		chk = newChecker(cmd.Args.Path)
+		cmd.Args.FileWriter = chk.Write
 	}
-	if cmd.Args.IncludeTimestamp {
-		opts = append(opts, generator.WithTimestamp(time.Now()))
+
+	// Configure generator.
+	opts, optsKey, dirOpts := cmd.Args.generatorConfig(time.Now())
+	// The watch filter only applies in watch mode, so that all templates are
+	// generated by a one-shot templ generate.
+	var watchFilter watcher.Filter
+	if cmd.Args.Watch {
+		watchFilter.Exclude = cmd.Args.WatchExclude
+		for _, wp := range cmd.Args.WatchInclude {
+			watchFilter.Include = append(watchFilter.Include, wp.Pattern)
+		}
 	}
 
 	// Check the version of the templ module.
@@ -100,6 +136,21 @@ func (cmd Generate) Run(ctx context.Context) (err error) {
 		cmd.Args.FileWriter,
 		cmd.Args.Lazy,
 	)
+	fseh.SetDirectoryGenerateOpts(dirOpts)
+	fseh.SetLintRules(cmd.Args.LintRules)
+	if cmd.Args.Watch && cmd.Args.Proxy != "" {
+		// The proxy serves a source map viewer, which is updated as templates
+		// are generated.
+		sourceMaps := visualize.NewStore()
+		cmd.sourceMapViewer = visualize.NewViewer(sourceMaps)
+		sourceMaps.OnChange = cmd.sourceMapViewer.Changed
+		fseh.SetSourceMapStore(sourceMaps)
+	}
+	cache := cmd.loadCache(writingToWriter)
+	fseh.SetCache(cache, optsKey)
+	if chk != nil {
+		fseh.SetFileRemover(chk.Remove)
+	}
 
 	// If we're processing a single file, don't bother setting up the channels/multithreaing.
 	if cmd.Args.FileName != "" {
@@ -107,7 +158,7 @@ func (cmd Generate) Run(ctx context.Context) (err error) {
 			Name: cmd.Args.FileName,
 			Op:   fsnotify.Create,
 		})
-		return err
+		return errors.Join(err, cmd.finish(fseh.Stats(), cache, chk))
 	}
 
 	// Start timer.
@@ -143,7 +194,17 @@ func (cmd Generate) Run(ctx context.Context) (err error) {
 			slog.String("path", cmd.Args.Path),
 			slog.Bool("devMode", cmd.Args.Watch),
 		)
-		if err := watcher.WalkFiles(ctx, cmd.Args.Path, events); err != nil {
+		if len(cmd.Args.Files) > 0 {
+			for _, fileName := range cmd.Args.Files {
+				select {
+				case <-ctx.Done():
+					return
+				case events <- fsnotify.Event{Name: fileName, Op: fsnotify.Create}:
+				}
+			}
+			return
+		}
+		if err := watcher.WalkFiles(ctx, cmd.Args.Path, watchFilter, events); err != nil {
 			cmd.Log.Error("WalkFiles failed, exiting", slog.Any("error", err))
 			errs <- FatalError{Err: fmt.Errorf("failed to walk files: %w", err)}
 			return
@@ -153,7 +214,7 @@ func (cmd Generate) Run(ctx context.Context) (err error) {
 			return
 		}
 		cmd.Log.Info("Watching files")
-		rw, err := watcher.Recursive(ctx, cmd.Args.Path, events, errs)
+		rw, err := watcher.Recursive(ctx, cmd.Args.Path, watchFilter, events, errs)
 		if err != nil {
 			cmd.Log.Error("Recursive watcher setup failed, exiting", slog.Any("error", err))
 			errs <- FatalError{Err: fmt.Errorf("failed to setup recursive watcher: %w", err)}
@@ -186,8 +247,11 @@ func (cmd Generate) Run(ctx context.Context) (err error) {
 			cmd.Args.FileWriter,
 			cmd.Args.Lazy,
 		)
+		fseh.SetDirectoryGenerateOpts(dirOpts)
+		fseh.SetLintRules(cmd.Args.LintRules)
+		fseh.SetCache(cache, optsKey)
 		errorCount.Store(0)
-		if err := watcher.WalkFiles(ctx, cmd.Args.Path, events); err != nil {
+		if err := watcher.WalkFiles(ctx, cmd.Args.Path, watchFilter, events); err != nil {
 			cmd.Log.Error("Post dev mode WalkFiles failed", slog.Any("error", err))
 			errs <- FatalError{Err: fmt.Errorf("failed to walk files: %w", err)}
 			return
@@ -208,16 +272,25 @@ func (cmd Generate) Run(ctx context.Context) (err error) {
 				cmd.Log.Debug("Processing file", slog.String("file", event.Name))
 				defer eventsWG.Done()
 				defer func() { <-sem }()
+				if ge, ok := cmd.handleWatchAction(ctx, fseh, watchFilter, event, errs); ok {
+					if ge != nil {
+						postGeneration <- ge
+					}
+					return
+				}
+				hadError := fseh.HasError(event.Name)
 				goUpdated, textUpdated, err := fseh.HandleEvent(ctx, event)
 				if err != nil {
 					cmd.Log.Error("Event handler failed", slog.Any("error", err))
 					errs <- err
 				}
-				if goUpdated || textUpdated {
+				errorsUpdated := hadError || err != nil
+				if goUpdated || textUpdated || errorsUpdated {
 					postGeneration <- &GenerationEvent{
-						Event:       event,
-						GoUpdated:   goUpdated,
-						TextUpdated: textUpdated,
+						Event:         event,
+						GoUpdated:     goUpdated,
+						TextUpdated:   textUpdated,
+						ErrorsUpdated: errorsUpdated,
 					}
 				}
 			}(event)
@@ -226,6 +299,10 @@ func (cmd Generate) Run(ctx context.Context) (err error) {
 		eventsWG.Wait()
 	}()
 
+	// Processes to restart after Go code is generated.
+	processes := cmd.Args.processes()
+	supervisor := run.NewSupervisor(cmd.Log, cmd.Args.Path, processes...)
+
 	// Start process to handle post-generation events.
 	var updates int
 	postGenerationWG.Add(1)
@@ -235,7 +312,8 @@ func (cmd Generate) Run(ctx context.Context) (err error) {
 		defer postGenerationWG.Done()
 		cmd.Log.Debug("Starting post-generation handler")
 		timeout := time.NewTimer(time.Hour * 24 * 365)
-		var goUpdated, textUpdated bool
+		var goUpdated, textUpdated, assetUpdated, errorsUpdated bool
+		var cssFiles []string
 		var p *proxy.Handler
 		for {
 			select {
@@ -246,7 +324,12 @@ func (cmd Generate) Run(ctx context.Context) (err error) {
 				}
 				goUpdated = goUpdated || ge.GoUpdated
 				textUpdated = textUpdated || ge.TextUpdated
-				if goUpdated || textUpdated {
+				assetUpdated = assetUpdated || ge.AssetUpdated
+				errorsUpdated = errorsUpdated || ge.ErrorsUpdated
+				if ge.CSSUpdated {
+					cssFiles = append(cssFiles, ge.Event.Name)
+				}
+				if goUpdated || textUpdated || assetUpdated || ge.CSSUpdated {
 					updates++
 				}
 				// Reset timer.
@@ -255,15 +338,15 @@ func (cmd Generate) Run(ctx context.Context) (err error) {
 				}
 				timeout.Reset(time.Millisecond * 100)
 			case <-timeout.C:
-				if !goUpdated && !textUpdated {
+				if !goUpdated && !textUpdated && !assetUpdated && len(cssFiles) == 0 && !errorsUpdated {
 					// Nothing to process, reset timer and wait again.
 					timeout.Reset(time.Hour * 24 * 365)
 					break
 				}
 				postGenerationEventsWG.Add(1)
-				if cmd.Args.Command != "" && goUpdated {
-					cmd.Log.Debug("Executing command", slog.String("command", cmd.Args.Command))
-					if _, err := run.Run(ctx, cmd.Args.Path, cmd.Args.Command); err != nil {
+				if goUpdated && len(processes) > 0 {
+					cmd.Log.Debug("Restarting processes")
+					if err := supervisor.Restart(ctx); err != nil {
 						cmd.Log.Error("Error executing command", slog.Any("error", err))
 					}
 				}
@@ -275,16 +358,36 @@ func (cmd Generate) Run(ctx context.Context) (err error) {
 						cmd.Log.Error("Failed to start proxy", slog.Any("error", err))
 					}
 				}
-				// Send server-sent event.
-				if p != nil && (textUpdated || goUpdated) {
-					cmd.Log.Debug("Sending reload event")
-					p.SendSSE("message", "reload")
+				// Update the error overlay. Errors are cleared on the next
+				// successful generation.
+				if p != nil {
+					p.SetErrors(overlayErrors(cmd.Args.Path, fseh.FileErrors()))
+				}
+				// Send server-sent events. If Go code was updated, the app has been
+				// restarted, so the page is reloaded. If only text or CSS was
+				// updated, the page is updated in place, keeping its state.
+				if p != nil {
+					switch {
+					case goUpdated || assetUpdated:
+						cmd.Log.Debug("Sending reload event")
+						p.SendSSE("message", "reload")
+					case textUpdated:
+						cmd.Log.Debug("Sending html event")
+						p.SendHTML()
+					}
+					if len(cssFiles) > 0 && !goUpdated && !assetUpdated {
+						cmd.Log.Debug("Sending css event", slog.Any("files", cssFiles))
+						p.SendCSS(relativePaths(cmd.Args.Path, cssFiles))
+					}
 				}
 				postGenerationEventsWG.Done()
 				// Reset timer.
 				timeout.Reset(time.Millisecond * 100)
 				textUpdated = false
 				goUpdated = false
+				assetUpdated = false
+				errorsUpdated = false
+				cssFiles = nil
 			}
 		}
 	}()
@@ -309,13 +412,17 @@ func (cmd Generate) Run(ctx context.Context) (err error) {
 	eventHandlerWG.Wait()
 	cmd.Log.Debug("Waiting for post-generation handler to complete")
 	postGenerationWG.Wait()
-	if cmd.Args.Command != "" {
-		cmd.Log.Debug("Killing command", slog.String("command", cmd.Args.Command))
-		if err := run.KillAll(); err != nil {
+	if len(processes) > 0 {
+		cmd.Log.Debug("Stopping processes")
+		if err := supervisor.Stop(); err != nil {
 			cmd.Log.Error("Error killing command", slog.Any("error", err))
 		}
 	}
 
+	if err := cmd.finish(fseh.Stats(), cache, chk); err != nil {
+		return err
+	}
+
 	// Check for errors after everything has completed.
 	if errorCount.Load() > 0 {
 		return fmt.Errorf("generation completed with %d errors", errorCount.Load())
@@ -329,6 +436,190 @@ func (cmd Generate) Run(ctx context.Context) (err error) {
 	return nil
 }
 
+// handleWatchAction handles changes to files matched by the watch include
+// patterns. If the file isn't matched by a pattern, ok is false.
+func (cmd Generate) handleWatchAction(ctx context.Context, fseh *FSEventHandler, filter watcher.Filter, event fsnotify.Event, errs chan error) (ge *GenerationEvent, ok bool) {
+	if watcher.IsTemplFile(event.Name) || event.Op == fsnotify.Chmod {
+		return nil, false
+	}
+	rel, err := filepath.Rel(cmd.Args.Path, event.Name)
+	if err != nil {
+		return nil, false
+	}
+	action, ok := cmd.Args.watchAction(rel)
+	if !ok {
+		return nil, false
+	}
+	cmd.Log.Debug("Watched file changed", slog.String("file", event.Name), slog.String("action", string(action)))
+	ge = &GenerationEvent{Event: event}
+	switch action {
+	case config.WatchActionGenerate:
+		fseh.ResetLastModTimes()
+		templEvents := make(chan fsnotify.Event)
+		go func() {
+			defer close(templEvents)
+			if err := watcher.WalkFiles(ctx, cmd.Args.Path, filter, templEvents); err != nil {
+				errs <- fmt.Errorf("failed to walk files: %w", err)
+			}
+		}()
+		for templEvent := range templEvents {
+			_, textUpdated, err := fseh.HandleEvent(ctx, templEvent)
+			if err != nil {
+				cmd.Log.Error("Event handler failed", slog.Any("error", err))
+				errs <- err
+			}
+			ge.TextUpdated = ge.TextUpdated || textUpdated
+		}
+		// The file may be a Go file that's used by the templates, so the
+		// commands must be rerun, even if no templates were updated.
+		ge.GoUpdated = true
+		ge.ErrorsUpdated = true
+	case config.WatchActionRun:
+		ge.GoUpdated = true
+	case config.WatchActionReload:
+		if strings.EqualFold(filepath.Ext(event.Name), ".css") {
+			ge.CSSUpdated = true
+			break
+		}
+		ge.AssetUpdated = true
+	}
+	return ge, true
+}
+
+// relativePaths returns the file names relative to dir, using forward slashes.
+func relativePaths(dir string, fileNames []string) (rel []string) {
+	rel = make([]string, len(fileNames))
+	for i, fileName := range fileNames {
+		rel[i] = filepath.ToSlash(fileName)
+		if r, err := filepath.Rel(dir, fileName); err == nil {
+			rel[i] = filepath.ToSlash(r)
+		}
+	}
+	return rel
+}
+
+func generatorOpts(includeVersion, includeTimestamp, minify bool, now time.Time) (opts []generator.GenerateOpt) {
+	if includeVersion {
+		opts = append(opts, generator.WithVersion(templ.Version()))
+	}
+	if includeTimestamp {
+		opts = append(opts, generator.WithTimestamp(now))
+	}
+	if minify {
+		opts = append(opts, generator.WithMinify())
+	}
+	return opts
+}
+
+// generatorConfig returns the generator options, the key that identifies them
+// in the generation cache, and the options for templates within directories.
+func (args *Arguments) generatorConfig(now time.Time) (opts []generator.GenerateOpt, optsKey string, dirOpts []DirectoryGenerateOpts) {
+	opts = generatorOpts(args.IncludeVersion, args.IncludeTimestamp, args.Minify, now)
+	optsKey = generatorOptsKey(args.IncludeVersion, args.IncludeTimestamp, args.Minify)
+	dirOpts = make([]DirectoryGenerateOpts, len(args.Directories))
+	for i, d := range args.Directories {
+		dir := d.Path
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(args.Path, dir)
+		}
+		includeVersion := valueOrDefault(d.IncludeVersion, args.IncludeVersion)
+		includeTimestamp := valueOrDefault(d.IncludeTimestamp, args.IncludeTimestamp)
+		minify := valueOrDefault(d.Minify, args.Minify)
+		dirOpts[i] = DirectoryGenerateOpts{
+			Path:    dir,
+			Opts:    generatorOpts(includeVersion, includeTimestamp, minify, now),
+			OptsKey: generatorOptsKey(includeVersion, includeTimestamp, minify),
+		}
+	}
+	return opts, optsKey, dirOpts
+}
+
+// generatorOptsKey identifies the generator options in the generation cache.
+// Templates that include a timestamp change on every run, so they aren't cached.
+func generatorOptsKey(includeVersion, includeTimestamp, minify bool) string {
+	if includeTimestamp {
+		return ""
+	}
+	return fmt.Sprintf("version=%t,minify=%t", includeVersion, minify)
+}
+
+// loadCache loads the generation cache from the module directory, if it's enabled.
+func (cmd Generate) loadCache(writingToWriter bool) *Cache {
+	if !cmd.Args.Cache {
+		return nil
+	}
+	if writingToWriter || cmd.Args.GenerateSourceMapVisualisations {
+		cmd.Log.Debug("Generation cache disabled, because the output isn't written to files")
+		return nil
+	}
+	moduleDir, err := modcheck.WalkUp(cmd.Args.Path)
+	if _, statErr := os.Stat(filepath.Join(moduleDir, "go.mod")); err != nil || statErr != nil {
+		moduleDir = cmd.Args.Path
+	}
+	cache, err := LoadCache(moduleDir)
+	if err != nil {
+		cmd.Log.Warn("Failed to load generation cache, regenerating all files", slog.Any("error", err))
+	}
+	cmd.Log.Debug("Using generation cache", slog.String("file", CacheFileName(moduleDir)))
+	return cache
+}
+
+// finish saves the generation cache, and reports the stats, and the results of
+// checking the generated files.
+func (cmd Generate) finish(stats Stats, cache *Cache, chk *checker) error {
+	if cache != nil {
+		if err := cache.Save(); err != nil {
+			cmd.Log.Warn("Failed to save generation cache", slog.Any("error", err))
+		}
+	}
+	for _, f := range stats.Files {
+		cmd.Log.Debug("File stats",
+			slog.String("file", f.FileName),
+			slog.String("result", f.Result),
+			slog.Int("written", f.Written),
+			slog.Float64("ms", f.DurationMS),
+		)
+	}
+	cmd.Log.Debug("Generation stats",
+		slog.Int("parsed", stats.Parsed),
+		slog.Int("skipped", stats.Skipped),
+		slog.Int("written", stats.Written),
+		slog.Int("errors", stats.Errors),
+		slog.Float64("ms", stats.DurationMS),
+	)
+	if cmd.Args.StatsFile != "" {
+		b, err := json.MarshalIndent(stats, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode stats: %w", err)
+		}
+		if err = os.WriteFile(cmd.Args.StatsFile, append(b, '\n'), 0o644); err != nil {
+			return fmt.Errorf("failed to write stats: %w", err)
+		}
+	}
+	if chk == nil {
+		return nil
+	}
+	result := chk.Result()
+	w := cmd.Args.CheckOutput
+	if w == nil {
+		w = os.Stdout
+	}
+	if err := writeCheckResult(w, result, cmd.Args.CheckJSON); err != nil {
+		return fmt.Errorf("failed to write check results: %w", err)
+	}
+	if !result.OK() {
+		return fmt.Errorf("%d generated files are out of date, and %d are orphaned", len(result.OutOfDate), len(result.Orphaned))
+	}
+	return nil
+}
+
+func valueOrDefault(v *bool, def bool) bool {
+	if v == nil {
+		return def
+	}
+	return *v
+}
+
 func (cmd *Generate) StartProxy(ctx context.Context) (p *proxy.Handler, err error) {
 	if cmd.Args.Proxy == "" {
 		cmd.Log.Debug("No proxy URL specified, not starting proxy")
@@ -345,10 +636,18 @@ func (cmd *Generate) StartProxy(ctx context.Context) (p *proxy.Handler, err erro
 	if cmd.Args.ProxyBind == "" {
 		cmd.Args.ProxyBind = "127.0.0.1"
 	}
-	p = proxy.New(cmd.Log, cmd.Args.ProxyBind, cmd.Args.ProxyPort, target)
+	opts, err := cmd.proxyOptions()
+	if err != nil {
+		return nil, FatalError{Err: err}
+	}
+	p = proxy.New(cmd.Log, cmd.Args.ProxyBind, cmd.Args.ProxyPort, target, opts...)
+	p.Dir = cmd.Args.Path
 	go func() {
 		cmd.Log.Info("Proxying", slog.String("from", p.URL), slog.String("to", p.Target.String()))
-		if err := http.ListenAndServe(fmt.Sprintf("%s:%d", cmd.Args.ProxyBind, cmd.Args.ProxyPort), p); err != nil {
+		if cmd.sourceMapViewer != nil {
+			cmd.Log.Info("Serving source map viewer", slog.String("url", p.SourceMapViewerURL()))
+		}
+		if err := p.ListenAndServe(); err != nil {
 			cmd.Log.Error("Proxy failed", slog.Any("error", err))
 		}
 	}()
@@ -362,6 +661,12 @@ func (cmd *Generate) StartProxy(ctx context.Context) (p *proxy.Handler, err erro
 		backoff.InitialInterval = time.Second
 		var client http.Client
 		client.Timeout = 1 * time.Second
+		if cmd.Args.ProxyTLS {
+			// The proxy uses a generated local certificate.
+			transport := http.DefaultTransport.(*http.Transport).Clone()
+			transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+			client.Transport = transport
+		}
 		for {
 			if _, err := client.Get(p.URL); err == nil {
 				break
@@ -380,3 +685,38 @@ func (cmd *Generate) StartProxy(ctx context.Context) (p *proxy.Handler, err erro
 	}()
 	return p, nil
 }
+
+func (cmd *Generate) proxyOptions() (opts []func(*proxy.Handler), err error) {
+	if cmd.Args.ProxyPrefix != "" {
+		opts = append(opts, proxy.WithPrefix(cmd.Args.ProxyPrefix))
+	}
+	if cmd.sourceMapViewer != nil {
+		opts = append(opts, proxy.WithSourceMapViewer(cmd.sourceMapViewer))
+	}
+	if cmd.Args.ProxyInsecureSkipVerify || cmd.Args.ProxyCAFile != "" {
+		tlsConfig := &tls.Config{InsecureSkipVerify: cmd.Args.ProxyInsecureSkipVerify}
+		if cmd.Args.ProxyCAFile != "" {
+			pem, err := os.ReadFile(cmd.Args.ProxyCAFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read proxy CA file: %w", err)
+			}
+			pool, err := x509.SystemCertPool()
+			if err != nil {
+				pool = x509.NewCertPool()
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("failed to read proxy CA file %q: no certificates found", cmd.Args.ProxyCAFile)
+			}
+			tlsConfig.RootCAs = pool
+		}
+		opts = append(opts, proxy.WithTargetTLSConfig(tlsConfig))
+	}
+	if cmd.Args.ProxyTLS {
+		cert, err := proxy.LocalCertificate(proxy.DefaultCertificateDir(), cmd.Args.ProxyBind)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create proxy certificate: %w", err)
+		}
+		opts = append(opts, proxy.WithTLS(cert))
+	}
+	return opts, nil
+}
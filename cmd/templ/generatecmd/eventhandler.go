@@ -5,12 +5,14 @@ import (
 	"bytes"
 	"context"
 	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"go/format"
 	"go/scanner"
 	"go/token"
 	"io"
 	"log/slog"
+	"maps"
 	"os"
 	"path"
 	"path/filepath"
@@ -55,7 +57,7 @@ func NewFSEventHandler(
 		dir:                        dir,
 		fileNameToLastModTime:      make(map[string]time.Time),
 		fileNameToLastModTimeMutex: &sync.Mutex{},
-		fileNameToError:            make(map[string]struct{}),
+		fileNameToError:            make(map[string]error),
 		fileNameToErrorMutex:       &sync.Mutex{},
 		hashes:                     make(map[string][sha256.Size]byte),
 		hashesMutex:                &sync.Mutex{},
@@ -65,6 +67,8 @@ func NewFSEventHandler(
 		keepOrphanedFiles:          keepOrphanedFiles,
 		writer:                     fileWriter,
 		lazy:                       lazy,
+		remove:                     os.Remove,
+		stats:                      newStatsCollector(),
 	}
 	if devMode {
 		fseh.genOpts = append(fseh.genOpts, generator.WithExtractStrings())
@@ -72,23 +76,104 @@ func NewFSEventHandler(
 	return fseh
 }
 
+// DirectoryGenerateOpts are the generator options used for templates within a directory.
+type DirectoryGenerateOpts struct {
+	// Path is the absolute path of the directory.
+	Path string
+	Opts []generator.GenerateOpt
+	// OptsKey identifies the options in the generation cache. If it's empty,
+	// templates within the directory aren't cached.
+	OptsKey string
+}
+
 type FSEventHandler struct {
 	Log *slog.Logger
 	// dir is the root directory being processed.
 	dir                        string
 	fileNameToLastModTime      map[string]time.Time
 	fileNameToLastModTimeMutex *sync.Mutex
-	fileNameToError            map[string]struct{}
+	fileNameToError            map[string]error
 	fileNameToErrorMutex       *sync.Mutex
 	hashes                     map[string][sha256.Size]byte
 	hashesMutex                *sync.Mutex
 	genOpts                    []generator.GenerateOpt
+	dirGenOpts                 []DirectoryGenerateOpts
+	lintRules                  map[string]string
 	genSourceMapVis            bool
 	DevMode                    bool
 	Errors                     []error
 	keepOrphanedFiles          bool
 	writer                     func(string, []byte) error
 	lazy                       bool
+	remove                     func(string) error
+	cache                      *Cache
+	optsKey                    string
+	stats                      *statsCollector
+	sourceMaps                 *visualize.Store
+}
+
+// SetCache enables the generation cache. Templates that are unchanged since they
+// were last generated with the same options are skipped. optsKey identifies the
+// generator options, and must be empty if the output can change between runs,
+// e.g. because it includes a timestamp.
+func (h *FSEventHandler) SetCache(c *Cache, optsKey string) {
+	h.cache = c
+	h.optsKey = optsKey
+}
+
+// SetFileRemover replaces the function used to remove orphaned files.
+func (h *FSEventHandler) SetFileRemover(remove func(fileName string) error) {
+	h.remove = remove
+}
+
+// Stats returns the stats of the templates that have been handled.
+func (h *FSEventHandler) Stats() Stats {
+	return h.stats.stats()
+}
+
+// SetSourceMapStore records the source map of each generated template in the
+// store, so that it can be shown by the source map viewer.
+func (h *FSEventHandler) SetSourceMapStore(s *visualize.Store) {
+	h.sourceMaps = s
+}
+
+// SetDirectoryGenerateOpts overrides the generator options used for templates
+// within the directories. Where directories are nested, the options of the
+// innermost directory are used.
+func (h *FSEventHandler) SetDirectoryGenerateOpts(dirGenOpts []DirectoryGenerateOpts) {
+	h.dirGenOpts = dirGenOpts
+}
+
+// SetLintRules sets the severity of diagnostics, by rule name. The severity
+// can be "off", "warn" or "error". Diagnostics for rules that aren't listed
+// are logged as warnings.
+func (h *FSEventHandler) SetLintRules(rules map[string]string) {
+	h.lintRules = rules
+}
+
+// generateOpts returns the generator options for the template file, and the
+// key that identifies them in the cache.
+func (h *FSEventHandler) generateOpts(absFilePath string) (opts []generator.GenerateOpt, optsKey string) {
+	opts = h.genOpts
+	optsKey = h.optsKey
+	var matched string
+	for _, d := range h.dirGenOpts {
+		rel, err := filepath.Rel(d.Path, absFilePath)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if len(d.Path) < len(matched) {
+			continue
+		}
+		matched = d.Path
+		opts = d.Opts
+		optsKey = d.OptsKey
+		if h.DevMode {
+			opts = append(opts[:len(opts):len(opts)], generator.WithExtractStrings())
+		}
+	}
+	// Limit the capacity, so that appending to the options doesn't modify the shared slice.
+	return opts[:len(opts):len(opts)], optsKey
 }
 
 func (h *FSEventHandler) HandleEvent(ctx context.Context, event fsnotify.Event) (goUpdated, textUpdated bool, err error) {
@@ -103,7 +188,7 @@ func (h *FSEventHandler) HandleEvent(ctx context.Context, event fsnotify.Event)
 			return false, false, nil
 		}
 		h.Log.Debug("Deleting orphaned Go file", slog.String("file", event.Name))
-		if err = os.Remove(event.Name); err != nil {
+		if err = h.remove(event.Name); err != nil {
 			h.Log.Warn("Failed to remove orphaned file", slog.Any("error", err))
 		}
 		return true, false, nil
@@ -115,7 +200,7 @@ func (h *FSEventHandler) HandleEvent(ctx context.Context, event fsnotify.Event)
 			return false, true, nil
 		}
 		h.Log.Debug("Deleting watch mode file", slog.String("file", event.Name))
-		if err = os.Remove(event.Name); err != nil {
+		if err = h.remove(event.Name); err != nil {
 			h.Log.Warn("Failed to remove watch mode text file", slog.Any("error", err))
 			return false, false, nil
 		}
@@ -134,40 +219,89 @@ func (h *FSEventHandler) HandleEvent(ctx context.Context, event fsnotify.Event)
 		return false, false, nil
 	}
 	// If the go file is newer than the templ file, skip generation, because it's up-to-date.
+	start := time.Now()
 	if h.lazy && goFileIsUpToDate(event.Name, lastModTime) {
 		h.Log.Debug("Skipping file because the Go file is up-to-date", slog.String("file", event.Name))
+		h.stats.add(h.statsFileName(event.Name), fileResultSkipped, 0, time.Since(start))
 		return false, false, nil
 	}
 
 	// Start a processor.
-	start := time.Now()
-	goUpdated, textUpdated, diag, err := h.generate(ctx, event.Name)
+	goUpdated, textUpdated, skipped, diag, err := h.generate(ctx, event.Name)
+	written := countTrue(goUpdated, textUpdated)
 	if err != nil {
 		h.Log.Error(
 			"Error generating code",
 			slog.String("file", event.Name),
 			slog.Any("error", err),
 		)
-		h.SetError(event.Name, true)
-		return goUpdated, textUpdated, fmt.Errorf("failed to generate code for %q: %w", event.Name, err)
-	}
-	if len(diag) > 0 {
-		for _, d := range diag {
-			h.Log.Warn(d.Message,
-				slog.String("from", fmt.Sprintf("%d:%d", d.Range.From.Line, d.Range.From.Col)),
-				slog.String("to", fmt.Sprintf("%d:%d", d.Range.To.Line, d.Range.To.Col)),
-			)
+		h.stats.add(h.statsFileName(event.Name), fileResultError, written, time.Since(start))
+		err = fmt.Errorf("failed to generate code for %q: %w", event.Name, err)
+		h.SetError(event.Name, err)
+		return goUpdated, textUpdated, err
+	}
+	result := fileResultGenerated
+	if skipped {
+		result = fileResultSkipped
+	}
+	h.stats.add(h.statsFileName(event.Name), result, written, time.Since(start))
+	var lintErrors []parser.Diagnostic
+	for _, d := range diag {
+		level := slog.LevelWarn
+		switch h.lintRules[d.Rule] {
+		case "off":
+			continue
+		case "error":
+			level = slog.LevelError
+			lintErrors = append(lintErrors, d)
 		}
-		return
+		h.Log.Log(ctx, level, d.Message,
+			slog.String("file", event.Name),
+			slog.String("rule", d.Rule),
+			slog.String("from", fmt.Sprintf("%d:%d", d.Range.From.Line, d.Range.From.Col)),
+			slog.String("to", fmt.Sprintf("%d:%d", d.Range.To.Line, d.Range.To.Col)),
+		)
 	}
-	if errorCleared, errorCount := h.SetError(event.Name, false); errorCleared {
+	if len(lintErrors) > 0 {
+		h.stats.add(h.statsFileName(event.Name), fileResultError, written, time.Since(start))
+		err = LintError{FileName: event.Name, Diagnostics: lintErrors}
+		h.SetError(event.Name, err)
+		return goUpdated, textUpdated, err
+	}
+	if errorCleared, errorCount := h.SetError(event.Name, nil); errorCleared {
 		h.Log.Info("Error cleared", slog.String("file", event.Name), slog.Int("errors", errorCount))
 	}
-	h.Log.Debug("Generated code", slog.String("file", event.Name), slog.Duration("in", time.Since(start)))
+	if skipped {
+		h.Log.Debug("Skipping file because it's unchanged since it was cached", slog.String("file", event.Name))
+		return goUpdated, textUpdated, nil
+	}
+	h.Log.Debug("Generated code", slog.String("file", event.Name), slog.Int("written", written), slog.Duration("in", time.Since(start)))
 
 	return goUpdated, textUpdated, nil
 }
 
+// statsFileName returns the name of the file relative to the directory being processed.
+func (h *FSEventHandler) statsFileName(fileName string) string {
+	absFilePath, err := filepath.Abs(fileName)
+	if err != nil {
+		return fileName
+	}
+	rel, err := filepath.Rel(h.dir, absFilePath)
+	if err != nil {
+		return fileName
+	}
+	return filepath.ToSlash(rel)
+}
+
+func countTrue(values ...bool) (n int) {
+	for _, v := range values {
+		if v {
+			n++
+		}
+	}
+	return n
+}
+
 func goFileIsUpToDate(templFileName string, templFileLastMod time.Time) (upToDate bool) {
 	goFileName := strings.TrimSuffix(templFileName, ".templ") + "_templ.go"
 	goFileInfo, err := os.Stat(goFileName)
@@ -177,17 +311,53 @@ func goFileIsUpToDate(templFileName string, templFileLastMod time.Time) (upToDat
 	return goFileInfo.ModTime().After(templFileLastMod)
 }
 
-func (h *FSEventHandler) SetError(fileName string, hasError bool) (previouslyHadError bool, errorCount int) {
+// LintError is returned when a template has diagnostics for rules with the
+// "error" severity.
+type LintError struct {
+	FileName    string
+	Diagnostics []parser.Diagnostic
+}
+
+func (e LintError) Error() string {
+	return fmt.Sprintf("%q has %d lint errors", e.FileName, len(e.Diagnostics))
+}
+
+// SetError records the error for the file, or clears it if err is nil.
+func (h *FSEventHandler) SetError(fileName string, err error) (previouslyHadError bool, errorCount int) {
 	h.fileNameToErrorMutex.Lock()
 	defer h.fileNameToErrorMutex.Unlock()
 	_, previouslyHadError = h.fileNameToError[fileName]
 	delete(h.fileNameToError, fileName)
-	if hasError {
-		h.fileNameToError[fileName] = struct{}{}
+	if err != nil {
+		h.fileNameToError[fileName] = err
 	}
 	return previouslyHadError, len(h.fileNameToError)
 }
 
+// HasError returns true if the last generation of the file failed.
+func (h *FSEventHandler) HasError(fileName string) bool {
+	h.fileNameToErrorMutex.Lock()
+	defer h.fileNameToErrorMutex.Unlock()
+	_, ok := h.fileNameToError[fileName]
+	return ok
+}
+
+// FileErrors returns a copy of the errors of files that failed to generate, by file name.
+func (h *FSEventHandler) FileErrors() map[string]error {
+	h.fileNameToErrorMutex.Lock()
+	defer h.fileNameToErrorMutex.Unlock()
+	return maps.Clone(h.fileNameToError)
+}
+
+// ResetLastModTimes forgets the modification times of the templates, so that
+// they're regenerated when the next event for them is handled, even if they
+// haven't changed.
+func (h *FSEventHandler) ResetLastModTimes() {
+	h.fileNameToLastModTimeMutex.Lock()
+	defer h.fileNameToLastModTimeMutex.Unlock()
+	clear(h.fileNameToLastModTime)
+}
+
 func (h *FSEventHandler) UpsertLastModTime(fileName string) (modTime time.Time, updated bool) {
 	fileInfo, err := os.Stat(fileName)
 	if err != nil {
@@ -217,35 +387,54 @@ func (h *FSEventHandler) UpsertHash(fileName string, hash [sha256.Size]byte) (up
 
 // generate Go code for a single template.
 // If a basePath is provided, the filename included in error messages is relative to it.
-func (h *FSEventHandler) generate(ctx context.Context, fileName string) (goUpdated, textUpdated bool, diagnostics []parser.Diagnostic, err error) {
-	t, err := parser.Parse(fileName)
+// If the cache is enabled, and the template is unchanged since it was cached, it's skipped.
+func (h *FSEventHandler) generate(ctx context.Context, fileName string) (goUpdated, textUpdated, skipped bool, diagnostics []parser.Diagnostic, err error) {
+	src, err := os.ReadFile(fileName)
 	if err != nil {
-		return false, false, nil, fmt.Errorf("%s parsing error: %w", fileName, err)
+		return false, false, false, nil, fmt.Errorf("%s parsing error: %w", fileName, err)
 	}
 	targetFileName := strings.TrimSuffix(fileName, ".templ") + "_templ.go"
+	txtFileName := strings.TrimSuffix(fileName, ".templ") + "_templ.txt"
 
-	// Only use relative filenames to the basepath for filenames in runtime error messages.
-	absFilePath, err := filepath.Abs(fileName)
+	absFilePath, relFilePath, err := h.relativeFileName(fileName)
 	if err != nil {
-		return false, false, nil, fmt.Errorf("failed to get absolute path for %q: %w", fileName, err)
+		return false, false, false, nil, err
 	}
-	relFilePath, err := filepath.Rel(h.dir, absFilePath)
+
+	opts, optsKey := h.generateOpts(absFilePath)
+	// In dev mode, the generated code is only used until watching stops, so it isn't cached.
+	useCache := h.cache != nil && optsKey != "" && !h.DevMode
+	srcHash := inputHash(relFilePath, src)
+	if useCache {
+		if e, ok := h.cache.get(absFilePath, optsKey, srcHash); ok {
+			// Record the hashes of the outputs, so that they're not rewritten if
+			// a later change results in the same output.
+			h.UpsertHash(targetFileName, mustDecodeHash(e.GoHash))
+			if e.TextHash != "" {
+				h.UpsertHash(txtFileName, mustDecodeHash(e.TextHash))
+			}
+			return false, false, true, e.Diagnostics, nil
+		}
+	}
+
+	t, err := parser.ParseString(string(src))
 	if err != nil {
-		return false, false, nil, fmt.Errorf("failed to get relative path for %q: %w", fileName, err)
+		return false, false, false, nil, fmt.Errorf("%s parsing error: %w", fileName, err)
 	}
-	// Convert Windows file paths to Unix-style for consistency.
-	relFilePath = filepath.ToSlash(relFilePath)
 
 	var b bytes.Buffer
-	sourceMap, literals, err := generator.Generate(t, &b, append(h.genOpts, generator.WithFileName(relFilePath))...)
+	sourceMap, literals, err := generator.Generate(t, &b, append(opts, generator.WithFileName(relFilePath))...)
 	if err != nil {
-		return false, false, nil, fmt.Errorf("%s generation error: %w", fileName, err)
+		return false, false, false, nil, fmt.Errorf("%s generation error: %w", fileName, err)
+	}
+	if h.sourceMaps != nil {
+		h.sourceMaps.Set(visualize.File{Name: relFilePath, Templ: string(src), Go: b.String(), SourceMap: sourceMap})
 	}
 
 	formattedGoCode, err := format.Source(b.Bytes())
 	if err != nil {
 		err = remapErrorList(err, sourceMap, fileName)
-		return false, false, nil, fmt.Errorf("% source formatting error %w", fileName, err)
+		return false, false, false, nil, fmt.Errorf("% source formatting error %w", fileName, err)
 	}
 
 	// Hash output, and write out the file if the goCodeHash has changed.
@@ -253,32 +442,65 @@ func (h *FSEventHandler) generate(ctx context.Context, fileName string) (goUpdat
 	if h.UpsertHash(targetFileName, goCodeHash) {
 		goUpdated = true
 		if err = h.writer(targetFileName, formattedGoCode); err != nil {
-			return false, false, nil, fmt.Errorf("failed to write target file %q: %w", targetFileName, err)
+			return false, false, false, nil, fmt.Errorf("failed to write target file %q: %w", targetFileName, err)
 		}
 	}
 
 	// Add the txt file if it has changed.
+	var txtHash [sha256.Size]byte
 	if len(literals) > 0 {
-		txtFileName := strings.TrimSuffix(fileName, ".templ") + "_templ.txt"
-		txtHash := sha256.Sum256([]byte(literals))
+		txtHash = sha256.Sum256([]byte(literals))
 		if h.UpsertHash(txtFileName, txtHash) {
 			textUpdated = true
 			if err = os.WriteFile(txtFileName, []byte(literals), 0o644); err != nil {
-				return false, false, nil, fmt.Errorf("failed to write string literal file %q: %w", txtFileName, err)
+				return false, false, false, nil, fmt.Errorf("failed to write string literal file %q: %w", txtFileName, err)
 			}
 		}
 	}
 
 	parsedDiagnostics, err := parser.Diagnose(t)
 	if err != nil {
-		return goUpdated, textUpdated, nil, fmt.Errorf("%s diagnostics error: %w", fileName, err)
+		return goUpdated, textUpdated, false, nil, fmt.Errorf("%s diagnostics error: %w", fileName, err)
+	}
+
+	if useCache {
+		e := cacheEntry{
+			InputHash:   srcHash,
+			GoHash:      hex.EncodeToString(goCodeHash[:]),
+			Diagnostics: parsedDiagnostics,
+		}
+		if len(literals) > 0 {
+			e.TextHash = hex.EncodeToString(txtHash[:])
+		}
+		h.cache.set(absFilePath, optsKey, e)
 	}
 
 	if h.genSourceMapVis {
 		err = generateSourceMapVisualisation(ctx, fileName, targetFileName, sourceMap)
 	}
 
-	return goUpdated, textUpdated, parsedDiagnostics, err
+	return goUpdated, textUpdated, false, parsedDiagnostics, err
+}
+
+// relativeFileName returns the absolute path of the template, and its path
+// relative to the directory being processed. Only relative file names are used
+// in runtime error messages.
+func (h *FSEventHandler) relativeFileName(fileName string) (absFilePath, relFilePath string, err error) {
+	absFilePath, err = filepath.Abs(fileName)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get absolute path for %q: %w", fileName, err)
+	}
+	relFilePath, err = filepath.Rel(h.dir, absFilePath)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get relative path for %q: %w", fileName, err)
+	}
+	// Convert Windows file paths to Unix-style for consistency.
+	return absFilePath, filepath.ToSlash(relFilePath), nil
+}
+
+func mustDecodeHash(s string) (hash [sha256.Size]byte) {
+	_, _ = hex.Decode(hash[:], []byte(s))
+	return hash
 }
 
 // Takes an error from the formatter and attempts to convert the positions reported in the target file to their positions
@@ -3,22 +3,38 @@ package generatecmd
 import (
 	"context"
 	_ "embed"
+	"io"
 	"log/slog"
 
+	"github.com/a-h/templ/cmd/templ/config"
+	"github.com/a-h/templ/cmd/templ/generatecmd/run"
+	"github.com/a-h/templ/cmd/templ/generatecmd/watcher"
+
 	_ "net/http/pprof"
 )
 
 type Arguments struct {
-	FileName                        string
-	FileWriter                      FileWriterFunc
-	Path                            string
-	Watch                           bool
-	OpenBrowser                     bool
-	Command                         string
-	ProxyBind                       string
-	ProxyPort                       int
-	Proxy                           string
-	NotifyProxy                     bool
+	FileName string
+	// Files to generate, instead of walking Path. File names in the generated
+	// code are relative to Path.
+	Files       []string
+	FileWriter  FileWriterFunc
+	Path        string
+	Watch       bool
+	OpenBrowser bool
+	Command     string
+	ProxyBind   string
+	ProxyPort   int
+	Proxy       string
+	NotifyProxy bool
+	// ProxyInsecureSkipVerify disables verification of the certificate of HTTPS proxy targets.
+	ProxyInsecureSkipVerify bool
+	// ProxyCAFile is a PEM file of certificate authorities trusted for HTTPS proxy targets.
+	ProxyCAFile string
+	// ProxyPrefix is the path prefix of the proxy's endpoints, defaulting to /_templ.
+	ProxyPrefix string
+	// ProxyTLS serves the proxy over HTTPS, using a generated local certificate.
+	ProxyTLS                        bool
 	WorkerCount                     int
 	GenerateSourceMapVisualisations bool
 	IncludeVersion                  bool
@@ -27,6 +43,77 @@ type Arguments struct {
 	PPROFPort         int
 	KeepOrphanedFiles bool
 	Lazy              bool
+	// Minify the HTML and CSS output of the generated code.
+	Minify bool
+	// BuildCommand is run to completion before Command is started.
+	BuildCommand string
+	// Ready is a readiness check for Command, e.g. "tcp://localhost:8080" or
+	// "http://localhost:8080/health". The browser is reloaded when it passes.
+	Ready string
+	// Commands to run after generating code, in addition to Command.
+	Commands []string
+	// Processes to run after generating code, in addition to Command and Commands.
+	Processes []run.Process
+	// Directories override the generator options for templates within them.
+	Directories []DirectoryArguments
+	// WatchInclude is a list of glob patterns of additional files to watch,
+	// and the action to take when they change.
+	WatchInclude []config.WatchPattern
+	// WatchExclude is a list of glob patterns of files and directories to ignore.
+	WatchExclude []string
+	// Cache enables the persistent generation cache, so that templates that
+	// haven't changed since the last run are skipped.
+	Cache bool
+	// StatsFile is the name of a file to write generation stats to, as JSON.
+	StatsFile string
+	// Check compares the generated code with the files on disk, instead of
+	// writing it, and returns an error if they're out of date.
+	Check bool
+	// CheckJSON outputs the results of the check as JSON.
+	CheckJSON bool
+	// CheckOutput is where the results of the check are written, defaulting to os.Stdout.
+	CheckOutput io.Writer
+	// LintRules maps diagnostic rules to a severity of "off", "warn" or "error".
+	// Diagnostics for rules that aren't listed are logged as warnings.
+	LintRules map[string]string
+}
+
+// DirectoryArguments override the generator options for templates within a
+// directory. Nil options are inherited from the Arguments.
+type DirectoryArguments struct {
+	// Path of the directory, absolute or relative to the Arguments Path.
+	Path             string
+	IncludeVersion   *bool
+	IncludeTimestamp *bool
+	Minify           *bool
+}
+
+// watchAction returns the action of the first pattern that matches the path,
+// relative to the watched directory.
+func (args Arguments) watchAction(rel string) (action config.WatchAction, ok bool) {
+	for _, wp := range args.WatchInclude {
+		if watcher.Match(wp.Pattern, rel) {
+			return wp.Action, true
+		}
+	}
+	return "", false
+}
+
+// processes returns all of the processes to run after generating code.
+func (args Arguments) processes() (processes []run.Process) {
+	if args.Command != "" || args.BuildCommand != "" {
+		processes = append(processes, run.Process{
+			Build: args.BuildCommand,
+			Run:   args.Command,
+			Ready: args.Ready,
+		})
+	}
+	for _, c := range args.Commands {
+		if c != "" {
+			processes = append(processes, run.Process{Run: c})
+		}
+	}
+	return append(processes, args.Processes...)
 }
 
 func Run(ctx context.Context, log *slog.Logger, args Arguments) (err error) {
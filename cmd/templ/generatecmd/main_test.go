@@ -1,11 +1,14 @@
 package generatecmd
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"io"
 	"log/slog"
 	"os"
 	"path"
+	"strings"
 	"testing"
 
 	"github.com/a-h/templ/cmd/templ/testproject"
@@ -41,4 +44,158 @@ func TestGenerate(t *testing.T) {
 			t.Fatalf("templates_templ.go was not created: %v", err)
 		}
 	})
+	t.Run("watch exclude patterns are ignored outside watch mode", func(t *testing.T) {
+		// templ generate -path dir, with watch.exclude in the config.
+		dir, err := testproject.Create("github.com/a-h/templ/cmd/templ/testproject")
+		if err != nil {
+			t.Fatalf("failed to create test project: %v", err)
+		}
+		defer os.RemoveAll(dir)
+
+		if err = os.Remove(path.Join(dir, "templates_templ.go")); err != nil {
+			t.Fatalf("failed to remove templates_templ.go: %v", err)
+		}
+		err = Run(context.Background(), log, Arguments{
+			Path:         dir,
+			WatchExclude: []string{"*.templ"},
+		})
+		if err != nil {
+			t.Fatalf("failed to run generate command: %v", err)
+		}
+		if _, err = os.Stat(path.Join(dir, "templates_templ.go")); err != nil {
+			t.Fatalf("templates_templ.go was not created: %v", err)
+		}
+	})
+	t.Run("unchanged files are skipped when the cache is enabled", func(t *testing.T) {
+		// templ generate -path dir -cache -stats stats.json
+		dir, err := testproject.Create("github.com/a-h/templ/cmd/templ/testproject")
+		if err != nil {
+			t.Fatalf("failed to create test project: %v", err)
+		}
+		defer os.RemoveAll(dir)
+		statsFile := path.Join(dir, "stats.json")
+		generate := func() (stats Stats) {
+			err := Run(context.Background(), log, Arguments{
+				Path:      dir,
+				Cache:     true,
+				StatsFile: statsFile,
+			})
+			if err != nil {
+				t.Fatalf("failed to run generate command: %v", err)
+			}
+			b, err := os.ReadFile(statsFile)
+			if err != nil {
+				t.Fatalf("failed to read stats: %v", err)
+			}
+			if err = json.Unmarshal(b, &stats); err != nil {
+				t.Fatalf("failed to decode stats: %v", err)
+			}
+			return stats
+		}
+
+		// The first run populates the cache.
+		stats := generate()
+		if stats.Parsed == 0 || stats.Skipped != 0 {
+			t.Fatalf("expected all files to be parsed, got %#v", stats)
+		}
+		if _, err = os.Stat(CacheFileName(dir)); err != nil {
+			t.Fatalf("expected the cache to be written: %v", err)
+		}
+
+		// The second run skips everything.
+		parsed := stats.Parsed
+		stats = generate()
+		if stats.Parsed != 0 || stats.Skipped != parsed || stats.Written != 0 {
+			t.Fatalf("expected all files to be skipped, got %#v", stats)
+		}
+
+		// Files are regenerated if the output has been modified.
+		if err = os.WriteFile(path.Join(dir, "templates_templ.go"), []byte("package main\n"), 0o644); err != nil {
+			t.Fatalf("failed to modify templates_templ.go: %v", err)
+		}
+		stats = generate()
+		if stats.Parsed != 1 || stats.Written != 1 {
+			t.Fatalf("expected the modified file to be regenerated, got %#v", stats)
+		}
+	})
+	t.Run("out of date and orphaned files are reported by the check", func(t *testing.T) {
+		// templ generate -path dir -check -json
+		dir, err := testproject.Create("github.com/a-h/templ/cmd/templ/testproject")
+		if err != nil {
+			t.Fatalf("failed to create test project: %v", err)
+		}
+		defer os.RemoveAll(dir)
+		check := func() (result CheckResult, err error) {
+			var out bytes.Buffer
+			err = Run(context.Background(), log, Arguments{
+				Path:        dir,
+				Check:       true,
+				CheckJSON:   true,
+				CheckOutput: &out,
+			})
+			if decodeErr := json.Unmarshal(out.Bytes(), &result); decodeErr != nil {
+				t.Fatalf("failed to decode check result %q: %v", out.String(), decodeErr)
+			}
+			return result, err
+		}
+
+		// Generate the files, so that they're up-to-date.
+		if err = Run(context.Background(), log, Arguments{Path: dir}); err != nil {
+			t.Fatalf("failed to run generate command: %v", err)
+		}
+		result, err := check()
+		if err != nil || !result.OK() {
+			t.Fatalf("expected the generated files to be up-to-date, got %v, %#v", err, result)
+		}
+
+		// Text files left over from watch mode aren't reported.
+		if err = os.WriteFile(path.Join(dir, "templates_templ.txt"), []byte("text"), 0o644); err != nil {
+			t.Fatalf("failed to write templates_templ.txt: %v", err)
+		}
+		result, err = check()
+		if err != nil || !result.OK() {
+			t.Fatalf("expected watch mode text files to be ignored, got %v, %#v", err, result)
+		}
+		if _, err = os.Stat(path.Join(dir, "templates_templ.txt")); err != nil {
+			t.Errorf("expected templates_templ.txt not to be removed: %v", err)
+		}
+
+		// Modify a generated file, and add an orphaned file.
+		goFileName := path.Join(dir, "templates_templ.go")
+		original, err := os.ReadFile(goFileName)
+		if err != nil {
+			t.Fatalf("failed to read templates_templ.go: %v", err)
+		}
+		if err = os.WriteFile(goFileName, bytes.Replace(original, []byte("package main"), []byte("package main\n\n// Modified."), 1), 0o644); err != nil {
+			t.Fatalf("failed to modify templates_templ.go: %v", err)
+		}
+		if err = os.WriteFile(path.Join(dir, "orphan_templ.go"), []byte("package main\n"), 0o644); err != nil {
+			t.Fatalf("failed to write orphan_templ.go: %v", err)
+		}
+		result, err = check()
+		if err == nil {
+			t.Error("expected an error")
+		}
+		if len(result.OutOfDate) != 1 || result.OutOfDate[0].FileName != "templates_templ.go" {
+			t.Fatalf("expected templates_templ.go to be out of date, got %#v", result.OutOfDate)
+		}
+		if !strings.Contains(result.OutOfDate[0].Diff, "-// Modified.") {
+			t.Errorf("expected the diff to remove the modification, got:\n%s", result.OutOfDate[0].Diff)
+		}
+		if len(result.Orphaned) != 1 || result.Orphaned[0] != "orphan_templ.go" {
+			t.Errorf("expected orphan_templ.go to be orphaned, got %#v", result.Orphaned)
+		}
+
+		// The files aren't modified by the check.
+		actual, err := os.ReadFile(goFileName)
+		if err != nil {
+			t.Fatalf("failed to read templates_templ.go: %v", err)
+		}
+		if bytes.Equal(actual, original) {
+			t.Error("expected templates_templ.go not to be regenerated")
+		}
+		if _, err = os.Stat(path.Join(dir, "orphan_templ.go")); err != nil {
+			t.Errorf("expected orphan_templ.go not to be removed: %v", err)
+		}
+	})
 }
@@ -0,0 +1,52 @@
+package generatecmd
+
+import (
+	"errors"
+	"go/scanner"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/a-h/parse"
+	"github.com/a-h/templ/cmd/templ/generatecmd/proxy"
+)
+
+// overlayErrors converts the errors of files that failed to generate into
+// errors shown in the browser error overlay. File names are made relative to
+// dir.
+func overlayErrors(dir string, fileErrors map[string]error) (errs []proxy.Error) {
+	fileNames := make([]string, 0, len(fileErrors))
+	for fileName := range fileErrors {
+		fileNames = append(fileNames, fileName)
+	}
+	sort.Strings(fileNames)
+	for _, fileName := range fileNames {
+		e := overlayError(fileErrors[fileName])
+		e.FileName = fileName
+		if rel, err := filepath.Rel(dir, fileName); err == nil && !strings.HasPrefix(rel, "..") {
+			e.FileName = filepath.ToSlash(rel)
+		}
+		errs = append(errs, e)
+	}
+	return errs
+}
+
+// overlayError extracts the position and message from a generation error.
+func overlayError(err error) (e proxy.Error) {
+	var pe parse.ParseError
+	if errors.As(err, &pe) {
+		return proxy.Error{Line: pe.Pos.Line + 1, Col: pe.Pos.Col + 1, Message: pe.Msg}
+	}
+	var le LintError
+	if errors.As(err, &le) && len(le.Diagnostics) > 0 {
+		d := le.Diagnostics[0]
+		return proxy.Error{Line: int(d.Range.From.Line) + 1, Col: int(d.Range.From.Col) + 1, Message: d.Message}
+	}
+	var el scanner.ErrorList
+	if errors.As(err, &el) && len(el) > 0 && strings.HasSuffix(el[0].Pos.Filename, ".templ") {
+		// The positions of Go formatting errors are remapped to the template,
+		// where lines are 1-based, and columns are 0-based.
+		return proxy.Error{Line: el[0].Pos.Line, Col: el[0].Pos.Column + 1, Message: el[0].Msg}
+	}
+	return proxy.Error{Message: err.Error()}
+}
@@ -0,0 +1,151 @@
+package proxy
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+const (
+	certFileName = "localhost.pem"
+	keyFileName  = "localhost-key.pem"
+	// certValidity is how long generated certificates are valid for.
+	certValidity = 365 * 24 * time.Hour
+	// certRenewal is how long before expiry a certificate is replaced.
+	certRenewal = 24 * time.Hour
+)
+
+// LocalCertificate returns a self-signed certificate for localhost, and the
+// given hosts, to serve the proxy over HTTPS.
+//
+// The certificate is stored in dir, and reused until it's about to expire, so
+// that the browser doesn't have to accept a new certificate each time the proxy
+// starts. If dir is empty, the certificate isn't stored.
+func LocalCertificate(dir string, hosts ...string) (cert tls.Certificate, err error) {
+	hosts = certificateHosts(hosts)
+	if dir != "" {
+		cert, err = readCertificate(dir, hosts)
+		if err == nil {
+			return cert, nil
+		}
+	}
+	certPEM, keyPEM, err := generateCertificate(hosts, time.Now())
+	if err != nil {
+		return cert, fmt.Errorf("failed to generate certificate: %w", err)
+	}
+	if dir != "" {
+		if err = writeCertificate(dir, certPEM, keyPEM); err != nil {
+			return cert, err
+		}
+	}
+	return tls.X509KeyPair(certPEM, keyPEM)
+}
+
+// certificateHosts returns the hosts that the certificate is valid for. Empty
+// and unspecified addresses, e.g. 0.0.0.0, can't be browsed to, so they're skipped.
+func certificateHosts(hosts []string) (filtered []string) {
+	filtered = []string{"localhost", "127.0.0.1", "::1"}
+	for _, host := range hosts {
+		if host == "" || slices.Contains(filtered, host) {
+			continue
+		}
+		if ip := net.ParseIP(host); ip != nil && ip.IsUnspecified() {
+			continue
+		}
+		filtered = append(filtered, host)
+	}
+	return filtered
+}
+
+// DefaultCertificateDir is the directory that generated certificates are stored in.
+func DefaultCertificateDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "templ")
+}
+
+func readCertificate(dir string, hosts []string) (cert tls.Certificate, err error) {
+	cert, err = tls.LoadX509KeyPair(filepath.Join(dir, certFileName), filepath.Join(dir, keyFileName))
+	if err != nil {
+		return cert, err
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return cert, err
+	}
+	if time.Now().Add(certRenewal).After(leaf.NotAfter) {
+		return cert, errors.New("certificate is about to expire")
+	}
+	for _, host := range hosts {
+		if err = leaf.VerifyHostname(host); err != nil {
+			return cert, err
+		}
+	}
+	cert.Leaf = leaf
+	return cert, nil
+}
+
+func writeCertificate(dir string, certPEM, keyPEM []byte) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create certificate directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, keyFileName), keyPEM, 0o600); err != nil {
+		return fmt.Errorf("failed to write certificate key: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, certFileName), certPEM, 0o644); err != nil {
+		return fmt.Errorf("failed to write certificate: %w", err)
+	}
+	return nil
+}
+
+func generateCertificate(hosts []string, now time.Time) (certPEM, keyPEM []byte, err error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+	template := x509.Certificate{
+		SerialNumber:          serialNumber,
+		Subject:               pkix.Name{Organization: []string{"templ development proxy"}, CommonName: "localhost"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(certValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+			continue
+		}
+		template.DNSNames = append(template.DNSNames, host)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+	return certPEM, keyPEM, nil
+}
@@ -0,0 +1,76 @@
+package proxy
+
+import (
+	"bytes"
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLocalCertificate(t *testing.T) {
+	t.Run("certificates are valid for localhost and the given hosts", func(t *testing.T) {
+		cert, err := LocalCertificate("", "0.0.0.0", "dev.example.com")
+		if err != nil {
+			t.Fatalf("failed to create certificate: %v", err)
+		}
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			t.Fatalf("failed to parse certificate: %v", err)
+		}
+		for _, host := range []string{"localhost", "127.0.0.1", "::1", "dev.example.com"} {
+			if err := leaf.VerifyHostname(host); err != nil {
+				t.Errorf("expected certificate to be valid for %q: %v", host, err)
+			}
+		}
+	})
+	t.Run("stored certificates are reused", func(t *testing.T) {
+		dir := t.TempDir()
+		first, err := LocalCertificate(dir)
+		if err != nil {
+			t.Fatalf("failed to create certificate: %v", err)
+		}
+		second, err := LocalCertificate(dir)
+		if err != nil {
+			t.Fatalf("failed to load certificate: %v", err)
+		}
+		if !bytes.Equal(first.Certificate[0], second.Certificate[0]) {
+			t.Error("expected the stored certificate to be reused")
+		}
+		info, err := os.Stat(filepath.Join(dir, keyFileName))
+		if err != nil {
+			t.Fatalf("failed to stat key: %v", err)
+		}
+		if info.Mode().Perm() != 0o600 {
+			t.Errorf("expected the key to only be readable by the user, got %v", info.Mode().Perm())
+		}
+	})
+	t.Run("stored certificates are replaced if they don't cover the hosts", func(t *testing.T) {
+		dir := t.TempDir()
+		first, err := LocalCertificate(dir)
+		if err != nil {
+			t.Fatalf("failed to create certificate: %v", err)
+		}
+		second, err := LocalCertificate(dir, "192.168.1.10")
+		if err != nil {
+			t.Fatalf("failed to create certificate: %v", err)
+		}
+		if bytes.Equal(first.Certificate[0], second.Certificate[0]) {
+			t.Error("expected a new certificate to be created")
+		}
+	})
+	t.Run("stored certificates are replaced before they expire", func(t *testing.T) {
+		dir := t.TempDir()
+		certPEM, keyPEM, err := generateCertificate(certificateHosts(nil), time.Now().Add(-certValidity))
+		if err != nil {
+			t.Fatalf("failed to generate certificate: %v", err)
+		}
+		if err = writeCertificate(dir, certPEM, keyPEM); err != nil {
+			t.Fatalf("failed to write certificate: %v", err)
+		}
+		if _, err = readCertificate(dir, certificateHosts(nil)); err == nil {
+			t.Error("expected the expiring certificate to be rejected")
+		}
+	})
+}
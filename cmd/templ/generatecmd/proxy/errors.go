@@ -0,0 +1,165 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Error is displayed in the browser error overlay.
+type Error struct {
+	// FileName of the template file.
+	FileName string `json:"fileName"`
+	// Line and Col are 1-based. If Line is zero, the position is unknown.
+	Line int `json:"line,omitempty"`
+	Col  int `json:"col,omitempty"`
+	// Message describing the error.
+	Message string `json:"message"`
+	// CodeFrame is the source code around the error, with the position marked.
+	CodeFrame string `json:"codeFrame,omitempty"`
+	// Runtime is true if the error was returned while rendering a template,
+	// rather than during code generation.
+	Runtime bool `json:"runtime,omitempty"`
+}
+
+// errorState is the set of errors currently shown in the browser overlay.
+type errorState struct {
+	m          sync.Mutex
+	generation []Error
+	runtime    []Error
+}
+
+func (s *errorState) setGeneration(errs []Error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.generation = errs
+}
+
+// addRuntime adds a runtime error, returning false if it's already shown.
+func (s *errorState) addRuntime(e Error) (added bool) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	for _, existing := range s.runtime {
+		if existing == e {
+			return false
+		}
+	}
+	s.runtime = append(s.runtime, e)
+	return true
+}
+
+func (s *errorState) clearRuntime() {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.runtime = nil
+}
+
+func (s *errorState) all() []Error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	all := make([]Error, 0, len(s.generation)+len(s.runtime))
+	all = append(all, s.generation...)
+	return append(all, s.runtime...)
+}
+
+func (s *errorState) JSON() string {
+	// Marshalling a slice of structs with string and int fields can't fail,
+	// and the output doesn't contain newlines, so it's safe to send as SSE data.
+	b, _ := json.Marshal(s.all())
+	return string(b)
+}
+
+// runtimeErrorExpression matches the output of templ.Error.Error().
+var runtimeErrorExpression = regexp.MustCompile(`([^\s"'<>:]+\.templ): error at line (\d+), col (\d+): ([^\n<]*)`)
+
+// parseRuntimeErrors finds templ.Error messages in the body of a response.
+func parseRuntimeErrors(body []byte) (errs []Error) {
+	for _, m := range runtimeErrorExpression.FindAllSubmatch(body, -1) {
+		line, _ := strconv.Atoi(string(m[2]))
+		col, _ := strconv.Atoi(string(m[3]))
+		errs = append(errs, Error{
+			FileName: string(m[1]),
+			Line:     line,
+			// templ.Error columns are zero-based.
+			Col:     col + 1,
+			Message: strings.TrimSpace(string(m[4])),
+			Runtime: true,
+		})
+	}
+	return errs
+}
+
+// codeFrameContextLines is the number of lines shown before and after the error.
+const codeFrameContextLines = 2
+
+// CodeFrame returns the source code around the 1-based line and column, with
+// the position marked, e.g.:
+//
+//	  1 | templ Hello() {
+//	> 2 | 	<div>{ name </div>
+//	    | 	       ^
+//	  3 | }
+func CodeFrame(src []byte, line, col int) string {
+	if line < 1 {
+		return ""
+	}
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(src))
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if line > len(lines) {
+		return ""
+	}
+	from, to := max(line-codeFrameContextLines, 1), min(line+codeFrameContextLines, len(lines))
+	width := len(strconv.Itoa(to))
+	var sb strings.Builder
+	for i := from; i <= to; i++ {
+		marker := " "
+		if i == line {
+			marker = ">"
+		}
+		sb.WriteString(strings.TrimRight(fmt.Sprintf("%s %*d | %s", marker, width, i, lines[i-1]), " "))
+		sb.WriteString("\n")
+		if i == line && col > 0 {
+			// Keep tabs, so that the caret lines up with the source code.
+			var indent strings.Builder
+			for j, r := range lines[i-1] {
+				if j >= col-1 {
+					break
+				}
+				if r == '\t' {
+					indent.WriteRune('\t')
+					continue
+				}
+				indent.WriteRune(' ')
+			}
+			fmt.Fprintf(&sb, "  %s | %s^\n", strings.Repeat(" ", width), indent.String())
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// withCodeFrame reads the template file and sets the code frame of the error.
+func (e Error) withCodeFrame(dir string) Error {
+	if e.CodeFrame != "" || e.Line == 0 {
+		return e
+	}
+	fileName := e.FileName
+	if !filepath.IsAbs(fileName) && dir != "" {
+		fileName = filepath.Join(dir, filepath.FromSlash(fileName))
+	}
+	src, err := os.ReadFile(fileName)
+	if err != nil {
+		return e
+	}
+	e.CodeFrame = CodeFrame(src, e.Line, e.Col)
+	return e
+}
@@ -0,0 +1,121 @@
+package proxy
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCodeFrame(t *testing.T) {
+	src := []byte("package main\n\ntempl Hello(name string) {\n\t<div>{ name </div>\n}\n")
+	tests := []struct {
+		name      string
+		line, col int
+		expected  string
+	}{
+		{
+			name: "the line is marked, with context lines before and after",
+			line: 4,
+			col:  7,
+			expected: `  2 |
+  3 | templ Hello(name string) {
+> 4 | 	<div>{ name </div>
+    | 	     ^
+  5 | }`,
+		},
+		{
+			name: "context is limited to the start of the file",
+			line: 1,
+			expected: `> 1 | package main
+  2 |
+  3 | templ Hello(name string) {`,
+		},
+		{
+			name:     "lines outside the file result in an empty frame",
+			line:     10,
+			expected: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := CodeFrame(src, tt.line, tt.col)
+			if diff := cmp.Diff(tt.expected, actual); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestParseRuntimeErrors(t *testing.T) {
+	body := []byte("<p>components/page.templ: error at line 12, col 4: name is empty</p>")
+	expected := []Error{
+		{FileName: "components/page.templ", Line: 12, Col: 5, Message: "name is empty", Runtime: true},
+	}
+	if diff := cmp.Diff(expected, parseRuntimeErrors(body)); diff != "" {
+		t.Error(diff)
+	}
+	if errs := parseRuntimeErrors([]byte("Internal Server Error")); len(errs) != 0 {
+		t.Errorf("expected no errors, got %v", errs)
+	}
+}
+
+func TestServerErrors(t *testing.T) {
+	newResponse := func(contentType, accept string) *http.Response {
+		r := &http.Response{
+			StatusCode: http.StatusInternalServerError,
+			Body:       io.NopCloser(strings.NewReader("page.templ: error at line 1, col 2: failed\n")),
+			Header:     make(http.Header),
+			Request: &http.Request{
+				URL:    &url.URL{Scheme: "http", Host: "example.com"},
+				Header: make(http.Header),
+			},
+		}
+		r.Header.Set("Content-Type", contentType)
+		r.Request.Header.Set("Accept", accept)
+		return r
+	}
+	log := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	t.Run("templ errors in 5xx responses are added to the overlay", func(t *testing.T) {
+		h := New(log, "127.0.0.1", 7474, &url.URL{Scheme: "http", Host: "example.com"})
+		if err := h.modifyResponse(newResponse("text/plain", "*/*")); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expected := `[{"fileName":"page.templ","line":1,"col":3,"message":"failed","runtime":true}]`
+		if actual := h.errors.JSON(); actual != expected {
+			t.Errorf("expected %s, got %s", expected, actual)
+		}
+	})
+	t.Run("non-HTML responses to browsers are replaced with a page that shows the overlay", func(t *testing.T) {
+		h := New(log, "127.0.0.1", 7474, &url.URL{Scheme: "http", Host: "example.com"})
+		r := newResponse("text/plain", "text/html,*/*")
+		if err := h.modifyResponse(r); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !strings.Contains(string(body), getScriptTag(DefaultPrefix, "")) {
+			t.Errorf("expected the reload script to be inserted, got %s", body)
+		}
+		if !strings.Contains(string(body), "page.templ: error at line 1, col 2: failed") {
+			t.Errorf("expected the original body to be included, got %s", body)
+		}
+	})
+	t.Run("setting generation errors clears runtime errors", func(t *testing.T) {
+		h := New(log, "127.0.0.1", 7474, &url.URL{Scheme: "http", Host: "example.com"})
+		if err := h.modifyResponse(newResponse("text/plain", "*/*")); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		h.SetErrors(nil)
+		if actual := h.errors.JSON(); actual != "[]" {
+			t.Errorf("expected no errors, got %s", actual)
+		}
+	})
+}
@@ -3,12 +3,15 @@ package proxy
 import (
 	"bytes"
 	"compress/gzip"
+	"crypto/tls"
+	"encoding/json"
 	"fmt"
 	"html"
 	"io"
 	stdlog "log"
 	"log/slog"
 	"math"
+	"net"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
@@ -27,34 +30,91 @@ import (
 //go:embed script.js
 var script string
 
+// DefaultPrefix is the path prefix of the proxy's endpoints, e.g. /_templ/reload/script.js.
+const DefaultPrefix = "/_templ"
+
 type Handler struct {
 	log    *slog.Logger
 	URL    string
 	Target *url.URL
+	// Dir is the directory that template file names in errors are relative to.
+	// It's used to read the source code shown in the error overlay.
+	Dir    string
 	p      *httputil.ReverseProxy
 	sse    *sse.Handler
+	errors *errorState
+	addr   string
+	// prefix of the proxy's endpoints.
+	prefix string
+	// targetTLSConfig is used to connect to HTTPS targets.
+	targetTLSConfig *tls.Config
+	// tlsConfig is used to serve the proxy over HTTPS, if set.
+	tlsConfig *tls.Config
+	// sourceMapViewer is served at the sourcemaps endpoint, if set.
+	sourceMapViewer http.Handler
+}
+
+// WithPrefix sets the path prefix of the proxy's endpoints, so that they don't
+// collide with the routes of the app. The default is /_templ.
+func WithPrefix(prefix string) func(*Handler) {
+	return func(h *Handler) {
+		prefix = "/" + strings.Trim(prefix, "/")
+		if prefix == "/" {
+			prefix = DefaultPrefix
+		}
+		h.prefix = prefix
+	}
+}
+
+// WithTargetTLSConfig sets the TLS configuration used to connect to HTTPS
+// targets, e.g. to trust a self-signed certificate.
+func WithTargetTLSConfig(c *tls.Config) func(*Handler) {
+	return func(h *Handler) {
+		h.targetTLSConfig = c
+	}
+}
+
+// WithTLS serves the proxy over HTTPS, using the certificate.
+func WithTLS(cert tls.Certificate) func(*Handler) {
+	return func(h *Handler) {
+		h.tlsConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+	}
+}
+
+// WithSourceMapViewer serves the source map viewer, e.g. at /_templ/sourcemaps/.
+func WithSourceMapViewer(viewer http.Handler) func(*Handler) {
+	return func(h *Handler) {
+		h.sourceMapViewer = viewer
+	}
+}
+
+// SourceMapViewerURL returns the URL of the source map viewer.
+func (h *Handler) SourceMapViewerURL() string {
+	return h.URL + h.prefix + "/sourcemaps/"
 }
 
-func getScriptTag(nonce string) string {
+func getScriptTag(prefix, nonce string) string {
+	src := html.EscapeString(prefix + "/reload/script.js")
 	if nonce != "" {
 		var sb strings.Builder
-		sb.WriteString(`<script src="/_templ/reload/script.js" nonce="`)
+		sb.WriteString(`<script src="` + src + `" nonce="`)
 		sb.WriteString(html.EscapeString(nonce))
 		sb.WriteString(`"></script>`)
 		return sb.String()
 	}
-	return `<script src="/_templ/reload/script.js"></script>`
+	return `<script src="` + src + `"></script>`
 }
 
-func insertScriptTagIntoBody(nonce, body string) (updated string) {
+func insertScriptTagIntoBody(prefix, nonce, body string) (updated string) {
+	scriptTag := getScriptTag(prefix, nonce)
 	doc, err := goquery.NewDocumentFromReader(strings.NewReader(body))
 	if err != nil {
-		return strings.Replace(body, "</body>", getScriptTag(nonce)+"</body>", -1)
+		return strings.Replace(body, "</body>", scriptTag+"</body>", -1)
 	}
-	doc.Find("body").AppendHtml(getScriptTag(nonce))
+	doc.Find("body").AppendHtml(scriptTag)
 	r, err := doc.Html()
 	if err != nil {
-		return strings.Replace(body, "</body>", getScriptTag(nonce)+"</body>", -1)
+		return strings.Replace(body, "</body>", scriptTag+"</body>", -1)
 	}
 	return r
 }
@@ -75,36 +135,19 @@ func (h *Handler) modifyResponse(r *http.Response) error {
 		log.Debug("Skipping response modification because templ-skip-modify header is set")
 		return nil
 	}
+	if r.StatusCode >= 500 {
+		if err := h.handleServerError(r); err != nil {
+			return err
+		}
+	}
 	if contentType := r.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "text/html") {
 		log.Debug("Skipping response modification because content type is not text/html", slog.String("content-type", contentType))
 		return nil
 	}
 
 	// Set up readers and writers.
-	newReader := func(in io.Reader) (out io.Reader, err error) {
-		return in, nil
-	}
-	newWriter := func(out io.Writer) io.WriteCloser {
-		return passthroughWriteCloser{out}
-	}
-	switch r.Header.Get("Content-Encoding") {
-	case "gzip":
-		newReader = func(in io.Reader) (out io.Reader, err error) {
-			return gzip.NewReader(in)
-		}
-		newWriter = func(out io.Writer) io.WriteCloser {
-			return gzip.NewWriter(out)
-		}
-	case "br":
-		newReader = func(in io.Reader) (out io.Reader, err error) {
-			return brotli.NewReader(in), nil
-		}
-		newWriter = func(out io.Writer) io.WriteCloser {
-			return brotli.NewWriter(out)
-		}
-	case "":
-		log.Debug("No content encoding header found")
-	default:
+	newReader, newWriter, ok := codec(r.Header.Get("Content-Encoding"))
+	if !ok {
 		h.log.Warn(unsupportedContentEncoding, slog.String("encoding", r.Header.Get("Content-Encoding")))
 	}
 
@@ -121,7 +164,7 @@ func (h *Handler) modifyResponse(r *http.Response) error {
 
 	// Update it.
 	csp := r.Header.Get("Content-Security-Policy")
-	updated := insertScriptTagIntoBody(parseNonce(csp), string(body))
+	updated := insertScriptTagIntoBody(h.prefix, parseNonce(csp), string(body))
 	if log.Enabled(r.Request.Context(), slog.LevelDebug) {
 		if len(updated) == len(body) {
 			log.Debug("Reload script not inserted")
@@ -149,6 +192,85 @@ func (h *Handler) modifyResponse(r *http.Response) error {
 	return nil
 }
 
+// codec returns functions to decode and encode a body with the content
+// encoding. If the encoding isn't supported, the body is passed through, and
+// ok is false.
+func codec(encoding string) (newReader func(io.Reader) (io.Reader, error), newWriter func(io.Writer) io.WriteCloser, ok bool) {
+	switch encoding {
+	case "gzip":
+		newReader = func(in io.Reader) (out io.Reader, err error) {
+			return gzip.NewReader(in)
+		}
+		newWriter = func(out io.Writer) io.WriteCloser {
+			return gzip.NewWriter(out)
+		}
+		return newReader, newWriter, true
+	case "br":
+		newReader = func(in io.Reader) (out io.Reader, err error) {
+			return brotli.NewReader(in), nil
+		}
+		newWriter = func(out io.Writer) io.WriteCloser {
+			return brotli.NewWriter(out)
+		}
+		return newReader, newWriter, true
+	}
+	newReader = func(in io.Reader) (out io.Reader, err error) {
+		return in, nil
+	}
+	newWriter = func(out io.Writer) io.WriteCloser {
+		return passthroughWriteCloser{out}
+	}
+	return newReader, newWriter, encoding == ""
+}
+
+// handleServerError looks for templ.Error details in the body of a 5xx
+// response, and shows them in the error overlay.
+//
+// If the response isn't HTML, but the request is from a browser, the body is
+// replaced with a HTML page that includes the reload script, so that the
+// overlay can be displayed.
+func (h *Handler) handleServerError(r *http.Response) error {
+	raw, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(raw))
+	newReader, _, _ := codec(r.Header.Get("Content-Encoding"))
+	decoded, err := newReader(bytes.NewReader(raw))
+	if err != nil {
+		return nil
+	}
+	body, err := io.ReadAll(decoded)
+	if err != nil {
+		return nil
+	}
+	errs := parseRuntimeErrors(body)
+	if len(errs) == 0 {
+		return nil
+	}
+	var added bool
+	for _, e := range errs {
+		if h.errors.addRuntime(e.withCodeFrame(h.Dir)) {
+			added = true
+		}
+	}
+	if added {
+		h.log.Debug("Runtime error detected", slog.String("url", r.Request.URL.String()), slog.Int("status", r.StatusCode))
+		h.sse.Send(errorsEventType, h.errors.JSON())
+	}
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "text/html") || !strings.Contains(r.Request.Header.Get("Accept"), "text/html") {
+		return nil
+	}
+	page := "<!DOCTYPE html><html><head><title>" + html.EscapeString(http.StatusText(r.StatusCode)) + "</title></head><body><pre>" + html.EscapeString(string(body)) + "</pre></body></html>"
+	r.Body = io.NopCloser(strings.NewReader(page))
+	r.Header.Set("Content-Type", "text/html; charset=utf-8")
+	r.Header.Del("Content-Encoding")
+	r.ContentLength = int64(len(page))
+	r.Header.Set("Content-Length", strconv.Itoa(len(page)))
+	return nil
+}
+
 func parseNonce(csp string) (nonce string) {
 outer:
 	for _, rawDirective := range strings.Split(csp, ";") {
@@ -171,27 +293,55 @@ outer:
 	return nonce
 }
 
-func New(log *slog.Logger, bind string, port int, target *url.URL) (h *Handler) {
+func New(log *slog.Logger, bind string, port int, target *url.URL, opts ...func(*Handler)) (h *Handler) {
 	p := httputil.NewSingleHostReverseProxy(target)
 	p.ErrorLog = stdlog.New(os.Stderr, "Proxy to target error: ", 0)
-	p.Transport = &roundTripper{
-		maxRetries:      20,
-		initialDelay:    100 * time.Millisecond,
-		backoffExponent: 1.5,
-	}
 	h = &Handler{
 		log:    log,
-		URL:    fmt.Sprintf("http://%s:%d", bind, port),
 		Target: target,
 		p:      p,
 		sse:    sse.New(),
+		errors: &errorState{},
+		addr:   net.JoinHostPort(bind, strconv.Itoa(port)),
+		prefix: DefaultPrefix,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	scheme := "http"
+	if h.tlsConfig != nil {
+		scheme = "https"
+	}
+	h.URL = fmt.Sprintf("%s://%s", scheme, h.addr)
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if h.targetTLSConfig != nil {
+		transport.TLSClientConfig = h.targetTLSConfig
+	}
+	p.Transport = &roundTripper{
+		transport:       transport,
+		maxRetries:      20,
+		initialDelay:    100 * time.Millisecond,
+		backoffExponent: 1.5,
 	}
 	p.ModifyResponse = h.modifyResponse
 	return h
 }
 
+// ListenAndServe starts the proxy, serving HTTPS if a certificate was provided with WithTLS.
+func (p *Handler) ListenAndServe() error {
+	s := &http.Server{
+		Addr:      p.addr,
+		Handler:   p,
+		TLSConfig: p.tlsConfig,
+	}
+	if p.tlsConfig != nil {
+		return s.ListenAndServeTLS("", "")
+	}
+	return s.ListenAndServe()
+}
+
 func (p *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	if r.URL.Path == "/_templ/reload/script.js" {
+	if r.URL.Path == p.prefix+"/reload/script.js" {
 		// Provides a script that reloads the page.
 		w.Header().Add("Content-Type", "text/javascript")
 		_, err := io.WriteString(w, script)
@@ -200,7 +350,26 @@ func (p *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
-	if r.URL.Path == "/_templ/reload/events" {
+	if r.URL.Path == p.prefix+"/reload/ws" {
+		// Sends reload events, and receives the page URL and JavaScript errors.
+		p.serveWebSocket(w, r)
+		return
+	}
+	if r.URL.Path == p.prefix+"/status" {
+		// Provides the connected clients.
+		p.serveStatus(w, r)
+		return
+	}
+	if r.URL.Path == p.prefix+"/reload/errors" {
+		// Provides the errors currently shown in the error overlay.
+		w.Header().Add("Content-Type", "application/json")
+		_, err := io.WriteString(w, p.errors.JSON())
+		if err != nil {
+			p.log.Error("Failed to write errors", slog.Any("error", err))
+		}
+		return
+	}
+	if r.URL.Path == p.prefix+"/reload/events" {
 		switch r.Method {
 		case http.MethodGet:
 			// Provides a list of messages including a reload message.
@@ -214,6 +383,16 @@ func (p *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "only GET or POST method allowed", http.StatusMethodNotAllowed)
 		return
 	}
+	if p.sourceMapViewer != nil && r.URL.Path == p.prefix+"/sourcemaps" {
+		// The viewer uses relative URLs, so the path must end with a slash.
+		http.Redirect(w, r, p.prefix+"/sourcemaps/", http.StatusMovedPermanently)
+		return
+	}
+	if p.sourceMapViewer != nil && strings.HasPrefix(r.URL.Path, p.prefix+"/sourcemaps/") {
+		// Provides the source map viewer.
+		http.StripPrefix(p.prefix+"/sourcemaps", p.sourceMapViewer).ServeHTTP(w, r)
+		return
+	}
 	p.p.ServeHTTP(w, r)
 }
 
@@ -221,7 +400,40 @@ func (p *Handler) SendSSE(eventType string, data string) {
 	p.sse.Send(eventType, data)
 }
 
+// SendHTML notifies browsers to refetch the current page, and update the DOM
+// in place, keeping focus, form inputs and scroll position.
+func (p *Handler) SendHTML() {
+	p.sse.Send("html", "morph")
+}
+
+// SendCSS notifies browsers to swap the stylesheets that match the updated CSS
+// files, without reloading the page. If no stylesheets match, all of them are
+// swapped.
+func (p *Handler) SendCSS(fileNames []string) {
+	// Marshalling a slice of strings can't fail.
+	b, _ := json.Marshal(fileNames)
+	p.sse.Send("css", string(b))
+}
+
+// errorsEventType is the server-sent event type used to update the error overlay.
+const errorsEventType = "templ-errors"
+
+// SetErrors replaces the generation errors shown in the browser error overlay.
+// An empty list clears the overlay. Runtime errors are cleared too, because
+// they're from the previous version of the code.
+func (p *Handler) SetErrors(errs []Error) {
+	withCodeFrames := make([]Error, len(errs))
+	for i, e := range errs {
+		withCodeFrames[i] = e.withCodeFrame(p.Dir)
+	}
+	p.errors.setGeneration(withCodeFrames)
+	p.errors.clearRuntime()
+	p.sse.Send(errorsEventType, p.errors.JSON())
+}
+
 type roundTripper struct {
+	// transport used to make requests. If nil, http.DefaultTransport is used.
+	transport       http.RoundTripper
 	maxRetries      int
 	initialDelay    time.Duration
 	backoffExponent float64
@@ -236,6 +448,13 @@ func (rt *roundTripper) setShouldSkipResponseModificationHeader(r *http.Request,
 	resp.Header.Set("templ-skip-modify", "true")
 }
 
+func (rt *roundTripper) roundTripper() http.RoundTripper {
+	if rt.transport == nil {
+		return http.DefaultTransport
+	}
+	return rt.transport
+}
+
 func (rt *roundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
 	// Read and buffer the body.
 	var bodyBytes []byte
@@ -259,7 +478,7 @@ func (rt *roundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
 		}
 
 		// Execute the request.
-		resp, err = http.DefaultTransport.RoundTrip(req)
+		resp, err = rt.roundTripper().RoundTrip(req)
 		if err != nil {
 			time.Sleep(rt.initialDelay * time.Duration(math.Pow(rt.backoffExponent, float64(retries))))
 			continue
@@ -273,12 +492,28 @@ func (rt *roundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
 	return nil, fmt.Errorf("max retries reached: %q", r.URL.String())
 }
 
-func NotifyProxy(host string, port int) error {
-	urlStr := fmt.Sprintf("http://%s:%d/_templ/reload/events", host, port)
+// NotifyProxy sends a reload event to the proxy. If the proxy is served over
+// HTTPS, its certificate isn't verified, because it's a generated local certificate.
+func NotifyProxy(host string, port int, prefix string, useTLS bool) error {
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+	scheme := "http"
+	client := http.DefaultClient
+	if useTLS {
+		scheme = "https"
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+		client = &http.Client{Transport: transport}
+	}
+	urlStr := fmt.Sprintf("%s://%s%s/reload/events", scheme, net.JoinHostPort(host, strconv.Itoa(port)), "/"+strings.Trim(prefix, "/"))
 	req, err := http.NewRequest(http.MethodPost, urlStr, nil)
 	if err != nil {
 		return err
 	}
-	_, err = http.DefaultClient.Do(req)
-	return err
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
 }
@@ -136,8 +136,8 @@ func TestProxy(t *testing.T) {
 		r.Header.Set("Content-Type", "text/html, charset=utf-8")
 		r.Header.Set("Content-Length", "26")
 
-		expectedString := insertScriptTagIntoBody("", `<html><body></body></html>`)
-		if !strings.Contains(expectedString, getScriptTag("")) {
+		expectedString := insertScriptTagIntoBody(DefaultPrefix, "", `<html><body></body></html>`)
+		if !strings.Contains(expectedString, getScriptTag(DefaultPrefix, "")) {
 			t.Fatalf("expected the script tag to be inserted, but it wasn't: %q", expectedString)
 		}
 
@@ -178,8 +178,8 @@ func TestProxy(t *testing.T) {
 		const nonce = "this-is-the-nonce"
 		r.Header.Set("Content-Security-Policy", fmt.Sprintf("script-src 'nonce-%s'", nonce))
 
-		expectedString := insertScriptTagIntoBody(nonce, `<html><body></body></html>`)
-		if !strings.Contains(expectedString, getScriptTag(nonce)) {
+		expectedString := insertScriptTagIntoBody(DefaultPrefix, nonce, `<html><body></body></html>`)
+		if !strings.Contains(expectedString, getScriptTag(DefaultPrefix, nonce)) {
 			t.Fatalf("expected the script tag to be inserted, but it wasn't: %q", expectedString)
 		}
 
@@ -218,8 +218,8 @@ func TestProxy(t *testing.T) {
 		r.Header.Set("Content-Type", "text/html, charset=utf-8")
 		r.Header.Set("Content-Length", "26")
 
-		expectedString := insertScriptTagIntoBody("", `<html><body><script>console.log("<body></body>")</script></body></html>`)
-		if !strings.Contains(expectedString, getScriptTag("")) {
+		expectedString := insertScriptTagIntoBody(DefaultPrefix, "", `<html><body><script>console.log("<body></body>")</script></body></html>`)
+		if !strings.Contains(expectedString, getScriptTag(DefaultPrefix, "")) {
 			t.Fatalf("expected the script tag to be inserted, but it wasn't: %q", expectedString)
 		}
 		if !strings.Contains(expectedString, `console.log("<body></body>")`) {
@@ -295,7 +295,7 @@ func TestProxy(t *testing.T) {
 		}
 		gzw.Close()
 
-		expectedString := insertScriptTagIntoBody("", body)
+		expectedString := insertScriptTagIntoBody(DefaultPrefix, "", body)
 
 		var expectedBytes bytes.Buffer
 		gzw = gzip.NewWriter(&expectedBytes)
@@ -356,7 +356,7 @@ func TestProxy(t *testing.T) {
 		}
 		brw.Close()
 
-		expectedString := insertScriptTagIntoBody("", body)
+		expectedString := insertScriptTagIntoBody(DefaultPrefix, "", body)
 
 		var expectedBytes bytes.Buffer
 		brw = brotli.NewWriter(&expectedBytes)
@@ -467,7 +467,7 @@ func TestProxy(t *testing.T) {
 		// Act: notify the proxy.
 		select { // Either SSE is listening or an error occurred.
 		case <-sseListening:
-			err = NotifyProxy(u2.Hostname(), port)
+			err = NotifyProxy(u2.Hostname(), port, DefaultPrefix, false)
 			if err != nil {
 				t.Fatalf("unexpected error notifying proxy: %v", err)
 			}
@@ -1,10 +1,291 @@
 (function() {
-  let templ_reloadSrc = window.templ_reloadSrc || new EventSource("/_templ/reload/events");
-  templ_reloadSrc.onmessage = (event) => {
-    if (event && event.data === "reload") {
+  if (window.templ_reloadSrc) {
+    // The script has already been loaded.
+    return;
+  }
+  // The proxy's endpoints are relative to the script, so that the prefix can be configured.
+  const base = (document.currentScript && document.currentScript.src)
+    ? new URL(document.currentScript.src).pathname.replace(/\/reload\/script\.js$/, "")
+    : "/_templ";
+  const handlers = {
+    message: (data) => {
+      if (data === "reload") {
+        templ_reload();
+      }
+    },
+    "templ-errors": (data) => templ_renderErrors(JSON.parse(data)),
+    css: (data) => templ_swapStylesheets(JSON.parse(data)),
+    html: () => templ_morphPage().catch(templ_reload),
+  };
+
+  // The ID of the last event is kept for the lifetime of the tab, so that
+  // events sent while the page is reloading aren't missed.
+  const lastEventIdKey = "templ_lastEventId";
+  function templ_lastEventIdQuery() {
+    try {
+      const id = window.sessionStorage.getItem(lastEventIdKey);
+      return id ? "?lastEventId=" + encodeURIComponent(id) : "";
+    } catch {
+      return "";
+    }
+  }
+  function templ_handleEvent(id, type, data) {
+    if (id) {
+      try {
+        window.sessionStorage.setItem(lastEventIdKey, id);
+      } catch {}
+    }
+    const handler = handlers[type];
+    if (handler) {
+      handler(data);
+    }
+  }
+
+  // Tabs in the background reload when they're next shown, so that saving a
+  // file with many tabs open doesn't reload them all at once.
+  let reloading = false;
+  function templ_reload() {
+    if (reloading) {
+      return;
+    }
+    reloading = true;
+    if (!document.hidden) {
       window.location.reload();
+      return;
     }
-  };
-  window.templ_reloadSrc = templ_reloadSrc;
+    document.addEventListener("visibilitychange", () => window.location.reload(), { once: true });
+  }
+
+  function templ_connectEventSource() {
+    const src = new EventSource(base + "/reload/events" + templ_lastEventIdQuery());
+    for (const type of Object.keys(handlers)) {
+      src.addEventListener(type, (event) => templ_handleEvent(event.lastEventId, type, event.data));
+    }
+    return { close: () => src.close(), send: () => {} };
+  }
+
+  // The WebSocket is used to report the page URL and JavaScript errors to
+  // templ. If it can't connect, server-sent events are used instead.
+  function templ_connectWebSocket() {
+    const url = new URL(base + "/reload/ws" + templ_lastEventIdQuery(), window.location.href);
+    url.protocol = url.protocol === "https:" ? "wss:" : "ws:";
+    const ws = new WebSocket(url);
+    let opened = false, closing = false;
+    const pending = [];
+    const conn = {
+      close: () => {
+        closing = true;
+        ws.close();
+      },
+      send: (msg) => {
+        if (ws.readyState !== WebSocket.OPEN) {
+          pending.push(msg);
+          return;
+        }
+        ws.send(JSON.stringify(msg));
+      },
+    };
+    ws.onopen = () => {
+      opened = true;
+      ws.send(JSON.stringify({ type: "location", url: window.location.href }));
+      pending.splice(0).forEach(conn.send);
+    };
+    ws.onmessage = (event) => {
+      const e = JSON.parse(event.data);
+      templ_handleEvent(e.id, e.type, e.data);
+    };
+    ws.onclose = () => {
+      if (closing) {
+        return;
+      }
+      if (!opened) {
+        window.templ_reloadSrc = templ_connectEventSource();
+        return;
+      }
+      setTimeout(() => {
+        const next = templ_connectWebSocket();
+        pending.splice(0).forEach(next.send);
+        window.templ_reloadSrc = next;
+      }, 1000);
+    };
+    return conn;
+  }
+
+  window.templ_reloadSrc = window.WebSocket ? templ_connectWebSocket() : templ_connectEventSource();
   window.onbeforeunload = () => window.templ_reloadSrc.close();
+
+  window.addEventListener("error", (event) => {
+    window.templ_reloadSrc.send({
+      type: "error",
+      url: window.location.href,
+      message: event.message,
+      source: event.filename,
+      line: event.lineno,
+      col: event.colno,
+      stack: event.error && event.error.stack,
+    });
+  });
+  window.addEventListener("unhandledrejection", (event) => {
+    window.templ_reloadSrc.send({
+      type: "error",
+      url: window.location.href,
+      message: "Unhandled promise rejection: " + String(event.reason),
+      stack: event.reason && event.reason.stack,
+    });
+  });
+
+  // Show any errors that occurred before the page was loaded.
+  fetch(base + "/reload/errors")
+    .then((resp) => resp.json())
+    .then(templ_renderErrors)
+    .catch(() => {});
+
+  // Replace the stylesheet links that match the updated files. If none match,
+  // e.g. because the CSS is served from a different path, all stylesheets are replaced.
+  function templ_swapStylesheets(fileNames) {
+    const links = Array.from(document.querySelectorAll('link[rel="stylesheet"]'));
+    const names = (fileNames || []).map((f) => f.split("/").pop());
+    let matched = links.filter((link) => names.includes(new URL(link.href, window.location.href).pathname.split("/").pop()));
+    if (matched.length === 0) {
+      matched = links;
+    }
+    for (const link of matched) {
+      const url = new URL(link.href, window.location.href);
+      url.searchParams.set("templ_reload", Date.now().toString());
+      const replacement = link.cloneNode();
+      replacement.href = url.toString();
+      // Remove the old stylesheet once the new one has loaded, to avoid a flash of unstyled content.
+      replacement.onload = replacement.onerror = () => link.remove();
+      link.after(replacement);
+    }
+  }
+
+  // Refetch the page, and update the DOM in place, so that focus, form inputs
+  // and the scroll position are kept.
+  async function templ_morphPage() {
+    const resp = await fetch(window.location.href, { headers: { "Accept": "text/html" } });
+    if (!resp.ok) {
+      throw new Error("failed to fetch page: " + resp.status);
+    }
+    const doc = new DOMParser().parseFromString(await resp.text(), "text/html");
+    const scrollX = window.scrollX, scrollY = window.scrollY;
+    document.title = doc.title;
+    templ_morphAttributes(document.body, doc.body);
+    templ_morphChildren(document.body, doc.body);
+    window.scrollTo(scrollX, scrollY);
+  }
+
+  function templ_isSameNode(a, b) {
+    if (a.nodeType !== b.nodeType) {
+      return false;
+    }
+    if (a.nodeType !== Node.ELEMENT_NODE) {
+      return true;
+    }
+    return a.tagName === b.tagName && a.id === b.id;
+  }
+
+  function templ_isKept(node) {
+    // The error overlay isn't part of the page.
+    return node.nodeType === Node.ELEMENT_NODE && node.id === "templ-error-overlay";
+  }
+
+  function templ_morphChildren(from, to) {
+    const toNodes = Array.from(to.childNodes);
+    let fromNodes = Array.from(from.childNodes).filter((n) => !templ_isKept(n));
+    for (let i = 0; i < toNodes.length; i++) {
+      const toNode = toNodes[i];
+      let fromNode = fromNodes[i];
+      if (!fromNode || !templ_isSameNode(fromNode, toNode)) {
+        // Elements with an id may have moved.
+        const match = toNode.id ? fromNodes.slice(i + 1).find((n) => templ_isSameNode(n, toNode)) : undefined;
+        if (match) {
+          from.insertBefore(match, fromNode || null);
+          fromNode = match;
+        } else {
+          fromNode = document.importNode(toNode, true);
+          from.insertBefore(fromNode, fromNodes[i] || null);
+        }
+        fromNodes = Array.from(from.childNodes).filter((n) => !templ_isKept(n));
+        if (!match) {
+          continue;
+        }
+      }
+      templ_morphNode(fromNode, toNode);
+    }
+    for (const extra of fromNodes.slice(toNodes.length)) {
+      extra.remove();
+    }
+  }
+
+  function templ_morphNode(from, to) {
+    if (from.nodeType !== Node.ELEMENT_NODE) {
+      if (from.nodeValue !== to.nodeValue) {
+        from.nodeValue = to.nodeValue;
+      }
+      return;
+    }
+    templ_morphAttributes(from, to);
+    // Keep the content of text areas, because it's user input.
+    if (from.tagName !== "TEXTAREA") {
+      templ_morphChildren(from, to);
+    }
+  }
+
+  function templ_morphAttributes(from, to) {
+    // Keep the current value of form inputs, because it's user input.
+    const isInput = from.tagName === "INPUT" || from.tagName === "SELECT" || from.tagName === "TEXTAREA";
+    const isKeptAttribute = (name) => isInput && (name === "value" || name === "checked" || name === "selected");
+    for (const attr of Array.from(from.attributes)) {
+      if (!to.hasAttribute(attr.name) && !isKeptAttribute(attr.name)) {
+        from.removeAttribute(attr.name);
+      }
+    }
+    for (const attr of Array.from(to.attributes)) {
+      if (from.getAttribute(attr.name) !== attr.value && !isKeptAttribute(attr.name)) {
+        from.setAttribute(attr.name, attr.value);
+      }
+    }
+  }
+
+  function templ_renderErrors(errors) {
+    let overlay = document.getElementById("templ-error-overlay");
+    if (overlay) {
+      overlay.remove();
+    }
+    if (!errors || errors.length === 0) {
+      return;
+    }
+    overlay = document.createElement("div");
+    overlay.id = "templ-error-overlay";
+    overlay.style.cssText = "position:fixed;inset:0;z-index:2147483647;overflow:auto;padding:2rem;background:rgba(0,0,0,0.85);color:#e8e8e8;font:14px/1.5 ui-monospace,SFMono-Regular,Menlo,Consolas,monospace;";
+    const close = document.createElement("button");
+    close.textContent = "×";
+    close.title = "Close";
+    close.style.cssText = "position:absolute;top:1rem;right:1rem;background:none;border:none;color:inherit;font-size:2rem;cursor:pointer;";
+    close.onclick = () => overlay.remove();
+    overlay.appendChild(close);
+    for (const e of errors) {
+      const section = document.createElement("div");
+      section.style.cssText = "max-width:960px;margin:0 auto 2rem;padding:1rem;border-top:4px solid #ff5555;background:#1e1e1e;";
+      const heading = document.createElement("div");
+      heading.style.cssText = "color:#ff5555;font-weight:bold;";
+      heading.textContent = e.runtime ? "templ: runtime error" : "templ: generation error";
+      const location = document.createElement("div");
+      location.style.cssText = "color:#8be9fd;";
+      location.textContent = e.fileName + (e.line ? ":" + e.line + (e.col ? ":" + e.col : "") : "");
+      const message = document.createElement("pre");
+      message.style.cssText = "white-space:pre-wrap;margin:1rem 0;";
+      message.textContent = e.message;
+      section.append(heading, location, message);
+      if (e.codeFrame) {
+        const frame = document.createElement("pre");
+        frame.style.cssText = "margin:0;padding:1rem;overflow:auto;tab-size:4;background:#111;";
+        frame.textContent = e.codeFrame;
+        section.appendChild(frame);
+      }
+      overlay.appendChild(section);
+    }
+    document.body.appendChild(overlay);
+  }
 })();
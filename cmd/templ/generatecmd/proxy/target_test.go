@@ -0,0 +1,179 @@
+package proxy
+
+import (
+	"crypto/tls"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+func newTestHandler(t *testing.T, target string, opts ...func(*Handler)) *Handler {
+	t.Helper()
+	u, err := url.Parse(target)
+	if err != nil {
+		t.Fatalf("failed to parse URL: %v", err)
+	}
+	return New(slog.New(slog.NewJSONHandler(io.Discard, nil)), "127.0.0.1", 7474, u, opts...)
+}
+
+func TestHTTPSTarget(t *testing.T) {
+	target := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		io.WriteString(w, "<html><body>secure</body></html>")
+	}))
+	defer target.Close()
+
+	t.Run("self-signed certificates are rejected by default", func(t *testing.T) {
+		h := newTestHandler(t, target.URL)
+		h.p.Transport.(*roundTripper).maxRetries = 0
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		if w.Code != http.StatusBadGateway {
+			t.Errorf("expected status %d, got %d", http.StatusBadGateway, w.Code)
+		}
+	})
+	t.Run("certificate verification can be skipped", func(t *testing.T) {
+		h := newTestHandler(t, target.URL, WithTargetTLSConfig(&tls.Config{InsecureSkipVerify: true}))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		if !strings.Contains(w.Body.String(), "secure") || !strings.Contains(w.Body.String(), getScriptTag(DefaultPrefix, "")) {
+			t.Errorf("unexpected body: %s", w.Body.String())
+		}
+	})
+	t.Run("the certificate authority can be provided", func(t *testing.T) {
+		h := newTestHandler(t, target.URL, WithTargetTLSConfig(&tls.Config{RootCAs: target.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs}))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		if w.Code != http.StatusOK {
+			t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+	})
+}
+
+func TestPrefix(t *testing.T) {
+	var proxiedPaths []string
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxiedPaths = append(proxiedPaths, r.URL.Path)
+		w.Header().Set("Content-Type", "text/html")
+		io.WriteString(w, "<html><body></body></html>")
+	}))
+	defer target.Close()
+	h := newTestHandler(t, target.URL, WithPrefix("/__dev/"))
+
+	t.Run("the script is served from the prefix", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/__dev/reload/script.js", nil))
+		if w.Header().Get("Content-Type") != "text/javascript" {
+			t.Errorf("expected the script to be served, got content type %q", w.Header().Get("Content-Type"))
+		}
+	})
+	t.Run("the script tag uses the prefix", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		if !strings.Contains(w.Body.String(), `<script src="/__dev/reload/script.js"></script>`) {
+			t.Errorf("expected the prefixed script tag, got %s", w.Body.String())
+		}
+	})
+	t.Run("the default prefix is passed to the app", func(t *testing.T) {
+		proxiedPaths = nil
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/_templ/status", nil))
+		if len(proxiedPaths) != 1 || proxiedPaths[0] != "/_templ/status" {
+			t.Errorf("expected the request to be proxied, got %v", proxiedPaths)
+		}
+	})
+}
+
+func TestSourceMapViewer(t *testing.T) {
+	var proxiedPaths []string
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxiedPaths = append(proxiedPaths, r.URL.Path)
+	}))
+	defer target.Close()
+	var viewerPaths []string
+	viewer := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		viewerPaths = append(viewerPaths, r.URL.Path)
+	})
+
+	t.Run("the viewer is served from the prefix", func(t *testing.T) {
+		h := newTestHandler(t, target.URL, WithSourceMapViewer(viewer))
+		viewerPaths = nil
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/_templ/sourcemaps/", nil))
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/_templ/sourcemaps/events", nil))
+		if len(viewerPaths) != 2 || viewerPaths[0] != "/" || viewerPaths[1] != "/events" {
+			t.Errorf("expected the viewer to receive paths without the prefix, got %v", viewerPaths)
+		}
+		if expected := "http://127.0.0.1:7474/_templ/sourcemaps/"; h.SourceMapViewerURL() != expected {
+			t.Errorf("expected URL %q, got %q", expected, h.SourceMapViewerURL())
+		}
+	})
+	t.Run("the path without a trailing slash is redirected", func(t *testing.T) {
+		h := newTestHandler(t, target.URL, WithSourceMapViewer(viewer))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/_templ/sourcemaps", nil))
+		if w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != "/_templ/sourcemaps/" {
+			t.Errorf("expected a redirect, got %d %q", w.Code, w.Header().Get("Location"))
+		}
+	})
+	t.Run("without a viewer, requests are proxied", func(t *testing.T) {
+		h := newTestHandler(t, target.URL)
+		proxiedPaths = nil
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/_templ/sourcemaps/", nil))
+		if len(proxiedPaths) != 1 {
+			t.Errorf("expected the request to be proxied, got %v", proxiedPaths)
+		}
+	})
+}
+
+func TestWebSocketPassthrough(t *testing.T) {
+	target := httptest.NewServer(websocket.Handler(func(ws *websocket.Conn) {
+		var msg string
+		if err := websocket.Message.Receive(ws, &msg); err != nil {
+			return
+		}
+		_ = websocket.Message.Send(ws, "echo: "+msg)
+	}))
+	defer target.Close()
+	s := httptest.NewServer(newTestHandler(t, target.URL))
+	defer s.Close()
+
+	ws, err := websocket.Dial("ws"+strings.TrimPrefix(s.URL, "http")+"/app/ws", "", s.URL)
+	if err != nil {
+		t.Fatalf("failed to connect through the proxy: %v", err)
+	}
+	defer ws.Close()
+	if err = ws.SetDeadline(time.Now().Add(time.Second * 5)); err != nil {
+		t.Fatalf("failed to set deadline: %v", err)
+	}
+	if err = websocket.Message.Send(ws, "hello"); err != nil {
+		t.Fatalf("failed to send: %v", err)
+	}
+	var reply string
+	if err = websocket.Message.Receive(ws, &reply); err != nil {
+		t.Fatalf("failed to receive: %v", err)
+	}
+	if reply != "echo: hello" {
+		t.Errorf("expected %q, got %q", "echo: hello", reply)
+	}
+}
+
+func TestTLS(t *testing.T) {
+	cert, err := LocalCertificate("")
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	h := newTestHandler(t, "http://example.com", WithTLS(cert))
+	if !strings.HasPrefix(h.URL, "https://") {
+		t.Errorf("expected a HTTPS URL, got %q", h.URL)
+	}
+}
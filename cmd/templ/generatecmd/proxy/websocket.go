@@ -0,0 +1,133 @@
+package proxy
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/a-h/templ/cmd/templ/generatecmd/sse"
+	"golang.org/x/net/websocket"
+)
+
+// browserMessage is sent by the reload script over the WebSocket connection.
+type browserMessage struct {
+	// Type is "location" or "error".
+	Type string `json:"type"`
+	// URL of the page.
+	URL string `json:"url"`
+	// Message, Source, Line, Col and Stack describe JavaScript errors.
+	Message string `json:"message,omitempty"`
+	Source  string `json:"source,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Col     int    `json:"col,omitempty"`
+	Stack   string `json:"stack,omitempty"`
+}
+
+// serveWebSocket sends the same events as the server-sent events endpoint, and
+// receives the URL of the page, and any JavaScript errors, from the browser.
+func (p *Handler) serveWebSocket(w http.ResponseWriter, r *http.Request) {
+	websocket.Server{
+		// The reload script is served by the proxy, but the page may be viewed
+		// using another host name, so any origin is accepted.
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(ws *websocket.Conn) {
+			defer ws.Close()
+			sub := p.sse.Subscribe(sse.Client{
+				Transport:  "websocket",
+				RemoteAddr: r.RemoteAddr,
+				UserAgent:  r.UserAgent(),
+				URL:        r.Referer(),
+			}, sse.LastEventIDFromRequest(r))
+			defer sub.Close()
+			log := p.log.With(slog.Int64("client", sub.ID))
+
+			// Read messages from the browser.
+			closed := make(chan struct{})
+			go func() {
+				defer close(closed)
+				for {
+					var msg browserMessage
+					if err := websocket.JSON.Receive(ws, &msg); err != nil {
+						return
+					}
+					p.handleBrowserMessage(log, sub.ID, msg)
+				}
+			}()
+
+			send := func(e sse.Event) error {
+				b, err := json.Marshal(e)
+				if err != nil {
+					return err
+				}
+				return websocket.Message.Send(ws, string(b))
+			}
+			for _, e := range sub.Replay {
+				if err := send(e); err != nil {
+					return
+				}
+			}
+			heartbeat := time.NewTicker(p.sse.Heartbeat)
+			defer heartbeat.Stop()
+			for {
+				select {
+				case <-heartbeat.C:
+					if err := send(sse.Event{Type: "heartbeat"}); err != nil {
+						return
+					}
+				case e, ok := <-sub.Events:
+					if !ok {
+						return
+					}
+					if err := send(e); err != nil {
+						return
+					}
+				case <-closed:
+					return
+				case <-r.Context().Done():
+					return
+				}
+			}
+		},
+	}.ServeHTTP(w, r)
+}
+
+func (p *Handler) handleBrowserMessage(log *slog.Logger, clientID int64, msg browserMessage) {
+	switch msg.Type {
+	case "location":
+		p.sse.SetClientURL(clientID, msg.URL)
+		log.Info("Browser connected", slog.String("url", msg.URL))
+	case "error":
+		attrs := []any{slog.String("url", msg.URL)}
+		if msg.Source != "" {
+			attrs = append(attrs, slog.String("source", msg.Source), slog.Int("line", msg.Line), slog.Int("col", msg.Col))
+		}
+		if msg.Stack != "" {
+			attrs = append(attrs, slog.String("stack", msg.Stack))
+		}
+		log.Warn("Browser error: "+msg.Message, attrs...)
+	default:
+		log.Debug("Unknown browser message", slog.String("type", msg.Type))
+	}
+}
+
+// status of the proxy, served at /_templ/status.
+type status struct {
+	Target      string       `json:"target"`
+	LastEventID string       `json:"lastEventId,omitempty"`
+	Clients     []sse.Client `json:"clients"`
+}
+
+func (p *Handler) serveStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	err := enc.Encode(status{
+		Target:      p.Target.String(),
+		LastEventID: p.sse.LastEventID(),
+		Clients:     p.sse.Clients(),
+	})
+	if err != nil {
+		p.log.Debug("Failed to write status", slog.Any("error", err))
+	}
+}
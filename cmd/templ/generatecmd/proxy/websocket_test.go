@@ -0,0 +1,113 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/a-h/templ/cmd/templ/generatecmd/sse"
+	"golang.org/x/net/websocket"
+)
+
+func TestWebSocket(t *testing.T) {
+	lh := newTestLogHandler(slog.LevelInfo)
+	h := New(slog.New(lh), "127.0.0.1", 7474, &url.URL{Scheme: "http", Host: "example.com"})
+	s := httptest.NewServer(h)
+	defer s.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(s.URL, "http") + "/_templ/reload/ws"
+	ws, err := websocket.Dial(wsURL, "", s.URL)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer ws.Close()
+
+	// Report the URL, and a JavaScript error.
+	if err = websocket.JSON.Send(ws, browserMessage{Type: "location", URL: "http://localhost:7331/page"}); err != nil {
+		t.Fatalf("failed to send location: %v", err)
+	}
+	if err = websocket.JSON.Send(ws, browserMessage{Type: "error", URL: "http://localhost:7331/page", Message: "x is not defined"}); err != nil {
+		t.Fatalf("failed to send error: %v", err)
+	}
+
+	t.Run("events are sent to the browser", func(t *testing.T) {
+		h.SendSSE("message", "reload")
+		if err := ws.SetReadDeadline(time.Now().Add(time.Second * 5)); err != nil {
+			t.Fatalf("failed to set deadline: %v", err)
+		}
+		var e sse.Event
+		if err := websocket.JSON.Receive(ws, &e); err != nil {
+			t.Fatalf("failed to receive event: %v", err)
+		}
+		if e.Type != "message" || e.Data != "reload" || e.ID == "" {
+			t.Errorf("unexpected event: %#v", e)
+		}
+	})
+	t.Run("the status endpoint lists connected clients", func(t *testing.T) {
+		var st status
+		for i := 0; i < 50; i++ {
+			resp, err := http.Get(s.URL + "/_templ/status")
+			if err != nil {
+				t.Fatalf("failed to get status: %v", err)
+			}
+			err = json.NewDecoder(resp.Body).Decode(&st)
+			resp.Body.Close()
+			if err != nil {
+				t.Fatalf("failed to decode status: %v", err)
+			}
+			if len(st.Clients) == 1 && st.Clients[0].URL == "http://localhost:7331/page" {
+				break
+			}
+			time.Sleep(time.Millisecond * 20)
+		}
+		if len(st.Clients) != 1 {
+			t.Fatalf("expected 1 client, got %#v", st.Clients)
+		}
+		if st.Clients[0].Transport != "websocket" || st.Clients[0].URL != "http://localhost:7331/page" {
+			t.Errorf("unexpected client: %#v", st.Clients[0])
+		}
+	})
+	t.Run("browser errors are logged", func(t *testing.T) {
+		var found bool
+		for i := 0; i < 50 && !found; i++ {
+			lh.m.Lock()
+			for _, r := range lh.records {
+				if r.Message == "Browser error: x is not defined" && r.Level == slog.LevelWarn {
+					found = true
+				}
+			}
+			lh.m.Unlock()
+			time.Sleep(time.Millisecond * 20)
+		}
+		if !found {
+			t.Error("expected the browser error to be logged")
+		}
+	})
+}
+
+func TestStatusIsNotProxied(t *testing.T) {
+	var proxied bool
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = true
+	}))
+	defer target.Close()
+	u, err := url.Parse(target.URL)
+	if err != nil {
+		t.Fatalf("failed to parse URL: %v", err)
+	}
+	h := New(slog.New(slog.NewJSONHandler(io.Discard, nil)), "127.0.0.1", 7474, u)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/_templ/status", nil))
+	if proxied {
+		t.Error("expected the status endpoint to be served by the proxy")
+	}
+	if !strings.Contains(w.Body.String(), `"clients": []`) {
+		t.Errorf("expected an empty list of clients, got %s", w.Body.String())
+	}
+}
@@ -8,7 +8,6 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
-	"strings"
 	"sync"
 	"syscall"
 	"time"
@@ -63,22 +62,39 @@ func Run(ctx context.Context, workingDir, input string) (cmd *exec.Cmd, err erro
 
 		delete(running, input)
 	}
-	parts := strings.Fields(input)
-	executable := parts[0]
-	args := []string{}
-	if len(parts) > 1 {
-		args = append(args, parts[1:]...)
+	parts, err := Split(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse command %q: %w", input, err)
 	}
 
-	cmd = exec.CommandContext(ctx, executable, args...)
+	cmd = exec.CommandContext(ctx, parts[0], parts[1:]...)
 	// Wait for the process to finish gracefully before termination.
 	cmd.WaitDelay = time.Second * 3
 	cmd.Env = os.Environ()
 	cmd.Dir = workingDir
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
-	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	configure(cmd)
 	running[input] = cmd
 	err = cmd.Start()
 	return
 }
+
+// configure the command to run in its own process group, so that child
+// processes can be stopped along with it.
+func configure(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// interrupt asks the process to stop.
+func interrupt(p *os.Process) error {
+	return errors.Join(
+		ignoreExited(p.Signal(syscall.SIGINT)),
+		ignoreExited(p.Signal(syscall.SIGTERM)),
+	)
+}
+
+// killGroup stops the process, and any child processes in its process group.
+func killGroup(p *os.Process) error {
+	return ignoreExited(syscall.Kill(-p.Pid, syscall.SIGKILL))
+}
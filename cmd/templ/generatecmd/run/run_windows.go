@@ -7,7 +7,6 @@ import (
 	"os"
 	"os/exec"
 	"strconv"
-	"strings"
 	"sync"
 )
 
@@ -51,14 +50,12 @@ func Run(ctx context.Context, workingDir, input string) (cmd *exec.Cmd, err erro
 		}
 		delete(running, input)
 	}
-	parts := strings.Fields(input)
-	executable := parts[0]
-	args := []string{}
-	if len(parts) > 1 {
-		args = append(args, parts[1:]...)
+	parts, err := Split(input)
+	if err != nil {
+		return nil, err
 	}
 
-	cmd = exec.Command(executable, args...)
+	cmd = exec.Command(parts[0], parts[1:]...)
 	cmd.Env = os.Environ()
 	cmd.Dir = workingDir
 	cmd.Stdout = os.Stdout
@@ -67,3 +64,19 @@ func Run(ctx context.Context, workingDir, input string) (cmd *exec.Cmd, err erro
 	err = cmd.Start()
 	return
 }
+
+func configure(cmd *exec.Cmd) {}
+
+// interrupt stops the process, and any child processes.
+// Windows processes can't be asked to stop gracefully.
+func interrupt(p *os.Process) error {
+	return killGroup(p)
+}
+
+// killGroup stops the process, and any child processes.
+func killGroup(p *os.Process) error {
+	kill := exec.Command("TASKKILL", "/T", "/F", "/PID", strconv.Itoa(p.Pid))
+	kill.Stderr = os.Stderr
+	kill.Stdout = os.Stdout
+	return kill.Run()
+}
@@ -0,0 +1,71 @@
+package run
+
+import (
+	"errors"
+	"strings"
+)
+
+// Split splits a command line into arguments, using shell-style quoting.
+//
+// Arguments are separated by whitespace. Within single quotes, all characters
+// are literal. Within double quotes, a backslash escapes a following double
+// quote or backslash. Outside of quotes, a backslash escapes any character.
+func Split(input string) (args []string, err error) {
+	var arg strings.Builder
+	var inArg, escaped bool
+	var quote rune
+	for _, r := range input {
+		switch {
+		case escaped:
+			// Within double quotes, only quotes and backslashes can be escaped.
+			if quote == '"' && r != '"' && r != '\\' {
+				arg.WriteRune('\\')
+			}
+			arg.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+				continue
+			}
+			arg.WriteRune(r)
+		case quote == '"':
+			switch r {
+			case '"':
+				quote = 0
+			case '\\':
+				escaped = true
+			default:
+				arg.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == '\\':
+			escaped = true
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, errors.New("unterminated quote in command")
+	}
+	if escaped {
+		return nil, errors.New("command ends with an escape character")
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	if len(args) == 0 {
+		return nil, errors.New("command is empty")
+	}
+	return args, nil
+}
@@ -0,0 +1,45 @@
+package run
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		input         string
+		expected      []string
+		expectedError bool
+	}{
+		{input: "go run .", expected: []string{"go", "run", "."}},
+		{input: "  go   run\t.  ", expected: []string{"go", "run", "."}},
+		{input: `echo "hello world"`, expected: []string{"echo", "hello world"}},
+		{input: `echo 'hello "world"'`, expected: []string{"echo", `hello "world"`}},
+		{input: `echo "say \"hi\""`, expected: []string{"echo", `say "hi"`}},
+		{input: `echo "C:\temp"`, expected: []string{"echo", `C:\temp`}},
+		{input: `echo hello\ world`, expected: []string{"echo", "hello world"}},
+		{input: `echo ""`, expected: []string{"echo", ""}},
+		{input: `go run -ldflags="-X main.version=1" .`, expected: []string{"go", "run", "-ldflags=-X main.version=1", "."}},
+		{input: `echo "unterminated`, expectedError: true},
+		{input: `echo \`, expectedError: true},
+		{input: "   ", expectedError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			actual, err := Split(tt.input)
+			if tt.expectedError {
+				if err == nil {
+					t.Fatalf("expected error, got %q", actual)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.expected, actual); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,384 @@
+package run
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+)
+
+// Process is a command that's managed by a Supervisor.
+type Process struct {
+	// Name is used to prefix the output of the process. If empty, the name
+	// of the executable is used.
+	Name string
+	// Build is an optional command that's run to completion before the Run
+	// command is started, e.g. "go build -o ./tmp/app .".
+	Build string
+	// Run is the command to run, e.g. "./tmp/app".
+	Run string
+	// Ready is an optional readiness check. It can be a TCP address, e.g.
+	// "tcp://localhost:8080", or a HTTP URL, e.g. "http://localhost:8080/health".
+	// The process is ready when the TCP port accepts connections, or the HTTP
+	// URL returns a non 5xx status code.
+	Ready string
+}
+
+func (p Process) name() string {
+	if p.Name != "" {
+		return p.Name
+	}
+	command := p.Run
+	if command == "" {
+		command = p.Build
+	}
+	if args, err := Split(command); err == nil {
+		return filepath.Base(args[0])
+	}
+	return "cmd"
+}
+
+// Supervisor runs processes, and restarts them if they crash.
+type Supervisor struct {
+	Log *slog.Logger
+	// Dir is the working directory of the processes.
+	Dir string
+	// Stdout and Stderr receive the output of the processes, with each line
+	// prefixed by the process name.
+	Stdout io.Writer
+	Stderr io.Writer
+	// ReadyTimeout is how long to wait for a process to become ready.
+	ReadyTimeout time.Duration
+
+	processes []*supervisedProcess
+}
+
+// NewSupervisor creates a Supervisor for the processes. Call Restart to start them.
+func NewSupervisor(log *slog.Logger, dir string, processes ...Process) *Supervisor {
+	s := &Supervisor{
+		Log:          log,
+		Dir:          dir,
+		Stdout:       os.Stdout,
+		Stderr:       os.Stderr,
+		ReadyTimeout: time.Second * 30,
+	}
+	for _, p := range processes {
+		s.processes = append(s.processes, &supervisedProcess{
+			s:       s,
+			Process: p,
+			name:    p.name(),
+		})
+	}
+	return s
+}
+
+// Restart stops the processes, runs their build commands, and starts them
+// again. Restart returns when all of the processes are ready, or failed to
+// start.
+func (s *Supervisor) Restart(ctx context.Context) (err error) {
+	var wg sync.WaitGroup
+	errs := make([]error, len(s.processes))
+	for i, p := range s.processes {
+		wg.Add(1)
+		go func(i int, p *supervisedProcess) {
+			defer wg.Done()
+			errs[i] = p.restart(ctx)
+		}(i, p)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// Stop the processes.
+func (s *Supervisor) Stop() (err error) {
+	errs := make([]error, len(s.processes))
+	for i, p := range s.processes {
+		errs[i] = p.stop()
+	}
+	return errors.Join(errs...)
+}
+
+type supervisedProcess struct {
+	Process
+	s    *Supervisor
+	name string
+
+	m sync.Mutex
+	// current is the running instance of the process, or nil.
+	current *instance
+}
+
+// instance of a running process.
+type instance struct {
+	cmd *exec.Cmd
+	// stopping is closed when the instance is being stopped, so that the exit
+	// isn't treated as a crash.
+	stopping chan struct{}
+	// exited is closed when the process exits.
+	exited chan struct{}
+}
+
+func (p *supervisedProcess) restart(ctx context.Context) (err error) {
+	if err = p.stop(); err != nil {
+		p.s.Log.Warn("Failed to stop process", slog.String("process", p.name), slog.Any("error", err))
+	}
+	if p.Build != "" {
+		p.s.Log.Debug("Building", slog.String("process", p.name), slog.String("command", p.Build))
+		if err = p.build(ctx); err != nil {
+			return fmt.Errorf("%s: build failed: %w", p.name, err)
+		}
+	}
+	if p.Run == "" {
+		return nil
+	}
+	if err = p.start(ctx, backoff.NewExponentialBackOff(backoff.WithMaxElapsedTime(0)), nil); err != nil {
+		return fmt.Errorf("%s: failed to start: %w", p.name, err)
+	}
+	if p.Ready == "" {
+		return nil
+	}
+	if err = p.waitReady(ctx); err != nil {
+		return fmt.Errorf("%s: not ready: %w", p.name, err)
+	}
+	return nil
+}
+
+func (p *supervisedProcess) build(ctx context.Context) (err error) {
+	args, err := Split(p.Build)
+	if err != nil {
+		return err
+	}
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = p.s.Dir
+	cmd.Env = os.Environ()
+	stdout, stderr := newPrefixWriter(p.s.Stdout, p.name), newPrefixWriter(p.s.Stderr, p.name)
+	defer stdout.Flush()
+	defer stderr.Flush()
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	return cmd.Run()
+}
+
+// start the process, and restart it with backoff if it crashes.
+// The process is only started if prev is the current instance, so that a
+// process that has been stopped or restarted isn't restarted after a crash.
+func (p *supervisedProcess) start(ctx context.Context, b backoff.BackOff, prev *instance) (err error) {
+	args, err := Split(p.Run)
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Dir = p.s.Dir
+	cmd.Env = os.Environ()
+	stdout, stderr := newPrefixWriter(p.s.Stdout, p.name), newPrefixWriter(p.s.Stderr, p.name)
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	configure(cmd)
+
+	p.m.Lock()
+	defer p.m.Unlock()
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if p.current != prev {
+		return nil
+	}
+	p.s.Log.Debug("Starting process", slog.String("process", p.name), slog.String("command", p.Run))
+	if err = cmd.Start(); err != nil {
+		return err
+	}
+	inst := &instance{
+		cmd:      cmd,
+		stopping: make(chan struct{}),
+		exited:   make(chan struct{}),
+	}
+	p.current = inst
+	started := time.Now()
+
+	go func() {
+		waitErr := cmd.Wait()
+		stdout.Flush()
+		stderr.Flush()
+		close(inst.exited)
+		select {
+		case <-inst.stopping:
+			return
+		case <-ctx.Done():
+			return
+		default:
+		}
+		if waitErr == nil {
+			p.s.Log.Info("Process exited", slog.String("process", p.name))
+			return
+		}
+		// A process that ran for a while before crashing is restarted quickly.
+		if time.Since(started) > time.Second*10 {
+			b.Reset()
+		}
+		delay := b.NextBackOff()
+		p.s.Log.Error("Process crashed, restarting",
+			slog.String("process", p.name),
+			slog.Any("error", waitErr),
+			slog.Duration("backoff", delay),
+		)
+		select {
+		case <-inst.stopping:
+			return
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		if err := p.start(ctx, b, inst); err != nil {
+			p.s.Log.Error("Failed to restart process", slog.String("process", p.name), slog.Any("error", err))
+		}
+	}()
+	return nil
+}
+
+func (p *supervisedProcess) stop() (err error) {
+	p.m.Lock()
+	defer p.m.Unlock()
+	inst := p.current
+	if inst == nil {
+		return nil
+	}
+	p.current = nil
+	close(inst.stopping)
+	select {
+	case <-inst.exited:
+		// The process has already exited, but its child processes may still be running.
+		_ = killGroup(inst.cmd.Process)
+		return nil
+	default:
+	}
+	p.s.Log.Debug("Stopping process", slog.String("process", p.name))
+	err = interrupt(inst.cmd.Process)
+	select {
+	case <-inst.exited:
+	case <-time.After(time.Second * 3):
+	}
+	// Stop any child processes, even if the parent has exited.
+	return errors.Join(err, killGroup(inst.cmd.Process))
+}
+
+func (p *supervisedProcess) waitReady(ctx context.Context) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, p.s.ReadyTimeout)
+	defer cancel()
+	u, err := url.Parse(p.Ready)
+	if err != nil {
+		return fmt.Errorf("invalid readiness check %q: %w", p.Ready, err)
+	}
+	check := func() error {
+		return checkHTTP(ctx, p.Ready)
+	}
+	switch u.Scheme {
+	case "tcp":
+		check = func() error {
+			return checkTCP(ctx, u.Host)
+		}
+	case "http", "https":
+	default:
+		return fmt.Errorf("invalid readiness check %q: expected a tcp:// address or http(s):// URL", p.Ready)
+	}
+	b := backoff.NewExponentialBackOff(
+		backoff.WithInitialInterval(time.Millisecond*50),
+		backoff.WithMaxInterval(time.Second),
+		backoff.WithMaxElapsedTime(0),
+	)
+	for {
+		if err = check(); err == nil {
+			p.s.Log.Debug("Process ready", slog.String("process", p.name))
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %w", ctx.Err(), err)
+		case <-time.After(b.NextBackOff()):
+		}
+	}
+}
+
+func checkTCP(ctx context.Context, addr string) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+func checkHTTP(ctx context.Context, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// prefixWriter prefixes each line written to it.
+type prefixWriter struct {
+	m      sync.Mutex
+	w      io.Writer
+	prefix []byte
+	buf    []byte
+}
+
+func newPrefixWriter(w io.Writer, name string) *prefixWriter {
+	return &prefixWriter{
+		w:      w,
+		prefix: []byte("[" + name + "] "),
+	}
+}
+
+func (pw *prefixWriter) Write(p []byte) (n int, err error) {
+	pw.m.Lock()
+	defer pw.m.Unlock()
+	pw.buf = append(pw.buf, p...)
+	for {
+		i := bytes.IndexByte(pw.buf, '\n')
+		if i < 0 {
+			break
+		}
+		if err = pw.writeLine(pw.buf[:i+1]); err != nil {
+			return 0, err
+		}
+		pw.buf = pw.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush writes any incomplete line.
+func (pw *prefixWriter) Flush() {
+	pw.m.Lock()
+	defer pw.m.Unlock()
+	if len(pw.buf) == 0 {
+		return
+	}
+	_ = pw.writeLine(append(pw.buf, '\n'))
+	pw.buf = nil
+}
+
+func (pw *prefixWriter) writeLine(line []byte) (err error) {
+	// Write the line with a single call, so that lines from different
+	// processes aren't interleaved.
+	_, err = pw.w.Write(append(append([]byte{}, pw.prefix...), line...))
+	return err
+}
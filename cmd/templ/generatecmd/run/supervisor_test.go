@@ -0,0 +1,178 @@
+//go:build unix
+
+package run
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a bytes.Buffer that's safe for concurrent use.
+type syncBuffer struct {
+	m sync.Mutex
+	b bytes.Buffer
+}
+
+func (sb *syncBuffer) Write(p []byte) (n int, err error) {
+	sb.m.Lock()
+	defer sb.m.Unlock()
+	return sb.b.Write(p)
+}
+
+func (sb *syncBuffer) String() string {
+	sb.m.Lock()
+	defer sb.m.Unlock()
+	return sb.b.String()
+}
+
+func newTestSupervisor(dir string, processes ...Process) (s *Supervisor, stdout *syncBuffer) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	s = NewSupervisor(log, dir, processes...)
+	stdout = &syncBuffer{}
+	s.Stdout = stdout
+	s.Stderr = stdout
+	return s, stdout
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second * 5)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for condition")
+		}
+		time.Sleep(time.Millisecond * 20)
+	}
+}
+
+func TestSupervisor(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping test in short mode.")
+	}
+	t.Run("output is prefixed with the process name", func(t *testing.T) {
+		s, stdout := newTestSupervisor(t.TempDir(),
+			Process{Name: "web", Run: `sh -c 'echo "hello world"; sleep 10'`},
+			Process{Run: `sh -c 'echo ok; sleep 10'`},
+		)
+		defer s.Stop()
+		if err := s.Restart(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		waitFor(t, func() bool {
+			out := stdout.String()
+			return strings.Contains(out, "[web] hello world\n") && strings.Contains(out, "[sh] ok\n")
+		})
+	})
+	t.Run("the build command is run before the run command", func(t *testing.T) {
+		dir := t.TempDir()
+		s, stdout := newTestSupervisor(dir, Process{
+			Name:  "app",
+			Build: `sh -c 'echo built > output.txt'`,
+			Run:   `sh -c 'cat output.txt; sleep 10'`,
+		})
+		defer s.Stop()
+		if err := s.Restart(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		waitFor(t, func() bool {
+			return strings.Contains(stdout.String(), "[app] built\n")
+		})
+	})
+	t.Run("a failed build returns an error", func(t *testing.T) {
+		s, _ := newTestSupervisor(t.TempDir(), Process{
+			Name:  "app",
+			Build: `sh -c 'exit 1'`,
+			Run:   "sleep 10",
+		})
+		defer s.Stop()
+		if err := s.Restart(context.Background()); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+	t.Run("crashed processes are restarted", func(t *testing.T) {
+		s, stdout := newTestSupervisor(t.TempDir(), Process{
+			Name: "crash",
+			Run:  `sh -c 'echo started; exit 1'`,
+		})
+		defer s.Stop()
+		if err := s.Restart(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		waitFor(t, func() bool {
+			return strings.Count(stdout.String(), "[crash] started\n") >= 2
+		})
+	})
+	t.Run("stopped processes are not restarted", func(t *testing.T) {
+		s, stdout := newTestSupervisor(t.TempDir(), Process{
+			Name: "app",
+			Run:  `sh -c 'echo started; sleep 10'`,
+		})
+		if err := s.Restart(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		waitFor(t, func() bool {
+			return strings.Contains(stdout.String(), "[app] started\n")
+		})
+		if err := s.Stop(); err != nil {
+			t.Fatalf("failed to stop: %v", err)
+		}
+		time.Sleep(time.Second)
+		if count := strings.Count(stdout.String(), "[app] started\n"); count != 1 {
+			t.Errorf("expected the process to be started once, got %d", count)
+		}
+	})
+	t.Run("restart waits for the HTTP readiness check", func(t *testing.T) {
+		var ready bool
+		var m sync.Mutex
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			m.Lock()
+			defer m.Unlock()
+			if !ready {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+		}))
+		defer server.Close()
+		go func() {
+			time.Sleep(time.Millisecond * 200)
+			m.Lock()
+			defer m.Unlock()
+			ready = true
+		}()
+		s, _ := newTestSupervisor(t.TempDir(), Process{
+			Run:   "sleep 10",
+			Ready: server.URL + "/health",
+		})
+		defer s.Stop()
+		if err := s.Restart(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		m.Lock()
+		defer m.Unlock()
+		if !ready {
+			t.Error("expected Restart to wait until the process was ready")
+		}
+	})
+	t.Run("restart returns an error if the process doesn't become ready", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		addr := server.Listener.Addr().String()
+		server.Close()
+		s, _ := newTestSupervisor(t.TempDir(), Process{
+			Run:   "sleep 10",
+			Ready: "tcp://" + addr,
+		})
+		s.ReadyTimeout = time.Millisecond * 300
+		defer s.Stop()
+		if err := s.Restart(context.Background()); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
@@ -0,0 +1,164 @@
+package generatecmd
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/scanner"
+	"go/token"
+	"log/slog"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/a-h/templ/generator"
+	"github.com/a-h/templ/parser/v2"
+)
+
+// SourceMapper maps positions in generated _templ.go files to positions in the
+// templates they were generated from, e.g. to show compiler errors in templates.
+type SourceMapper struct {
+	h     *FSEventHandler
+	m     sync.Mutex
+	files map[string]*generatedFile
+}
+
+// generatedFile is the generated code of a template.
+type generatedFile struct {
+	sourceMap *parser.SourceMap
+	// The source map refers to the code before it's formatted, but compilers
+	// report positions in the formatted code that's written to disk. Formatting
+	// only changes whitespace, so positions are mapped between the two using
+	// the positions of the tokens.
+	formatted, unformatted []token.Position
+}
+
+// unformattedPosition returns the position in the unformatted code that
+// corresponds to the 1-based line and column in the formatted code.
+func (f *generatedFile) unformattedPosition(line, col int) (unformattedLine, unformattedCol int, ok bool) {
+	if len(f.formatted) != len(f.unformatted) {
+		return 0, 0, false
+	}
+	// Find the last token that starts at or before the position.
+	i := sort.Search(len(f.formatted), func(i int) bool {
+		p := f.formatted[i]
+		return p.Line > line || (p.Line == line && p.Column > col)
+	}) - 1
+	if i < 0 || f.formatted[i].Line != line {
+		return 0, 0, false
+	}
+	u := f.unformatted[i]
+	return u.Line, u.Column + (col - f.formatted[i].Column), true
+}
+
+// tokenPositions returns the positions of the tokens in Go code. Semicolons
+// are skipped, because formatting can add or remove them.
+func tokenPositions(src []byte) (positions []token.Position) {
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+	var s scanner.Scanner
+	s.Init(file, src, nil, scanner.ScanComments)
+	for {
+		pos, tok, _ := s.Scan()
+		if tok == token.EOF {
+			return positions
+		}
+		if tok == token.SEMICOLON {
+			continue
+		}
+		positions = append(positions, fset.Position(pos))
+	}
+}
+
+// NewSourceMapper creates a SourceMapper for code generated with the arguments.
+func NewSourceMapper(log *slog.Logger, args Arguments) *SourceMapper {
+	if !filepath.IsAbs(args.Path) {
+		args.Path, _ = filepath.Abs(args.Path)
+	}
+	opts, _, dirOpts := args.generatorConfig(time.Now())
+	h := NewFSEventHandler(log, args.Path, false, opts, false, true, nil, false)
+	h.SetDirectoryGenerateOpts(dirOpts)
+	return &SourceMapper{
+		h:     h,
+		files: make(map[string]*generatedFile),
+	}
+}
+
+// RemapErrorList converts the 1-based positions of errors in generated
+// _templ.go files to positions in templates. Other errors, and errors that
+// can't be mapped, are unchanged.
+func (m *SourceMapper) RemapErrorList(list scanner.ErrorList) (remapped scanner.ErrorList) {
+	remapped = make(scanner.ErrorList, 0, len(list))
+	for _, e := range list {
+		if !strings.HasSuffix(e.Pos.Filename, "_templ.go") {
+			remapped = append(remapped, e)
+			continue
+		}
+		templFileName := strings.TrimSuffix(e.Pos.Filename, "_templ.go") + ".templ"
+		f, err := m.generatedFile(templFileName)
+		if err != nil {
+			m.h.Log.Debug("Failed to generate source map", slog.String("file", templFileName), slog.Any("error", err))
+			remapped = append(remapped, e)
+			continue
+		}
+		line, col, ok := f.unformattedPosition(e.Pos.Line, e.Pos.Column)
+		if !ok {
+			remapped = append(remapped, e)
+			continue
+		}
+		// remapErrorList expects 1-based lines, and 0-based columns.
+		copied := *e
+		copied.Pos.Line, copied.Pos.Column = line, col-1
+		l, ok := remapErrorList(scanner.ErrorList{&copied}, f.sourceMap, templFileName).(scanner.ErrorList)
+		if !ok || l[0].Pos.Filename != templFileName {
+			remapped = append(remapped, e)
+			continue
+		}
+		l[0].Pos.Column++
+		remapped = append(remapped, l[0])
+	}
+	return remapped
+}
+
+func (m *SourceMapper) generatedFile(templFileName string) (f *generatedFile, err error) {
+	m.m.Lock()
+	defer m.m.Unlock()
+	if f, ok := m.files[templFileName]; ok {
+		return f, nil
+	}
+	f, err = m.h.generatedFile(templFileName)
+	if err != nil {
+		return nil, err
+	}
+	m.files[templFileName] = f
+	return f, nil
+}
+
+// generatedFile generates the code for the template, without writing it.
+func (h *FSEventHandler) generatedFile(fileName string) (*generatedFile, error) {
+	t, err := parser.Parse(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("%s parsing error: %w", fileName, err)
+	}
+	absFilePath, relFilePath, err := h.relativeFileName(fileName)
+	if err != nil {
+		return nil, err
+	}
+	opts, _ := h.generateOpts(absFilePath)
+	var b bytes.Buffer
+	sourceMap, _, err := generator.Generate(t, &b, append(opts, generator.WithFileName(relFilePath))...)
+	if err != nil {
+		return nil, fmt.Errorf("%s generation error: %w", fileName, err)
+	}
+	formatted, err := format.Source(b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("%s source formatting error: %w", fileName, err)
+	}
+	return &generatedFile{
+		sourceMap:   sourceMap,
+		formatted:   tokenPositions(formatted),
+		unformatted: tokenPositions(b.Bytes()),
+	}, nil
+}
@@ -4,80 +4,270 @@ import (
 	_ "embed"
 	"fmt"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
-	"sync/atomic"
 	"time"
 )
 
+const (
+	// DefaultReplaySize is the number of events kept for clients that reconnect.
+	DefaultReplaySize = 64
+	// DefaultHeartbeat is the interval between heartbeat comments, which stop
+	// idle connections from being closed by intermediaries.
+	DefaultHeartbeat = time.Second * 15
+	// clientBufferSize is the number of events that can be queued for a client.
+	// Slow clients are disconnected, and catch up by reconnecting with the ID of
+	// the last event they received.
+	clientBufferSize = 32
+)
+
 func New() *Handler {
 	return &Handler{
-		m:        new(sync.Mutex),
-		requests: map[int64]chan event{},
+		m:          new(sync.Mutex),
+		epoch:      strconv.FormatInt(time.Now().UnixNano(), 36),
+		clients:    map[int64]*subscriber{},
+		ReplaySize: DefaultReplaySize,
+		Heartbeat:  DefaultHeartbeat,
 	}
 }
 
+// Handler sends events to connected clients using server-sent events. Other
+// transports, e.g. WebSockets, can receive the same events using Subscribe.
 type Handler struct {
-	m        *sync.Mutex
-	counter  int64
-	requests map[int64]chan event
+	m *sync.Mutex
+	// epoch identifies this handler, so that event IDs from a previous run of
+	// the proxy aren't used to replay events.
+	epoch        string
+	lastEventID  int64
+	replay       []Event
+	lastClientID int64
+	clients      map[int64]*subscriber
+
+	// ReplaySize is the number of recent events that are replayed to clients
+	// that reconnect with the ID of the last event they received.
+	ReplaySize int
+	// Heartbeat is the interval between heartbeat comments.
+	Heartbeat time.Duration
+}
+
+// Event sent to clients.
+type Event struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	Data string `json:"data"`
+	seq  int64
+}
+
+// Client is a connected browser.
+type Client struct {
+	ID int64 `json:"id"`
+	// Transport is "sse" or "websocket".
+	Transport   string    `json:"transport"`
+	RemoteAddr  string    `json:"remoteAddr"`
+	UserAgent   string    `json:"userAgent"`
+	URL         string    `json:"url,omitempty"`
+	ConnectedAt time.Time `json:"connectedAt"`
+}
+
+type subscriber struct {
+	client Client
+	events chan Event
+	closed bool
+}
+
+// Subscription to events.
+type Subscription struct {
+	ID int64
+	// Events receives events until the subscription is closed, or the client
+	// falls too far behind, in which case the channel is closed.
+	Events <-chan Event
+	// Replay contains the events the client missed while it was disconnected.
+	Replay []Event
+	h      *Handler
 }
 
-type event struct {
-	Type string
-	Data string
+// Close the subscription.
+func (s *Subscription) Close() {
+	s.h.m.Lock()
+	defer s.h.m.Unlock()
+	if sub, ok := s.h.clients[s.ID]; ok {
+		delete(s.h.clients, s.ID)
+		if !sub.closed {
+			close(sub.events)
+		}
+	}
 }
 
 // Send an event to all connected clients.
 func (s *Handler) Send(eventType string, data string) {
 	s.m.Lock()
 	defer s.m.Unlock()
-	for _, f := range s.requests {
-		f := f
-		go func(f chan event) {
-			f <- event{
-				Type: eventType,
-				Data: data,
-			}
-		}(f)
+	s.lastEventID++
+	e := Event{
+		ID:   s.eventID(s.lastEventID),
+		Type: eventType,
+		Data: data,
+		seq:  s.lastEventID,
 	}
+	s.replay = append(s.replay, e)
+	if len(s.replay) > s.ReplaySize {
+		s.replay = s.replay[len(s.replay)-s.ReplaySize:]
+	}
+	for _, sub := range s.clients {
+		if sub.closed {
+			continue
+		}
+		select {
+		case sub.events <- e:
+		default:
+			// Don't block other clients. Closing the channel disconnects the
+			// client, which reconnects and replays the events it missed.
+			sub.closed = true
+			close(sub.events)
+		}
+	}
+}
+
+func (s *Handler) eventID(seq int64) string {
+	return s.epoch + "-" + strconv.FormatInt(seq, 10)
+}
+
+// Subscribe to events. If lastEventID is the ID of an event sent by this
+// handler, the events sent since are replayed. If the events are no longer
+// available, a reload message is replayed instead.
+func (s *Handler) Subscribe(c Client, lastEventID string) *Subscription {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.lastClientID++
+	c.ID = s.lastClientID
+	c.ConnectedAt = time.Now()
+	events := make(chan Event, clientBufferSize)
+	s.clients[c.ID] = &subscriber{client: c, events: events}
+	return &Subscription{
+		ID:     c.ID,
+		Events: events,
+		Replay: s.missed(lastEventID),
+		h:      s,
+	}
+}
+
+func (s *Handler) missed(lastEventID string) (events []Event) {
+	epoch, seqString, ok := strings.Cut(lastEventID, "-")
+	if !ok || epoch != s.epoch {
+		// The client hasn't received any events from this handler.
+		return nil
+	}
+	seq, err := strconv.ParseInt(seqString, 10, 64)
+	if err != nil || seq >= s.lastEventID {
+		return nil
+	}
+	if len(s.replay) == 0 || s.replay[0].seq > seq+1 {
+		// Some of the events are no longer available.
+		return []Event{{ID: s.eventID(s.lastEventID), Type: "message", Data: "reload", seq: s.lastEventID}}
+	}
+	for _, e := range s.replay {
+		if e.seq > seq {
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+// SetClientURL records the URL of the page the client is viewing.
+func (s *Handler) SetClientURL(id int64, url string) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if sub, ok := s.clients[id]; ok {
+		sub.client.URL = url
+	}
+}
+
+// Clients returns the connected clients, ordered by ID.
+func (s *Handler) Clients() (clients []Client) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	clients = make([]Client, 0, len(s.clients))
+	for _, sub := range s.clients {
+		clients = append(clients, sub.client)
+	}
+	sort.Slice(clients, func(i, j int) bool {
+		return clients[i].ID < clients[j].ID
+	})
+	return clients
+}
+
+// LastEventID returns the ID of the last event sent, or an empty string if
+// no events have been sent.
+func (s *Handler) LastEventID() string {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if s.lastEventID == 0 {
+		return ""
+	}
+	return s.eventID(s.lastEventID)
+}
+
+// LastEventIDFromRequest returns the ID of the last event received by the
+// client. Browsers set the Last-Event-ID header when an EventSource reconnects.
+// The lastEventId query string parameter is used by clients that can't set
+// headers, e.g. after a page reload.
+func LastEventIDFromRequest(r *http.Request) string {
+	if id := r.Header.Get("Last-Event-ID"); id != "" {
+		return id
+	}
+	return r.URL.Query().Get("lastEventId")
 }
 
 func (s *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Last-Event-ID")
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
 
-	id := atomic.AddInt64(&s.counter, 1)
-	s.m.Lock()
-	events := make(chan event)
-	s.requests[id] = events
-	s.m.Unlock()
-	defer func() {
-		s.m.Lock()
-		defer s.m.Unlock()
-		delete(s.requests, id)
-		close(events)
-	}()
-
-	timer := time.NewTimer(0)
-loop:
+	sub := s.Subscribe(Client{
+		Transport:  "sse",
+		RemoteAddr: r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
+		URL:        r.Referer(),
+	}, LastEventIDFromRequest(r))
+	defer sub.Close()
+
+	write := func(e Event) error {
+		_, err := fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", e.ID, e.Type, e.Data)
+		return err
+	}
+
+	// Tell the browser how long to wait before reconnecting.
+	if _, err := fmt.Fprint(w, "retry: 1000\n\n"); err != nil {
+		return
+	}
+	for _, e := range sub.Replay {
+		if err := write(e); err != nil {
+			return
+		}
+	}
+	w.(http.Flusher).Flush()
+
+	heartbeat := time.NewTicker(s.Heartbeat)
+	defer heartbeat.Stop()
 	for {
 		select {
-		case <-timer.C:
-			if _, err := fmt.Fprintf(w, "event: message\ndata: ping\n\n"); err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case e, ok := <-sub.Events:
+			if !ok {
 				return
 			}
-			timer.Reset(time.Second * 5)
-		case e := <-events:
-			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, e.Data); err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+			if err := write(e); err != nil {
 				return
 			}
 		case <-r.Context().Done():
-			break loop
+			return
 		}
 		w.(http.Flusher).Flush()
 	}
@@ -0,0 +1,136 @@
+package sse
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func eventData(events []Event) (data []string) {
+	for _, e := range events {
+		data = append(data, e.Data)
+	}
+	return data
+}
+
+func TestSubscribe(t *testing.T) {
+	t.Run("events sent after the last event ID are replayed", func(t *testing.T) {
+		h := New()
+		h.Send("message", "a")
+		lastEventID := h.LastEventID()
+		h.Send("message", "b")
+		h.Send("message", "c")
+
+		sub := h.Subscribe(Client{}, lastEventID)
+		defer sub.Close()
+		if actual := strings.Join(eventData(sub.Replay), ","); actual != "b,c" {
+			t.Errorf("expected b,c to be replayed, got %q", actual)
+		}
+	})
+	t.Run("nothing is replayed to clients that are up-to-date", func(t *testing.T) {
+		h := New()
+		h.Send("message", "a")
+		sub := h.Subscribe(Client{}, h.LastEventID())
+		defer sub.Close()
+		if len(sub.Replay) != 0 {
+			t.Errorf("expected no events to be replayed, got %v", sub.Replay)
+		}
+	})
+	t.Run("event IDs from another handler are ignored", func(t *testing.T) {
+		previous := New()
+		previous.Send("message", "a")
+		h := New()
+		h.epoch = previous.epoch + "x"
+		h.Send("message", "b")
+		sub := h.Subscribe(Client{}, previous.LastEventID())
+		defer sub.Close()
+		if len(sub.Replay) != 0 {
+			t.Errorf("expected no events to be replayed, got %v", sub.Replay)
+		}
+	})
+	t.Run("if the missed events are no longer available, a reload is replayed", func(t *testing.T) {
+		h := New()
+		h.ReplaySize = 2
+		h.Send("message", "a")
+		lastEventID := h.LastEventID()
+		h.Send("css", "b")
+		h.Send("css", "c")
+		h.Send("css", "d")
+		sub := h.Subscribe(Client{}, lastEventID)
+		defer sub.Close()
+		if len(sub.Replay) != 1 || sub.Replay[0].Type != "message" || sub.Replay[0].Data != "reload" {
+			t.Errorf("expected a reload to be replayed, got %v", sub.Replay)
+		}
+	})
+	t.Run("slow clients are disconnected without blocking", func(t *testing.T) {
+		h := New()
+		sub := h.Subscribe(Client{}, "")
+		defer sub.Close()
+		for i := 0; i < clientBufferSize+1; i++ {
+			h.Send("message", "reload")
+		}
+		var received int
+		for range sub.Events {
+			received++
+		}
+		if received != clientBufferSize {
+			t.Errorf("expected %d events before the channel was closed, got %d", clientBufferSize, received)
+		}
+	})
+	t.Run("connected clients are listed until they unsubscribe", func(t *testing.T) {
+		h := New()
+		a := h.Subscribe(Client{Transport: "sse"}, "")
+		b := h.Subscribe(Client{Transport: "websocket"}, "")
+		h.SetClientURL(b.ID, "http://localhost:7331/")
+		clients := h.Clients()
+		if len(clients) != 2 || clients[1].URL != "http://localhost:7331/" {
+			t.Fatalf("unexpected clients: %#v", clients)
+		}
+		a.Close()
+		b.Close()
+		if clients := h.Clients(); len(clients) != 0 {
+			t.Errorf("expected no clients, got %#v", clients)
+		}
+	})
+}
+
+func TestServeHTTP(t *testing.T) {
+	h := New()
+	h.Heartbeat = time.Millisecond * 10
+	h.Send("message", "a")
+	lastEventID := h.LastEventID()
+	h.Send("message", "reload")
+	s := httptest.NewServer(h)
+	defer s.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+	req.Header.Set("Last-Event-ID", lastEventID)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+		if scanner.Text() == ": heartbeat" {
+			break
+		}
+	}
+	output := strings.Join(lines, "\n")
+	expected := "retry: 1000\n\nid: " + h.eventID(2) + "\nevent: message\ndata: reload\n\n: heartbeat"
+	if output != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, output)
+	}
+}
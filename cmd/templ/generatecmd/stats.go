@@ -0,0 +1,90 @@
+package generatecmd
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Stats of a generation run.
+type Stats struct {
+	// Parsed is the number of templates that were parsed and generated.
+	Parsed int `json:"parsed"`
+	// Skipped is the number of templates that were up-to-date, and weren't parsed.
+	Skipped int `json:"skipped"`
+	// Written is the number of files that were written.
+	Written int `json:"written"`
+	// Errors is the number of templates that failed to generate.
+	Errors int `json:"errors"`
+	// DurationMS is the total time spent generating templates, in milliseconds.
+	DurationMS float64     `json:"durationMs"`
+	Files      []FileStats `json:"files"`
+}
+
+// FileStats are the stats of a single template.
+type FileStats struct {
+	FileName string `json:"fileName"`
+	// Result is "generated", "skipped" or "error".
+	Result string `json:"result"`
+	// Written is the number of files that were written.
+	Written    int     `json:"written"`
+	DurationMS float64 `json:"durationMs"`
+}
+
+const (
+	fileResultGenerated = "generated"
+	fileResultSkipped   = "skipped"
+	fileResultError     = "error"
+)
+
+type statsCollector struct {
+	m     sync.Mutex
+	files map[string]FileStats
+}
+
+func newStatsCollector() *statsCollector {
+	return &statsCollector{
+		files: make(map[string]FileStats),
+	}
+}
+
+// add records the result of generating a template. In watch mode, templates are
+// generated many times. Only the most recent result is kept.
+func (s *statsCollector) add(fileName, result string, written int, d time.Duration) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.files[fileName] = FileStats{
+		FileName:   fileName,
+		Result:     result,
+		Written:    written,
+		DurationMS: durationMS(d),
+	}
+}
+
+func (s *statsCollector) stats() (stats Stats) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	stats.Files = make([]FileStats, 0, len(s.files))
+	for _, f := range s.files {
+		switch f.Result {
+		case fileResultGenerated:
+			stats.Parsed++
+		case fileResultSkipped:
+			stats.Skipped++
+		case fileResultError:
+			stats.Errors++
+		}
+		stats.Written += f.Written
+		stats.DurationMS += f.DurationMS
+		stats.Files = append(stats.Files, f)
+	}
+	slices.SortFunc(stats.Files, func(a, b FileStats) int {
+		return strings.Compare(a.FileName, b.FileName)
+	})
+	return stats
+}
+
+func durationMS(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -19,6 +19,7 @@ import (
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
+	"github.com/a-h/templ/cmd/templ/config"
 	"github.com/a-h/templ/cmd/templ/generatecmd"
 	"github.com/a-h/templ/cmd/templ/generatecmd/modcheck"
 )
@@ -209,12 +210,13 @@ func TestFileModificationsResultInSSEWithGzip(t *testing.T) {
 	}
 
 	// Give the filesystem watcher a few seconds.
+	// Text changes don't require a restart, so the page is updated in place.
 	var reloadCount int
 loop:
 	for {
 		select {
 		case event := <-events:
-			if event.Data == "reload" {
+			if event.Type == "html" {
 				reloadCount++
 				break loop
 			}
@@ -277,12 +279,13 @@ func TestFileModificationsResultInSSE(t *testing.T) {
 	}
 
 	// Give the filesystem watcher a few seconds.
+	// Text changes don't require a restart, so the page is updated in place.
 	var reloadCount int
 loop:
 	for {
 		select {
 		case event := <-events:
-			if event.Data == "reload" {
+			if event.Type == "html" {
 				reloadCount++
 				break loop
 			}
@@ -309,6 +312,136 @@ loop:
 	}
 }
 
+func TestWatchedAssetModificationsResultInSSE(t *testing.T) {
+	if testing.Short() {
+		return
+	}
+	args, teardown, err := Setup(false, func(a *generatecmd.Arguments) {
+		a.WatchInclude = []config.WatchPattern{
+			{Pattern: "*.css", Action: config.WatchActionReload},
+		}
+		a.WatchExclude = []string{"*.min.css"}
+	})
+	if err != nil {
+		t.Fatalf("failed to setup test: %v", err)
+	}
+	defer teardown(t)
+
+	// Start the SSE check.
+	events := make(chan Event)
+	go func() {
+		_ = readSSE(context.Background(), fmt.Sprintf("%s/_templ/reload/events", args.ProxyURL), events)
+	}()
+	// Give the SSE client time to connect.
+	time.Sleep(time.Second)
+
+	receivedReload := func() bool {
+		for {
+			select {
+			case event := <-events:
+				if event.Data == "reload" || event.Type == "css" {
+					return true
+				}
+			case <-time.After(time.Second * 2):
+				return false
+			}
+		}
+	}
+
+	// Excluded files don't trigger a reload.
+	if err = os.WriteFile(filepath.Join(args.AppDir, "site.min.css"), []byte("body{}"), 0660); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	if receivedReload() {
+		t.Error("expected excluded file not to trigger a reload")
+	}
+
+	// Included files trigger a reload.
+	if err = os.WriteFile(filepath.Join(args.AppDir, "site.css"), []byte("body {}"), 0660); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	if !receivedReload() {
+		t.Error("failed to receive SSE about asset update after 2 seconds")
+	}
+}
+
+func TestGenerationErrorsResultInSSE(t *testing.T) {
+	if testing.Short() {
+		return
+	}
+	args, teardown, err := Setup(false)
+	if err != nil {
+		t.Fatalf("failed to setup test: %v", err)
+	}
+	defer teardown(t)
+
+	// Start the SSE check.
+	events := make(chan Event)
+	go func() {
+		_ = readSSE(context.Background(), fmt.Sprintf("%s/_templ/reload/events", args.ProxyURL), events)
+	}()
+	// Give the SSE client time to connect.
+	time.Sleep(time.Second)
+
+	receivedErrors := func() (data string, ok bool) {
+		for {
+			select {
+			case event := <-events:
+				if event.Type == "templ-errors" {
+					return event.Data, true
+				}
+			case <-time.After(time.Second * 5):
+				return "", false
+			}
+		}
+	}
+
+	// Break the HTML.
+	templFile := filepath.Join(args.AppDir, "templates.templ")
+	err = replaceInFile(templFile,
+		`<div data-testid="modification">Original</div>`,
+		`<div data-testid="modification" -unclosed div-</div>`)
+	if err != nil {
+		t.Fatalf("failed to replace text in file: %v", err)
+	}
+	data, ok := receivedErrors()
+	if !ok {
+		t.Fatal("failed to receive SSE about generation error after 5 seconds")
+	}
+	if !strings.Contains(data, `"fileName":"templates.templ"`) || !strings.Contains(data, `"codeFrame"`) {
+		t.Errorf("expected the error to include the file name and code frame, got %s", data)
+	}
+
+	// Fix the HTML.
+	err = replaceInFile(templFile,
+		`<div data-testid="modification" -unclosed div-</div>`,
+		`<div data-testid="modification">Original</div>`)
+	if err != nil {
+		t.Fatalf("failed to replace text in file: %v", err)
+	}
+	data, ok = receivedErrors()
+	if !ok {
+		t.Fatal("failed to receive SSE about cleared errors after 5 seconds")
+	}
+	if data != "[]" {
+		t.Errorf("expected errors to be cleared, got %s", data)
+	}
+
+	// The errors are available to pages that are loaded later.
+	resp, err := http.Get(args.ProxyURL + "/_templ/reload/errors")
+	if err != nil {
+		t.Fatalf("failed to get errors: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("failed to read errors: %v", err)
+	}
+	if string(body) != "[]" {
+		t.Errorf("expected no errors, got %s", body)
+	}
+}
+
 func NewTestArgs(modRoot, appDir string, appPort int, proxyBind string, proxyPort int) TestArgs {
 	return TestArgs{
 		ModRoot:   modRoot,
@@ -331,7 +464,7 @@ type TestArgs struct {
 	ProxyURL  string
 }
 
-func Setup(gzipEncoding bool) (args TestArgs, teardown func(t *testing.T), err error) {
+func Setup(gzipEncoding bool, opts ...func(*generatecmd.Arguments)) (args TestArgs, teardown func(t *testing.T), err error) {
 	wd, err := os.Getwd()
 	if err != nil {
 		return args, teardown, fmt.Errorf("could not find working dir: %w", err)
@@ -372,7 +505,7 @@ func Setup(gzipEncoding bool) (args TestArgs, teardown func(t *testing.T), err e
 
 		log := slog.New(slog.NewJSONHandler(io.Discard, nil))
 
-		cmdErr = generatecmd.Run(ctx, log, generatecmd.Arguments{
+		generateArgs := generatecmd.Arguments{
 			Path:                            appDir,
 			Watch:                           true,
 			OpenBrowser:                     false,
@@ -387,7 +520,11 @@ func Setup(gzipEncoding bool) (args TestArgs, teardown func(t *testing.T), err e
 			IncludeTimestamp:                false,
 			PPROFPort:                       0,
 			KeepOrphanedFiles:               false,
-		})
+		}
+		for _, opt := range opts {
+			opt(&generateArgs)
+		}
+		cmdErr = generatecmd.Run(ctx, log, generateArgs)
 	}()
 
 	// Wait for server to start.
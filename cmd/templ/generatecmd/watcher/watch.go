@@ -16,6 +16,7 @@ import (
 func Recursive(
 	ctx context.Context,
 	path string,
+	filter Filter,
 	out chan fsnotify.Event,
 	errors chan error,
 ) (w *RecursiveWatcher, err error) {
@@ -26,6 +27,8 @@ func Recursive(
 	w = &RecursiveWatcher{
 		ctx:    ctx,
 		w:      fsnw,
+		root:   path,
+		filter: filter,
 		Events: out,
 		Errors: errors,
 		timers: make(map[timerKey]*time.Timer),
@@ -35,8 +38,9 @@ func Recursive(
 }
 
 // WalkFiles walks the file tree rooted at path, sending a Create event for each
-// file it encounters.
-func WalkFiles(ctx context.Context, path string, out chan fsnotify.Event) (err error) {
+// templ file it encounters. Files matched by the filter's Include patterns are
+// only watched for changes, and aren't sent.
+func WalkFiles(ctx context.Context, path string, filter Filter, out chan fsnotify.Event) (err error) {
 	rootPath := path
 	fileSystem := os.DirFS(rootPath)
 	return fs.WalkDir(fileSystem, ".", func(path string, info os.DirEntry, err error) error {
@@ -47,10 +51,10 @@ func WalkFiles(ctx context.Context, path string, out chan fsnotify.Event) (err e
 		if err != nil {
 			return nil
 		}
-		if info.IsDir() && shouldSkipDir(absPath) {
+		if info.IsDir() && (shouldSkipDir(absPath) || filter.excludes(path)) {
 			return filepath.SkipDir
 		}
-		if !shouldIncludeFile(absPath) {
+		if !IsTemplFile(absPath) || filter.excludes(path) {
 			return nil
 		}
 		out <- fsnotify.Event{
@@ -61,7 +65,8 @@ func WalkFiles(ctx context.Context, path string, out chan fsnotify.Event) (err e
 	})
 }
 
-func shouldIncludeFile(name string) bool {
+// IsTemplFile returns true if the file is a templ file, or a file generated from one.
+func IsTemplFile(name string) bool {
 	if strings.HasSuffix(name, ".templ") {
 		return true
 	}
@@ -74,9 +79,83 @@ func shouldIncludeFile(name string) bool {
 	return false
 }
 
+// Filter selects the files and directories to watch, in addition to templ files.
+//
+// Patterns are matched against slash separated paths relative to the watched
+// directory. A "**" path segment matches any number of directories. Patterns
+// that don't contain a slash are matched against the file or directory name.
+type Filter struct {
+	// Include patterns of additional files to watch.
+	Include []string
+	// Exclude patterns of files and directories to ignore.
+	Exclude []string
+}
+
+func (f Filter) includes(rel, name string) bool {
+	if f.excludes(rel) {
+		return false
+	}
+	if IsTemplFile(name) {
+		return true
+	}
+	return matchAny(f.Include, rel)
+}
+
+// excludes returns true if the path, or any of its parent directories, is excluded.
+func (f Filter) excludes(rel string) bool {
+	segments := strings.Split(filepath.ToSlash(rel), "/")
+	for i := range segments {
+		if matchAny(f.Exclude, strings.Join(segments[:i+1], "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchAny(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		if Match(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// Match returns true if the path, relative to the watched directory, matches
+// the pattern. See Filter for the pattern syntax.
+func Match(pattern, rel string) bool {
+	if !strings.Contains(pattern, "/") {
+		pattern = "**/" + pattern
+	}
+	return matchGlob(strings.Split(pattern, "/"), strings.Split(filepath.ToSlash(rel), "/"))
+}
+
+func matchGlob(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchGlob(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
 type RecursiveWatcher struct {
 	ctx     context.Context
 	w       *fsnotify.Watcher
+	root    string
+	filter  Filter
 	Events  chan fsnotify.Event
 	Errors  chan error
 	timerMu sync.Mutex
@@ -113,8 +192,8 @@ func (w *RecursiveWatcher) loop() {
 					w.Errors <- err
 				}
 			}
-			// Only notify on templ related files.
-			if !shouldIncludeFile(event.Name) {
+			// Only notify on templ related files, and files matched by the filter.
+			if !w.filter.includes(w.rel(event.Name), event.Name) {
 				continue
 			}
 			tk := timerKeyFromEvent(event)
@@ -148,21 +227,33 @@ func (w *RecursiveWatcher) Add(dir string) error {
 		if !info.IsDir() {
 			return nil
 		}
-		if shouldSkipDir(dir) {
+		if shouldSkipDir(dir) || w.filter.excludes(w.rel(dir)) {
 			return filepath.SkipDir
 		}
 		return w.w.Add(dir)
 	})
 }
 
+// rel returns the path of name relative to the watched directory.
+func (w *RecursiveWatcher) rel(name string) string {
+	if w.root == "" {
+		return name
+	}
+	rel, err := filepath.Rel(w.root, name)
+	if err != nil {
+		return name
+	}
+	return rel
+}
+
 func shouldSkipDir(dir string) bool {
 	if dir == "." {
 		return false
 	}
-	if dir == "vendor" || dir == "node_modules" {
+	name := filepath.Base(dir)
+	if name == "vendor" || name == "node_modules" {
 		return true
 	}
-	_, name := path.Split(dir)
 	// These directories are ignored by the Go tool.
 	if strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
 		return true
@@ -123,3 +123,31 @@ func TestWatchDoesNotDebounceSeparateEvents(t *testing.T) {
 		}
 	}
 }
+
+func TestFilter(t *testing.T) {
+	f := Filter{
+		Include: []string{"*.css", "static/**/*.js"},
+		Exclude: []string{"tmp", "**/*.min.css"},
+	}
+	tests := []struct {
+		rel      string
+		expected bool
+	}{
+		{rel: "index.templ", expected: true},
+		{rel: "index_templ.go", expected: true},
+		{rel: "main.go", expected: false},
+		{rel: "styles/site.css", expected: true},
+		{rel: "styles/site.min.css", expected: false},
+		{rel: "static/app.js", expected: true},
+		{rel: "static/js/app.js", expected: true},
+		{rel: "scripts/app.js", expected: false},
+		{rel: "tmp/index.templ", expected: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rel, func(t *testing.T) {
+			if actual := f.includes(tt.rel, "/root/"+tt.rel); actual != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, actual)
+			}
+		})
+	}
+}
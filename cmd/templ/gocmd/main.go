@@ -0,0 +1,328 @@
+package gocmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/scanner"
+	"go/token"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/a-h/templ/cmd/templ/generatecmd"
+)
+
+type Arguments struct {
+	// Args are passed to the go command, e.g. ["test", "-race", "./..."].
+	Args []string
+	// Dir is the working directory of the go command.
+	Dir string
+	// Generate is used to generate the templates. Path and Files are set by Run.
+	Generate generatecmd.Arguments
+}
+
+// Subcommands of the go command that build packages, and are supported by templ go.
+var Subcommands = []string{"build", "install", "run", "test", "vet"}
+
+// Run generates the templates in the packages being built, then runs the go
+// command. Positions in generated _templ.go files in the output of the go
+// command are mapped to positions in the templates.
+func Run(ctx context.Context, log *slog.Logger, stdin io.Reader, stdout, stderr io.Writer, args Arguments) (err error) {
+	if len(args.Args) == 0 || !slices.Contains(Subcommands, args.Args[0]) {
+		return fmt.Errorf("unsupported go command, expected one of: %s", strings.Join(Subcommands, ", "))
+	}
+	dir, patterns := packagePatterns(args.Dir, args.Args[0], args.Args[1:])
+	if !filepath.IsAbs(dir) {
+		if dir, err = filepath.Abs(dir); err != nil {
+			return fmt.Errorf("failed to get absolute path: %w", err)
+		}
+	}
+	files, err := templateFiles(ctx, dir, patterns, buildFlags(args.Args[1:]))
+	if err != nil {
+		return err
+	}
+
+	// Generate templates.
+	genArgs := args.Generate
+	genArgs.Path = dir
+	genArgs.Files = files
+	if len(files) > 0 {
+		log.Debug("Generating templates", slog.Int("count", len(files)))
+		if err = generatecmd.Run(ctx, log, genArgs); err != nil {
+			return err
+		}
+	}
+
+	// Run the go command.
+	cmd := exec.CommandContext(ctx, "go", args.Args...)
+	cmd.Dir = args.Dir
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	ew := &errorWriter{
+		w:      stderr,
+		dir:    dir,
+		mapper: generatecmd.NewSourceMapper(log, genArgs),
+	}
+	cmd.Stderr = ew
+	err = cmd.Run()
+	if flushErr := ew.Flush(); flushErr != nil && err == nil {
+		err = flushErr
+	}
+	return err
+}
+
+// flagsWithValues are the flags of go build and go test that take a value.
+var flagsWithValues = map[string]bool{
+	"C": true, "o": true, "p": true, "asmflags": true, "buildmode": true,
+	"compiler": true, "gccgoflags": true, "gcflags": true, "installsuffix": true, "ldflags": true,
+	"mod": true, "modfile": true, "overlay": true, "pgo": true, "pkgdir": true, "tags": true,
+	"toolexec": true, "exec": true, "vet": true, "covermode": true, "coverpkg": true,
+	"bench": true, "benchtime": true, "blockprofile": true, "blockprofilerate": true, "count": true,
+	"coverprofile": true, "cpu": true, "cpuprofile": true, "fuzz": true, "fuzzminimizetime": true,
+	"fuzztime": true, "list": true, "memprofile": true, "memprofilerate": true, "mutexprofile": true,
+	"mutexprofilefraction": true, "outputdir": true, "parallel": true, "run": true, "shuffle": true,
+	"skip": true, "timeout": true, "trace": true,
+}
+
+// buildFlags returns the flags that affect which packages are built, so that
+// they can be passed to go list.
+func buildFlags(args []string) (flags []string) {
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := splitFlag(args[i])
+		if name == "" {
+			continue
+		}
+		if !hasValue && flagsWithValues[name] && i+1 < len(args) {
+			i++
+			value, hasValue = args[i], true
+		}
+		switch name {
+		case "tags", "mod", "modfile":
+			flags = append(flags, "-"+name+"="+value)
+		}
+	}
+	return flags
+}
+
+// splitFlag returns the name of the flag, and its value if it's set with -name=value.
+func splitFlag(arg string) (name, value string, hasValue bool) {
+	if !strings.HasPrefix(arg, "-") || arg == "-" || arg == "--" {
+		return "", "", false
+	}
+	name, value, hasValue = strings.Cut(strings.TrimLeft(arg, "-"), "=")
+	return name, value, hasValue
+}
+
+// packagePatterns returns the working directory of the go command, and the
+// package patterns or .go files in the arguments.
+func packagePatterns(dir, subcommand string, args []string) (workDir string, patterns []string) {
+	workDir = dir
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "-args" && subcommand == "test" {
+			break
+		}
+		if name, value, hasValue := splitFlag(arg); name != "" {
+			if !hasValue && flagsWithValues[name] && i+1 < len(args) {
+				i++
+				value = args[i]
+			}
+			if name == "C" {
+				workDir = filepath.Join(dir, value)
+			}
+			continue
+		}
+		patterns = append(patterns, arg)
+		// go run takes a single package, or a list of .go files. The remaining
+		// arguments are passed to the program.
+		if subcommand == "run" && !strings.HasSuffix(arg, ".go") {
+			break
+		}
+		if subcommand == "run" && i+1 < len(args) && !strings.HasSuffix(args[i+1], ".go") {
+			break
+		}
+	}
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+	return workDir, patterns
+}
+
+// templateFiles returns the templates in the packages matched by the patterns.
+// Templates outside of dir aren't included.
+func templateFiles(ctx context.Context, dir string, patterns, buildFlags []string) (files []string, err error) {
+	var dirs, recursiveDirs, importPaths []string
+	for _, pattern := range patterns {
+		switch {
+		case strings.HasSuffix(pattern, ".go"):
+			dirs = append(dirs, filepath.Dir(filepath.Join(dir, pattern)))
+		case isFilesystemPattern(pattern):
+			if root, ok := strings.CutSuffix(pattern, "..."); ok {
+				recursiveDirs = append(recursiveDirs, filepath.Join(dir, root))
+				continue
+			}
+			dirs = append(dirs, filepath.Join(dir, pattern))
+		default:
+			importPaths = append(importPaths, pattern)
+		}
+	}
+	if len(importPaths) > 0 {
+		listed, err := listPackageDirs(ctx, dir, importPaths, buildFlags)
+		if err != nil {
+			return nil, err
+		}
+		dirs = append(dirs, listed...)
+	}
+	seen := make(map[string]bool)
+	add := func(fileName string) {
+		if !seen[fileName] && isWithin(dir, fileName) {
+			seen[fileName] = true
+			files = append(files, fileName)
+		}
+	}
+	for _, d := range dirs {
+		matches, err := filepath.Glob(filepath.Join(d, "*.templ"))
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range matches {
+			add(m)
+		}
+	}
+	for _, root := range recursiveDirs {
+		err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if path != root && skipDir(path, d.Name()) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if strings.HasSuffix(path, ".templ") {
+				add(path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to find templates in %q: %w", root, err)
+		}
+	}
+	slices.Sort(files)
+	return files, nil
+}
+
+func isFilesystemPattern(pattern string) bool {
+	return pattern == "." || pattern == ".." || filepath.IsAbs(pattern) ||
+		strings.HasPrefix(pattern, "./") || strings.HasPrefix(pattern, "../")
+}
+
+// skipDir returns true for directories that the go command ignores when
+// matching ./... patterns, and for nested modules.
+func skipDir(path, name string) bool {
+	if strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || name == "testdata" || name == "vendor" {
+		return true
+	}
+	_, err := os.Stat(filepath.Join(path, "go.mod"))
+	return err == nil
+}
+
+func isWithin(dir, fileName string) bool {
+	rel, err := filepath.Rel(dir, fileName)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// listPackageDirs uses go list to find the directories of packages. Directories
+// that only contain templates aren't packages until they've been generated, so
+// they aren't found.
+func listPackageDirs(ctx context.Context, dir string, patterns, buildFlags []string) (dirs []string, err error) {
+	args := append([]string{"list", "-e", "-f", "{{.Dir}}"}, buildFlags...)
+	cmd := exec.CommandContext(ctx, "go", append(args, patterns...)...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list packages: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			dirs = append(dirs, line)
+		}
+	}
+	return dirs, nil
+}
+
+// positionExpression matches positions in generated files, e.g.
+// "./page_templ.go:37:66: undefined: name", or "vet: page_templ.go:1:2: ...".
+var positionExpression = regexp.MustCompile(`^(.*?)(\S+_templ\.go):(\d+):(\d+)(.*)$`)
+
+// errorWriter maps positions in generated _templ.go files to positions in
+// templates, line by line.
+type errorWriter struct {
+	w      io.Writer
+	dir    string
+	mapper *generatecmd.SourceMapper
+	buf    []byte
+}
+
+func (ew *errorWriter) Write(p []byte) (n int, err error) {
+	ew.buf = append(ew.buf, p...)
+	for {
+		i := bytes.IndexByte(ew.buf, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		line := string(ew.buf[:i+1])
+		ew.buf = ew.buf[i+1:]
+		if _, err = io.WriteString(ew.w, ew.mapLine(line)); err != nil {
+			return len(p), err
+		}
+	}
+}
+
+// Flush writes any incomplete line.
+func (ew *errorWriter) Flush() error {
+	if len(ew.buf) == 0 {
+		return nil
+	}
+	_, err := io.WriteString(ew.w, ew.mapLine(string(ew.buf)))
+	ew.buf = nil
+	return err
+}
+
+func (ew *errorWriter) mapLine(line string) string {
+	text, newline := strings.CutSuffix(line, "\n")
+	m := positionExpression.FindStringSubmatch(text)
+	if m == nil {
+		return line
+	}
+	prefix, goFileName, rest := m[1], m[2], m[5]
+	lineNumber, _ := strconv.Atoi(m[3])
+	col, _ := strconv.Atoi(m[4])
+	fileName := goFileName
+	if !filepath.IsAbs(fileName) {
+		fileName = filepath.Join(ew.dir, fileName)
+	}
+	list := ew.mapper.RemapErrorList(scanner.ErrorList{{
+		Pos: token.Position{Filename: fileName, Line: lineNumber, Column: col},
+	}})
+	if len(list) != 1 || list[0].Pos.Filename == fileName {
+		return line
+	}
+	templFileName := strings.TrimSuffix(goFileName, "_templ.go") + ".templ"
+	mapped := fmt.Sprintf("%s%s:%d:%d%s", prefix, templFileName, list[0].Pos.Line, list[0].Pos.Column, rest)
+	if newline {
+		mapped += "\n"
+	}
+	return mapped
+}
@@ -0,0 +1,200 @@
+package gocmd
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/a-h/templ/cmd/templ/generatecmd"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPackagePatterns(t *testing.T) {
+	tests := []struct {
+		name             string
+		args             []string
+		expectedDir      string
+		expectedPatterns []string
+	}{
+		{
+			name:             "no patterns builds the current directory",
+			args:             []string{"build", "-o", "app"},
+			expectedDir:      "work",
+			expectedPatterns: []string{"."},
+		},
+		{
+			name:             "flag values aren't patterns",
+			args:             []string{"test", "-race", "-run", "TestX", "-count=1", "./...", "example.com/pkg"},
+			expectedDir:      "work",
+			expectedPatterns: []string{"./...", "example.com/pkg"},
+		},
+		{
+			name:             "arguments after -args are passed to the test binary",
+			args:             []string{"test", ".", "-args", "./other"},
+			expectedDir:      "work",
+			expectedPatterns: []string{"."},
+		},
+		{
+			name:             "arguments after the package are passed to the program",
+			args:             []string{"run", "./cmd/app", "./serve"},
+			expectedDir:      "work",
+			expectedPatterns: []string{"./cmd/app"},
+		},
+		{
+			name:             "go run accepts a list of .go files",
+			args:             []string{"run", "main.go", "other.go", "arg.go.txt"},
+			expectedDir:      "work",
+			expectedPatterns: []string{"main.go", "other.go"},
+		},
+		{
+			name:             "-C changes the directory",
+			args:             []string{"build", "-C", "sub", "./..."},
+			expectedDir:      filepath.Join("work", "sub"),
+			expectedPatterns: []string{"./..."},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, patterns := packagePatterns("work", tt.args[0], tt.args[1:])
+			if dir != tt.expectedDir {
+				t.Errorf("expected dir %q, got %q", tt.expectedDir, dir)
+			}
+			if diff := cmp.Diff(tt.expectedPatterns, patterns); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestBuildFlags(t *testing.T) {
+	actual := buildFlags([]string{"-race", "-tags", "dev", "-mod=vendor", "-o", "app", "./..."})
+	expected := []string{"-tags=dev", "-mod=vendor"}
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestTemplateFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"a.templ",
+		"sub/b.templ",
+		"sub/testdata/c.templ",
+		"sub/.hidden/d.templ",
+		"nested/go.mod",
+		"nested/e.templ",
+	} {
+		fileName := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fileName, nil, 0660); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		name     string
+		patterns []string
+		expected []string
+	}{
+		{
+			name:     "a directory only includes its own templates",
+			patterns: []string{"."},
+			expected: []string{"a.templ"},
+		},
+		{
+			name:     "recursive patterns skip testdata, hidden directories and nested modules",
+			patterns: []string{"./..."},
+			expected: []string{"a.templ", "sub/b.templ"},
+		},
+		{
+			name:     ".go files include the templates in their directory",
+			patterns: []string{"sub/main.go"},
+			expected: []string{"sub/b.templ"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := templateFiles(context.Background(), dir, tt.patterns, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			actual := make([]string, len(files))
+			for i, f := range files {
+				rel, _ := filepath.Rel(dir, f)
+				actual[i] = filepath.ToSlash(rel)
+			}
+			if diff := cmp.Diff(tt.expected, actual); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestErrorWriter(t *testing.T) {
+	dir := t.TempDir()
+	template := `package views
+
+templ Page(name string) {
+	<div>
+		{ nam }
+	</div>
+}
+`
+	if err := os.MkdirAll(filepath.Join(dir, "views"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "views", "page.templ"), []byte(template), 0660); err != nil {
+		t.Fatal(err)
+	}
+	log := slog.New(slog.NewJSONHandler(io.Discard, nil))
+	args := generatecmd.Arguments{
+		Path:        dir,
+		WorkerCount: 1,
+	}
+	if err := generatecmd.Run(context.Background(), log, args); err != nil {
+		t.Fatalf("failed to generate: %v", err)
+	}
+	generated, err := os.ReadFile(filepath.Join(dir, "views", "page_templ.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Find the position of the undefined variable in the generated code.
+	var line, col int
+	for i, l := range bytes.Split(generated, []byte("\n")) {
+		if c := bytes.Index(l, []byte("(nam)")); c >= 0 {
+			line, col = i+1, c+2
+			break
+		}
+	}
+	if line == 0 {
+		t.Fatalf("expression not found in generated code:\n%s", generated)
+	}
+
+	var out bytes.Buffer
+	ew := &errorWriter{
+		w:      &out,
+		dir:    dir,
+		mapper: generatecmd.NewSourceMapper(log, args),
+	}
+	input := "# example.com/views\n" +
+		"views/page_templ.go:" + strconv.Itoa(line) + ":" + strconv.Itoa(col) + ": undefined: nam\n" +
+		"views/other.go:1:1: unrelated"
+	if _, err = io.WriteString(ew, input); err != nil {
+		t.Fatal(err)
+	}
+	if err = ew.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	expected := "# example.com/views\n" +
+		"views/page.templ:5:5: undefined: nam\n" +
+		"views/other.go:1:1: unrelated"
+	if diff := cmp.Diff(expected, out.String()); diff != "" {
+		t.Error(diff)
+	}
+}
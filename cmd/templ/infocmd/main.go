@@ -13,7 +13,9 @@ import (
 	"strings"
 
 	"github.com/a-h/templ"
+	"github.com/a-h/templ/cmd/templ/config"
 	"github.com/a-h/templ/cmd/templ/lspcmd/pls"
+	"gopkg.in/yaml.v3"
 )
 
 type Arguments struct {
@@ -25,9 +27,18 @@ type Info struct {
 		GOOS   string `json:"goos"`
 		GOARCH string `json:"goarch"`
 	} `json:"os"`
-	Go    ToolInfo `json:"go"`
-	Gopls ToolInfo `json:"gopls"`
-	Templ ToolInfo `json:"templ"`
+	Go     ToolInfo   `json:"go"`
+	Gopls  ToolInfo   `json:"gopls"`
+	Templ  ToolInfo   `json:"templ"`
+	Config ConfigInfo `json:"config"`
+}
+
+type ConfigInfo struct {
+	Location string `json:"location"`
+	OK       bool   `json:"ok"`
+	Message  string `json:"message,omitempty"`
+	// Effective is the config used by the templ CLI, with defaults applied.
+	Effective config.Config `json:"effective"`
 }
 
 type ToolInfo struct {
@@ -118,12 +129,28 @@ func findTempl() (location string, err error) {
 	return "", fmt.Errorf("templ is not in the path (%q). You can install templ with `go install github.com/a-h/templ/cmd/templ@latest`", os.Getenv("PATH"))
 }
 
+func getConfigInfo() (d ConfigInfo) {
+	c, err := config.Load(".")
+	if err != nil {
+		d.Message = err.Error()
+		return
+	}
+	d.Location = c.Path
+	if d.Location == "" {
+		d.Message = "no config file found, using defaults"
+	}
+	d.Effective = c.WithDefaults()
+	d.OK = true
+	return
+}
+
 func getInfo() (d Info) {
 	d.OS.GOOS = runtime.GOOS
 	d.OS.GOARCH = runtime.GOARCH
 	d.Go = getGoInfo()
 	d.Gopls = getGoplsInfo()
 	d.Templ = getTemplInfo()
+	d.Config = getConfigInfo()
 	return
 }
 
@@ -138,7 +165,20 @@ func Run(ctx context.Context, log *slog.Logger, stdout io.Writer, args Arguments
 	logInfo(ctx, log, "go", info.Go)
 	logInfo(ctx, log, "gopls", info.Gopls)
 	logInfo(ctx, log, "templ", info.Templ)
-	return nil
+	logInfo(ctx, log, "config", ToolInfo{
+		Location: info.Config.Location,
+		OK:       info.Config.OK,
+		Message:  info.Config.Message,
+	})
+	if !info.Config.OK {
+		return nil
+	}
+	enc := yaml.NewEncoder(stdout)
+	enc.SetIndent(2)
+	if err = enc.Encode(info.Config.Effective); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	return enc.Close()
 }
 
 func logInfo(ctx context.Context, log *slog.Logger, name string, ti ToolInfo) {
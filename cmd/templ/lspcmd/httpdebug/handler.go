@@ -5,6 +5,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"sort"
 
 	"github.com/a-h/templ"
 	"github.com/a-h/templ/cmd/templ/lspcmd/proxy"
@@ -17,6 +18,9 @@ var log *zap.Logger
 func NewHandler(l *zap.Logger, s *proxy.Server) http.Handler {
 	m := http.NewServeMux()
 	log = l
+	viewer := visualize.NewViewer(sourceMaps{s: s})
+	s.SourceMapChanged = viewer.Changed
+	m.Handle("/viewer/", http.StripPrefix("/viewer", viewer))
 	m.HandleFunc("/templ", func(w http.ResponseWriter, r *http.Request) {
 		uri := r.URL.Query().Get("uri")
 		c, ok := s.TemplSource.Get(uri)
@@ -80,6 +84,41 @@ func NewHandler(l *zap.Logger, s *proxy.Server) http.Handler {
 	return m
 }
 
+// sourceMaps provides the templates open in the editor to the source map
+// viewer.
+type sourceMaps struct {
+	s *proxy.Server
+}
+
+func (sm sourceMaps) Names() (uris []string) {
+	uris = sm.s.TemplSource.URIs()
+	sort.Strings(uris)
+	return uris
+}
+
+func (sm sourceMaps) File(uri string) (f visualize.File, ok bool) {
+	templSource, ok := sm.s.TemplSource.Get(uri)
+	if !ok {
+		return f, false
+	}
+	sourceMap, ok := sm.s.SourceMapCache.Get(uri)
+	if !ok {
+		return f, false
+	}
+	return visualize.File{
+		Name:      uri,
+		Templ:     templSource.String(),
+		Go:        sm.s.GoSource[uri],
+		SourceMap: sourceMap,
+	}, true
+}
+
+func getViewerURL(uri string) templ.SafeURL {
+	q := make(url.Values)
+	q.Set("name", uri)
+	return templ.SafeURL("/viewer/?" + q.Encode())
+}
+
 func getMapURL(uri string) templ.SafeURL {
 	return withQuery("/", uri)
 }
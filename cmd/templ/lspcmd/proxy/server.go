@@ -3,12 +3,13 @@ package proxy
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 	"regexp"
 	"strings"
 
-	"github.com/a-h/parse"
 	lsp "github.com/a-h/protocol"
 	"github.com/a-h/templ"
+	"github.com/a-h/templ/cmd/templ/config"
 	"github.com/a-h/templ/cmd/templ/imports"
 	"github.com/a-h/templ/generator"
 	"github.com/a-h/templ/parser/v2"
@@ -37,6 +38,9 @@ type Server struct {
 	DiagnosticCache *DiagnosticCache
 	TemplSource     *DocumentContents
 	GoSource        map[string]string
+	// SourceMapChanged is called after the source map of a template is
+	// updated or deleted, if set.
+	SourceMapChanged func(uri string)
 }
 
 func NewServer(log *zap.Logger, target lsp.Server, cache *SourceMapCache, diagnosticCache *DiagnosticCache) (s *Server) {
@@ -118,8 +122,10 @@ func (p *Server) convertGoRangeToTemplRange(templURI lsp.DocumentURI, input lsp.
 }
 
 // parseTemplate parses the templ file content, and notifies the end user via the LSP about how it went.
+// Syntax errors don't stop the template from being returned, so that the rest of the file can still
+// be used for completion, hover etc.
 func (p *Server) parseTemplate(ctx context.Context, uri uri.URI, templateText string) (template parser.TemplateFile, ok bool, err error) {
-	template, err = parser.ParseString(templateText)
+	template, syntaxDiagnostics, err := parser.ParseStringRecovering(templateText)
 	if err != nil {
 		msg := &lsp.PublishDiagnosticsParams{
 			URI: uri,
@@ -132,18 +138,6 @@ func (p *Server) parseTemplate(ctx context.Context, uri uri.URI, templateText st
 				},
 			},
 		}
-		if pe, isParserError := err.(parse.ParseError); isParserError {
-			msg.Diagnostics[0].Range = lsp.Range{
-				Start: lsp.Position{
-					Line:      uint32(pe.Pos.Line),
-					Character: uint32(pe.Pos.Col),
-				},
-				End: lsp.Position{
-					Line:      uint32(pe.Pos.Line),
-					Character: uint32(pe.Pos.Col),
-				},
-			}
-		}
 		msg.Diagnostics = p.DiagnosticCache.AddGoDiagnostics(string(uri), msg.Diagnostics)
 		err = lsp.ClientFromContext(ctx).PublishDiagnostics(ctx, msg)
 		if err != nil {
@@ -157,27 +151,15 @@ func (p *Server) parseTemplate(ctx context.Context, uri uri.URI, templateText st
 		return
 	}
 	ok = true
-	if len(parsedDiagnostics) > 0 {
+	if len(syntaxDiagnostics) > 0 || len(parsedDiagnostics) > 0 {
 		msg := &lsp.PublishDiagnosticsParams{
 			URI: uri,
 		}
+		for _, d := range syntaxDiagnostics {
+			msg.Diagnostics = append(msg.Diagnostics, templDiagnostic(lsp.DiagnosticSeverityError, d))
+		}
 		for _, d := range parsedDiagnostics {
-			msg.Diagnostics = append(msg.Diagnostics, lsp.Diagnostic{
-				Severity: lsp.DiagnosticSeverityWarning,
-				Code:     "",
-				Source:   "templ",
-				Message:  d.Message,
-				Range: lsp.Range{
-					Start: lsp.Position{
-						Line:      uint32(d.Range.From.Line),
-						Character: uint32(d.Range.From.Col),
-					},
-					End: lsp.Position{
-						Line:      uint32(d.Range.To.Line),
-						Character: uint32(d.Range.To.Col),
-					},
-				},
-			})
+			msg.Diagnostics = append(msg.Diagnostics, templDiagnostic(lsp.DiagnosticSeverityWarning, d))
 		}
 		msg.Diagnostics = p.DiagnosticCache.AddGoDiagnostics(string(uri), msg.Diagnostics)
 		err = lsp.ClientFromContext(ctx).PublishDiagnostics(ctx, msg)
@@ -200,6 +182,25 @@ func (p *Server) parseTemplate(ctx context.Context, uri uri.URI, templateText st
 	return
 }
 
+func templDiagnostic(severity lsp.DiagnosticSeverity, d parser.Diagnostic) lsp.Diagnostic {
+	return lsp.Diagnostic{
+		Severity: severity,
+		Code:     "",
+		Source:   "templ",
+		Message:  d.Message,
+		Range: lsp.Range{
+			Start: lsp.Position{
+				Line:      uint32(d.Range.From.Line),
+				Character: uint32(d.Range.From.Col),
+			},
+			End: lsp.Position{
+				Line:      uint32(d.Range.To.Line),
+				Character: uint32(d.Range.To.Col),
+			},
+		},
+	}
+}
+
 func (p *Server) Initialize(ctx context.Context, params *lsp.InitializeParams) (result *lsp.InitializeResult, err error) {
 	p.Log.Info("client -> server: Initialize")
 	defer p.Log.Info("client -> server: Initialize end")
@@ -219,7 +220,11 @@ func (p *Server) Initialize(ctx context.Context, params *lsp.InitializeParams) (
 	result.Capabilities.ExecuteCommandProvider.Commands = []string{}
 	result.Capabilities.DocumentFormattingProvider = true
 	result.Capabilities.SemanticTokensProvider = nil
-	result.Capabilities.DocumentRangeFormattingProvider = false
+	result.Capabilities.DocumentRangeFormattingProvider = true
+	result.Capabilities.DocumentOnTypeFormattingProvider = &lsp.DocumentOnTypeFormattingOptions{
+		FirstTriggerCharacter: "}",
+		MoreTriggerCharacter:  []string{">"},
+	}
 	result.Capabilities.TextDocumentSync = lsp.TextDocumentSyncOptions{
 		OpenClose:         true,
 		Change:            lsp.TextDocumentSyncKindFull,
@@ -594,7 +599,7 @@ func (p *Server) DidChange(ctx context.Context, params *lsp.DidChangeTextDocumen
 	//
 	// This change would increase the surface area of gopls that we use, so may surface a number of issues
 	// if enabled.
-	sm, _, err := generator.Generate(template, w)
+	sm, _, err := generator.Generate(template, w, p.generateOpts(params.TextDocument.URI)...)
 	if err != nil {
 		p.Log.Error("generate failure", zap.Error(err))
 		return
@@ -603,6 +608,7 @@ func (p *Server) DidChange(ctx context.Context, params *lsp.DidChangeTextDocumen
 	p.Log.Info("setting cache", zap.String("uri", string(params.TextDocument.URI)))
 	p.SourceMapCache.Set(string(params.TextDocument.URI), sm)
 	p.GoSource[string(params.TextDocument.URI)] = w.String()
+	p.sourceMapChanged(params.TextDocument.URI)
 	// Change the path.
 	params.TextDocument.URI = goURI
 	params.TextDocument.TextDocumentIdentifier.URI = goURI
@@ -613,6 +619,12 @@ func (p *Server) DidChange(ctx context.Context, params *lsp.DidChangeTextDocumen
 	return p.Target.DidChange(ctx, params)
 }
 
+func (p *Server) sourceMapChanged(uri lsp.DocumentURI) {
+	if p.SourceMapChanged != nil {
+		p.SourceMapChanged(string(uri))
+	}
+}
+
 func (p *Server) DidChangeConfiguration(ctx context.Context, params *lsp.DidChangeConfigurationParams) (err error) {
 	p.Log.Info("client -> server: DidChangeConfiguration")
 	defer p.Log.Info("client -> server: DidChangeConfiguration end")
@@ -641,6 +653,7 @@ func (p *Server) DidClose(ctx context.Context, params *lsp.DidCloseTextDocumentP
 	// Delete the template and sourcemaps from caches.
 	p.TemplSource.Delete(string(params.TextDocument.URI))
 	p.SourceMapCache.Delete(string(params.TextDocument.URI))
+	p.sourceMapChanged(params.TextDocument.URI)
 	// Get gopls to delete the Go file from its cache.
 	params.TextDocument.URI = goURI
 	return p.Target.DidClose(ctx, params)
@@ -667,7 +680,7 @@ func (p *Server) DidOpen(ctx context.Context, params *lsp.DidOpenTextDocumentPar
 	// Generate the output code and cache the source map and Go contents to use during completion
 	// requests.
 	w := new(strings.Builder)
-	sm, _, err := generator.Generate(template, w)
+	sm, _, err := generator.Generate(template, w, p.generateOpts(params.TextDocument.URI)...)
 	if err != nil {
 		return
 	}
@@ -676,6 +689,7 @@ func (p *Server) DidOpen(ctx context.Context, params *lsp.DidOpenTextDocumentPar
 	// Set the Go contents.
 	params.TextDocument.Text = w.String()
 	p.GoSource[string(params.TextDocument.URI)] = params.TextDocument.Text
+	p.sourceMapChanged(params.TextDocument.URI)
 	// Change the path.
 	params.TextDocument.URI = goURI
 	return p.Target.DidOpen(ctx, params)
@@ -785,6 +799,10 @@ func (p *Server) Formatting(ctx context.Context, params *lsp.DocumentFormattingP
 	if !ok {
 		return
 	}
+	// Formatting a file with syntax errors would move the broken code around.
+	if containsErrors(template) {
+		return
+	}
 	p.Log.Info("attempting to organise imports", zap.String("uri", template.Filepath))
 	template, err = imports.Process(template)
 	if err != nil {
@@ -792,7 +810,7 @@ func (p *Server) Formatting(ctx context.Context, params *lsp.DocumentFormattingP
 		return
 	}
 	w := new(strings.Builder)
-	err = template.Write(w)
+	err = template.WriteWithOptions(w, p.formatOptions(params.TextDocument.URI, params.Options))
 	if err != nil {
 		p.Log.Error("handleFormatting: faled to write template", zap.Error(err))
 		return
@@ -809,6 +827,46 @@ func (p *Server) Formatting(ctx context.Context, params *lsp.DocumentFormattingP
 	return
 }
 
+// formatOptions returns the format options from the project config of the
+// document. The editor's tab size is used to measure lines if the config
+// doesn't set one.
+func (p *Server) formatOptions(documentURI lsp.DocumentURI, editorOptions lsp.FormattingOptions) parser.FormatOptions {
+	cfg, err := config.Load(filepath.Dir(uri.URI(documentURI).Filename()))
+	if err != nil {
+		p.Log.Warn("failed to load config, using the default format options", zap.Error(err))
+	}
+	opts := cfg.Fmt.FormatOptions()
+	if opts.TabWidth == 0 {
+		opts.TabWidth = int(editorOptions.TabSize)
+	}
+	return opts
+}
+
+// generateOpts returns the generator options from the project config of the
+// document, so that the Go code matches the output of templ generate. The
+// version and timestamp comments don't change the Go code, so they're left out.
+func (p *Server) generateOpts(documentURI lsp.DocumentURI) (opts []generator.GenerateOpt) {
+	fileName := uri.URI(documentURI).Filename()
+	cfg, err := config.Load(filepath.Dir(fileName))
+	if err != nil {
+		p.Log.Warn("failed to load config, using the default generator options", zap.Error(err))
+	}
+	if *cfg.GenerateOptions(fileName).Minify {
+		opts = append(opts, generator.WithMinify())
+	}
+	return opts
+}
+
+func containsErrors(template parser.TemplateFile) (found bool) {
+	parser.Inspect(template, func(n any) bool {
+		if _, ok := n.(parser.ErrorNode); ok {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
 func (p *Server) Hover(ctx context.Context, params *lsp.HoverParams) (result *lsp.Hover, err error) {
 	p.Log.Info("client -> server: Hover")
 	defer p.Log.Info("client -> server: Hover end")
@@ -864,28 +922,13 @@ func (p *Server) Implementation(ctx context.Context, params *lsp.ImplementationP
 func (p *Server) OnTypeFormatting(ctx context.Context, params *lsp.DocumentOnTypeFormattingParams) (result []lsp.TextEdit, err error) {
 	p.Log.Info("client -> server: OnTypeFormatting")
 	defer p.Log.Info("client -> server: OnTypeFormatting end")
-	templURI := params.TextDocument.URI
-	// Rewrite the request.
-	var ok bool
-	ok, params.TextDocument.URI, params.Position = p.updatePosition(params.TextDocument.URI, params.Position)
-	if !ok {
-		return nil, nil
-	}
-	// Get the response.
-	result, err = p.Target.OnTypeFormatting(ctx, params)
-	if err != nil {
-		return
+	// Format the nodes on the line up to the character that was typed, e.g. the
+	// if statement closed by a }, or the element closed by a >.
+	r := lsp.Range{
+		Start: lsp.Position{Line: params.Position.Line},
+		End:   params.Position,
 	}
-	if result == nil {
-		return
-	}
-	// Rewrite the response.
-	for i := 0; i < len(result); i++ {
-		r := result[i]
-		r.Range = p.convertGoRangeToTemplRange(templURI, r.Range)
-		result[i] = r
-	}
-	return
+	return p.formatRange(params.TextDocument.URI, r, params.Options), nil
 }
 
 func (p *Server) PrepareRename(ctx context.Context, params *lsp.PrepareRenameParams) (result *lsp.Range, err error) {
@@ -914,26 +957,48 @@ func (p *Server) PrepareRename(ctx context.Context, params *lsp.PrepareRenamePar
 func (p *Server) RangeFormatting(ctx context.Context, params *lsp.DocumentRangeFormattingParams) (result []lsp.TextEdit, err error) {
 	p.Log.Info("client -> server: RangeFormatting")
 	defer p.Log.Info("client -> server: RangeFormatting end")
-	templURI := params.TextDocument.URI
-	// Rewrite the request.
-	var isTemplURI bool
-	isTemplURI, params.TextDocument.URI = convertTemplToGoURI(params.TextDocument.URI)
-	if !isTemplURI {
-		err = fmt.Errorf("not a templ file")
-		return
+	return p.formatRange(params.TextDocument.URI, params.Range, params.Options), nil
+}
+
+// formatRange formats the smallest nodes that enclose the range, and returns
+// the lines that changed. Outside of templates, e.g. in Go code, the whole
+// file is formatted, but only the changes within the range are returned.
+func (p *Server) formatRange(templURI lsp.DocumentURI, r lsp.Range, options lsp.FormattingOptions) (result []lsp.TextEdit) {
+	d, ok := p.TemplSource.Get(string(templURI))
+	if !ok {
+		return nil
 	}
-	// Call gopls.
-	result, err = p.Target.RangeFormatting(ctx, params)
+	src := d.String()
+	opts := p.formatOptions(templURI, options)
+	from := parser.Position{Line: r.Start.Line, Col: r.Start.Character}
+	to := parser.Position{Line: r.End.Line, Col: r.End.Character}
+	formatted, replaced, ok, err := parser.FormatRange(src, from, to, opts)
 	if err != nil {
-		return
+		// The template can't be formatted until its syntax errors are fixed.
+		p.Log.Info("range formatting skipped", zap.String("uri", string(templURI)), zap.Error(err))
+		return nil
 	}
-	// Rewrite the response.
-	for i := 0; i < len(result); i++ {
-		r := result[i]
-		r.Range = p.convertGoRangeToTemplRange(templURI, r.Range)
-		result[i] = r
+	if ok {
+		result = textEdits(src, int(replaced.From.Index), int(replaced.To.Index), formatted)
+	} else {
+		template, err := parser.ParseString(src)
+		if err != nil {
+			return nil
+		}
+		w := new(strings.Builder)
+		if err = template.WriteWithOptions(w, opts); err != nil {
+			p.Log.Error("formatRange: failed to write template", zap.Error(err))
+			return nil
+		}
+		for _, edit := range textEdits(src, 0, len(src), w.String()) {
+			if overlaps(edit, r) {
+				result = append(result, edit)
+			}
+		}
 	}
-	return result, err
+	// Keep the document in sync with the edits that the client will apply.
+	d.Replace(applyTextEdits(src, result))
+	return result
 }
 
 func (p *Server) References(ctx context.Context, params *lsp.ReferenceParams) (result []lsp.Location, err error) {
@@ -0,0 +1,102 @@
+package proxy
+
+import (
+	"sort"
+	"strings"
+
+	lsp "github.com/a-h/protocol"
+	"github.com/a-h/templ/cmd/templ/textdiff"
+)
+
+// textEdits returns the edits that replace the text of src between the start
+// and end indices with the replacement. Only the lines that differ are edited,
+// so that editors keep the cursor position, and the rest of the document.
+func textEdits(src string, start, end int, replacement string) (edits []lsp.TextEdit) {
+	lineStarts := []int{0}
+	for i, c := range src {
+		if c == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+	position := func(index int) lsp.Position {
+		line := sort.Search(len(lineStarts), func(i int) bool { return lineStarts[i] > index }) - 1
+		return lsp.Position{Line: uint32(line), Character: uint32(index - lineStarts[line])}
+	}
+
+	// Group the added and removed lines into edits.
+	from, to := start, start
+	var newText strings.Builder
+	var changed bool
+	flush := func() {
+		if changed {
+			edits = append(edits, lsp.TextEdit{
+				Range:   lsp.Range{Start: position(from), End: position(to)},
+				NewText: newText.String(),
+			})
+		}
+		from, changed = to, false
+		newText.Reset()
+	}
+	for _, op := range textdiff.Lines(splitLines(src[start:end]), splitLines(replacement)) {
+		switch op.Kind {
+		case ' ':
+			flush()
+			to += len(op.Line)
+			from = to
+		case '-':
+			to += len(op.Line)
+			changed = true
+		case '+':
+			newText.WriteString(op.Line)
+			changed = true
+		}
+	}
+	flush()
+	return edits
+}
+
+// splitLines splits s after each newline, so that the lines can be joined to
+// get s.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.SplitAfter(s, "\n")
+}
+
+// applyTextEdits returns src with the edits applied. The edits must be sorted,
+// and must not overlap.
+func applyTextEdits(src string, edits []lsp.TextEdit) string {
+	lineStarts := []int{0}
+	for i, c := range src {
+		if c == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+	index := func(p lsp.Position) int {
+		if int(p.Line) >= len(lineStarts) {
+			return len(src)
+		}
+		return min(lineStarts[p.Line]+int(p.Character), len(src))
+	}
+	var sb strings.Builder
+	var last int
+	for _, edit := range edits {
+		start := index(edit.Range.Start)
+		sb.WriteString(src[last:start])
+		sb.WriteString(edit.NewText)
+		last = index(edit.Range.End)
+	}
+	sb.WriteString(src[last:])
+	return sb.String()
+}
+
+// overlaps returns true if the edit changes text within the range, or inserts
+// text at its boundaries.
+func overlaps(edit lsp.TextEdit, r lsp.Range) bool {
+	return !positionBefore(r.End, edit.Range.Start) && !positionBefore(edit.Range.End, r.Start)
+}
+
+func positionBefore(a, b lsp.Position) bool {
+	return a.Line < b.Line || (a.Line == b.Line && a.Character < b.Character)
+}
@@ -0,0 +1,82 @@
+package proxy
+
+import (
+	"testing"
+
+	lsp "github.com/a-h/protocol"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTextEdits(t *testing.T) {
+	tests := []struct {
+		name        string
+		src         string
+		start, end  int
+		replacement string
+		expected    []lsp.TextEdit
+	}{
+		{
+			name:        "unchanged text has no edits",
+			src:         "a\nb\nc\n",
+			end:         6,
+			replacement: "a\nb\nc\n",
+		},
+		{
+			name:        "only changed lines are edited",
+			src:         "a\n  b\nc\n  d\ne\n",
+			end:         14,
+			replacement: "a\n\tb\nc\n\td\ne\n",
+			expected: []lsp.TextEdit{
+				{
+					Range:   lsp.Range{Start: lsp.Position{Line: 1}, End: lsp.Position{Line: 2}},
+					NewText: "\tb\n",
+				},
+				{
+					Range:   lsp.Range{Start: lsp.Position{Line: 3}, End: lsp.Position{Line: 4}},
+					NewText: "\td\n",
+				},
+			},
+		},
+		{
+			name:        "positions are relative to the document",
+			src:         "x\n<a>  <b/></a>",
+			start:       5,
+			end:         14,
+			replacement: "\n\t<b/>\n</a>",
+			expected: []lsp.TextEdit{
+				{
+					Range:   lsp.Range{Start: lsp.Position{Line: 1, Character: 3}, End: lsp.Position{Line: 1, Character: 12}},
+					NewText: "\n\t<b/>\n</a>",
+				},
+			},
+		},
+		{
+			name:        "lines can be inserted and deleted",
+			src:         "a\nb\nc\n",
+			end:         6,
+			replacement: "a\nx\nc\nd\n",
+			expected: []lsp.TextEdit{
+				{
+					Range:   lsp.Range{Start: lsp.Position{Line: 1}, End: lsp.Position{Line: 2}},
+					NewText: "x\n",
+				},
+				{
+					Range:   lsp.Range{Start: lsp.Position{Line: 3}, End: lsp.Position{Line: 3}},
+					NewText: "d\n",
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := textEdits(tt.src, tt.start, tt.end, tt.replacement)
+			if diff := cmp.Diff(tt.expected, actual); diff != "" {
+				t.Error(diff)
+			}
+			expected := tt.src[:tt.start] + tt.replacement + tt.src[tt.end:]
+			if diff := cmp.Diff(expected, applyTextEdits(tt.src, actual)); diff != "" {
+				t.Errorf("unexpected text after applying edits:\n%s", diff)
+			}
+		})
+	}
+}
@@ -2,20 +2,29 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"log/slog"
 	"os"
+	"os/exec"
 	"os/signal"
+	"path/filepath"
 	"runtime"
 
 	"github.com/a-h/templ"
+	"github.com/a-h/templ/cmd/templ/config"
+	"github.com/a-h/templ/cmd/templ/doccmd"
 	"github.com/a-h/templ/cmd/templ/fmtcmd"
 	"github.com/a-h/templ/cmd/templ/generatecmd"
+	generaterun "github.com/a-h/templ/cmd/templ/generatecmd/run"
+	"github.com/a-h/templ/cmd/templ/gocmd"
 	"github.com/a-h/templ/cmd/templ/infocmd"
 	"github.com/a-h/templ/cmd/templ/lspcmd"
+	"github.com/a-h/templ/cmd/templ/migratecmd"
 	"github.com/a-h/templ/cmd/templ/sloghandler"
+	"github.com/a-h/templ/cmd/templ/snapshotcmd"
 	"github.com/fatih/color"
 )
 
@@ -35,6 +44,10 @@ See docs at https://templ.guide
 commands:
   generate   Generates Go code from templ files
   fmt        Formats templ files
+  go         Generates templ files, then runs a go command
+  migrate    Rewrites templ files to use new APIs
+  doc        Generates a reference of the components in templ files
+  snapshot   Compares the HTML of storybook stories with snapshots
   lsp        Starts a language server for templ files
   info       Displays information about the templ environment
   version    Prints the version
@@ -52,6 +65,14 @@ func run(stdin io.Reader, stdout, stderr io.Writer, args []string) (code int) {
 		return generateCmd(stdout, stderr, args[2:])
 	case "fmt":
 		return fmtCmd(stdin, stdout, stderr, args[2:])
+	case "go":
+		return goCmd(stdin, stdout, stderr, args[2:])
+	case "migrate":
+		return migrateCmd(stdout, stderr, args[2:])
+	case "doc":
+		return docCmd(stdout, stderr, args[2:])
+	case "snapshot":
+		return snapshotCmd(stdout, stderr, args[2:])
 	case "lsp":
 		return lspCmd(stdin, stdout, stderr, args[2:])
 	case "version", "--version":
@@ -155,10 +176,16 @@ Args:
     Set to false to skip inclusion of the templ version in the generated code. (default true)
   -include-timestamp
     Set to true to include the current time in the generated code.
+  -minify
+    Set to true to minify the HTML and CSS output of the generated code.
   -watch
     Set to true to watch the path for changes and regenerate code.
   -cmd <cmd>
     Set the command to run after generating code.
+  -build-cmd <cmd>
+    Set a command to run to completion before the -cmd command is started, e.g. "go build -o ./tmp/app .".
+  -ready <url>
+    Wait for the -cmd command to accept connections before reloading the browser, e.g. tcp://localhost:8080 or http://localhost:8080/health.
   -proxy
     Set the URL to proxy after generating code and executing the command.
   -proxyport
@@ -167,10 +194,26 @@ Args:
     The address the proxy will listen on. (default 127.0.0.1)
   -notify-proxy
     If present, the command will issue a reload event to the proxy 127.0.0.1:7331, or use proxyport and proxybind to specify a different address.
+  -proxy-insecure-skip-verify
+    Don't verify the certificate of a HTTPS proxy target, e.g. a self-signed certificate. (default false)
+  -proxy-ca <file>
+    A PEM file of certificate authorities to trust when proxying to a HTTPS target.
+  -proxy-prefix <path>
+    The path prefix of the proxy's reload endpoints, to avoid collisions with the app's routes. (default /_templ)
+  -proxy-tls
+    Serve the proxy over HTTPS, using a generated local certificate. (default false)
   -w
     Number of workers to use when generating code. (default runtime.NumCPUs)
   -lazy
     Only generate .go files if the source .templ file is newer.	
+  -cache
+    Skip templates that haven't changed since they were last generated, using a cache stored in .templ/cache in the module directory. (default false)
+  -stats <file>
+    Write generation stats, including the number of files parsed, skipped and written, and the time taken for each file, to a JSON file.
+  -check
+    Check that the generated files are up-to-date, without writing them. Prints a diff of each out-of-date file, and lists orphaned files, then exits with a non-zero exit code. (default false)
+  -json
+    Output the results of -check as JSON. (default false)
   -pprof
     Port to run the pprof server on.
   -keep-orphaned-files
@@ -208,16 +251,27 @@ func generateCmd(stdout, stderr io.Writer, args []string) (code int) {
 	watchFlag := cmd.Bool("watch", false, "")
 	openBrowserFlag := cmd.Bool("open-browser", true, "")
 	cmdFlag := cmd.String("cmd", "", "")
+	buildCmdFlag := cmd.String("build-cmd", "", "")
+	readyFlag := cmd.String("ready", "", "")
 	proxyFlag := cmd.String("proxy", "", "")
 	proxyPortFlag := cmd.Int("proxyport", 7331, "")
 	proxyBindFlag := cmd.String("proxybind", "127.0.0.1", "")
 	notifyProxyFlag := cmd.Bool("notify-proxy", false, "")
+	proxyInsecureSkipVerifyFlag := cmd.Bool("proxy-insecure-skip-verify", false, "")
+	proxyCAFlag := cmd.String("proxy-ca", "", "")
+	proxyPrefixFlag := cmd.String("proxy-prefix", "/_templ", "")
+	proxyTLSFlag := cmd.Bool("proxy-tls", false, "")
 	workerCountFlag := cmd.Int("w", runtime.NumCPU(), "")
 	pprofPortFlag := cmd.Int("pprof", 0, "")
 	keepOrphanedFilesFlag := cmd.Bool("keep-orphaned-files", false, "")
 	verboseFlag := cmd.Bool("v", false, "")
 	logLevelFlag := cmd.String("log-level", "info", "")
 	lazyFlag := cmd.Bool("lazy", false, "")
+	cacheFlag := cmd.Bool("cache", false, "")
+	statsFlag := cmd.String("stats", "", "")
+	checkFlag := cmd.Bool("check", false, "")
+	jsonFlag := cmd.Bool("json", false, "")
+	minifyFlag := cmd.Bool("minify", false, "")
 	helpFlag := cmd.Bool("help", false, "")
 	err := cmd.Parse(args)
 	if err != nil {
@@ -231,6 +285,20 @@ func generateCmd(stdout, stderr io.Writer, args []string) (code int) {
 
 	log := newLogger(*logLevelFlag, *verboseFlag, stderr)
 
+	configDir := *pathFlag
+	if *fileNameFlag != "" {
+		configDir = *fileNameFlag
+	}
+	cfg, err := config.Load(configDir)
+	if err != nil {
+		color.New(color.FgRed).Fprint(stderr, "(✗) ")
+		fmt.Fprintln(stderr, "Failed to load config: "+err.Error())
+		return 1
+	}
+	if cfg.Path != "" {
+		log.Debug("Loaded config", slog.String("path", cfg.Path))
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	signalChan := make(chan os.Signal, 1)
 	signal.Notify(signalChan, os.Interrupt)
@@ -245,17 +313,23 @@ func generateCmd(stdout, stderr io.Writer, args []string) (code int) {
 		fw = generatecmd.WriterFileWriter(stdout)
 	}
 
-	err = generatecmd.Run(ctx, log, generatecmd.Arguments{
+	generateArgs := generatecmd.Arguments{
 		FileName:                        *fileNameFlag,
 		Path:                            *pathFlag,
 		FileWriter:                      fw,
 		Watch:                           *watchFlag,
 		OpenBrowser:                     *openBrowserFlag,
 		Command:                         *cmdFlag,
+		BuildCommand:                    *buildCmdFlag,
+		Ready:                           *readyFlag,
 		Proxy:                           *proxyFlag,
 		ProxyPort:                       *proxyPortFlag,
 		ProxyBind:                       *proxyBindFlag,
 		NotifyProxy:                     *notifyProxyFlag,
+		ProxyInsecureSkipVerify:         *proxyInsecureSkipVerifyFlag,
+		ProxyCAFile:                     *proxyCAFlag,
+		ProxyPrefix:                     *proxyPrefixFlag,
+		ProxyTLS:                        *proxyTLSFlag,
 		WorkerCount:                     *workerCountFlag,
 		GenerateSourceMapVisualisations: *sourceMapVisualisationsFlag,
 		IncludeVersion:                  *includeVersionFlag,
@@ -263,7 +337,15 @@ func generateCmd(stdout, stderr io.Writer, args []string) (code int) {
 		PPROFPort:                       *pprofPortFlag,
 		KeepOrphanedFiles:               *keepOrphanedFilesFlag,
 		Lazy:                            *lazyFlag,
-	})
+		Cache:                           *cacheFlag,
+		StatsFile:                       *statsFlag,
+		Check:                           *checkFlag,
+		CheckJSON:                       *jsonFlag,
+		CheckOutput:                     stdout,
+		Minify:                          *minifyFlag,
+	}
+	applyGenerateConfig(cfg, flagsSet(cmd), &generateArgs)
+	err = generatecmd.Run(ctx, log, generateArgs)
 	if err != nil {
 		color.New(color.FgRed).Fprint(stderr, "(✗) ")
 		fmt.Fprintln(stderr, "Command failed: "+err.Error())
@@ -272,6 +354,94 @@ func generateCmd(stdout, stderr io.Writer, args []string) (code int) {
 	return 0
 }
 
+// flagsSet returns the names of the flags that were set on the command line.
+func flagsSet(cmd *flag.FlagSet) map[string]bool {
+	set := make(map[string]bool)
+	cmd.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	return set
+}
+
+// applyGenerateConfig sets the arguments from the config file, unless the
+// equivalent flag was set on the command line.
+func applyGenerateConfig(cfg config.Config, set map[string]bool, args *generatecmd.Arguments) {
+	applyGeneratorConfig(cfg, set, args)
+	if cfg.Proxy.OpenBrowser != nil && !set["open-browser"] {
+		args.OpenBrowser = *cfg.Proxy.OpenBrowser
+	}
+	if cfg.Proxy.URL != "" && !set["proxy"] {
+		args.Proxy = cfg.Proxy.URL
+	}
+	if cfg.Proxy.Port > 0 && !set["proxyport"] {
+		args.ProxyPort = cfg.Proxy.Port
+	}
+	if cfg.Proxy.Bind != "" && !set["proxybind"] {
+		args.ProxyBind = cfg.Proxy.Bind
+	}
+	if cfg.Proxy.InsecureSkipVerify && !set["proxy-insecure-skip-verify"] {
+		args.ProxyInsecureSkipVerify = true
+	}
+	if cfg.Proxy.CAFile != "" && !set["proxy-ca"] {
+		args.ProxyCAFile = cfg.Proxy.CAFile
+	}
+	if cfg.Proxy.Prefix != "" && !set["proxy-prefix"] {
+		args.ProxyPrefix = cfg.Proxy.Prefix
+	}
+	if cfg.Proxy.TLS && !set["proxy-tls"] {
+		args.ProxyTLS = true
+	}
+	// The -cmd flag replaces the commands and processes of the config file.
+	if !set["cmd"] {
+		args.Commands = cfg.Commands
+		for _, p := range cfg.Processes {
+			args.Processes = append(args.Processes, generaterun.Process{
+				Name:  p.Name,
+				Build: p.Build,
+				Run:   p.Run,
+				Ready: p.Ready,
+			})
+		}
+	}
+	args.WatchInclude = cfg.Watch.Include
+	args.WatchExclude = cfg.Watch.Exclude
+}
+
+// applyGeneratorConfig sets the arguments that control code generation from the
+// config file, unless the equivalent flag was set on the command line. The
+// commands, processes, proxy and watch settings of watch mode aren't set.
+func applyGeneratorConfig(cfg config.Config, set map[string]bool, args *generatecmd.Arguments) {
+	applyBool := func(flag string, v *bool, target *bool) {
+		if v != nil && !set[flag] {
+			*target = *v
+		}
+	}
+	applyBool("include-version", cfg.Generate.IncludeVersion, &args.IncludeVersion)
+	applyBool("include-timestamp", cfg.Generate.IncludeTimestamp, &args.IncludeTimestamp)
+	applyBool("minify", cfg.Generate.Minify, &args.Minify)
+	applyBool("lazy", cfg.Generate.Lazy, &args.Lazy)
+	applyBool("cache", cfg.Generate.Cache, &args.Cache)
+	applyBool("keep-orphaned-files", cfg.Generate.KeepOrphanedFiles, &args.KeepOrphanedFiles)
+	applyBool("source-map-visualisations", cfg.Generate.SourceMapVisualisations, &args.GenerateSourceMapVisualisations)
+	if cfg.Generate.Workers > 0 && !set["w"] {
+		args.WorkerCount = cfg.Generate.Workers
+	}
+	for _, d := range cfg.Generate.Directories {
+		args.Directories = append(args.Directories, generatecmd.DirectoryArguments{
+			Path:             d.Path,
+			IncludeVersion:   d.IncludeVersion,
+			IncludeTimestamp: d.IncludeTimestamp,
+			Minify:           d.Minify,
+		})
+	}
+	if len(cfg.Lint.Rules) > 0 {
+		args.LintRules = make(map[string]string, len(cfg.Lint.Rules))
+		for rule, severity := range cfg.Lint.Rules {
+			args.LintRules[rule] = string(severity)
+		}
+	}
+}
+
 const fmtUsageText = `usage: templ fmt [<args> ...]
 
 Format all files in directory:
@@ -325,14 +495,326 @@ func fmtCmd(stdin io.Reader, stdout, stderr io.Writer, args []string) (code int)
 
 	log := newLogger(*logLevelFlag, *verboseFlag, stderr)
 
+	configDir := "."
+	if cmd.NArg() > 0 {
+		configDir = cmd.Arg(0)
+	} else if *stdinFilepath != "" {
+		configDir = filepath.Dir(*stdinFilepath)
+	}
+	cfg, err := config.Load(configDir)
+	if err != nil {
+		log.Error("Failed to load config", slog.Any("error", err))
+		return 1
+	}
+	if cfg.Fmt.Workers > 0 && !flagsSet(cmd)["w"] {
+		*workerCountFlag = cfg.Fmt.Workers
+	}
+
 	err = fmtcmd.Run(log, stdin, stdout, fmtcmd.Arguments{
 		ToStdout:      *stdoutFlag,
 		Files:         cmd.Args(),
 		WorkerCount:   *workerCountFlag,
 		StdinFilepath: *stdinFilepath,
 		FailIfChanged: *failIfChanged,
+		FormatOptions: cfg.Fmt.FormatOptions(),
+	})
+	if err != nil {
+		return 1
+	}
+	return 0
+}
+
+const goUsageText = `usage: templ go <build|install|run|test|vet> [<go args>...]
+
+Generates the templ files in the packages being built, then runs the go command
+with the same arguments. Positions in generated _templ.go files in compiler
+errors are mapped to positions in the templ files.
+
+Build all packages:
+
+  templ go build ./...
+
+Run tests:
+
+  templ go test -race ./...
+
+The generate options are read from the templ config file in the current
+directory, or its parents.
+
+Args:
+  -help
+    Print help and exit.
+`
+
+func goCmd(stdin io.Reader, stdout, stderr io.Writer, args []string) (code int) {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, goUsageText)
+		return 64 // EX_USAGE
+	}
+	switch args[0] {
+	case "help", "-help", "--help", "-h":
+		fmt.Fprint(stdout, goUsageText)
+		return 0
+	}
+
+	log := newLogger("warn", false, stderr)
+
+	cfg, err := config.Load(".")
+	if err != nil {
+		color.New(color.FgRed).Fprint(stderr, "(✗) ")
+		fmt.Fprintln(stderr, "Failed to load config: "+err.Error())
+		return 1
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	signalChan := make(chan os.Signal, 1)
+	signal.Notify(signalChan, os.Interrupt)
+	go func() {
+		<-signalChan
+		cancel()
+	}()
+
+	generateArgs := generatecmd.Arguments{
+		WorkerCount:    runtime.NumCPU(),
+		IncludeVersion: true,
+	}
+	applyGeneratorConfig(cfg, map[string]bool{}, &generateArgs)
+	err = gocmd.Run(ctx, log, stdin, stdout, stderr, gocmd.Arguments{
+		Args:     args,
+		Dir:      ".",
+		Generate: generateArgs,
+	})
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode()
+		}
+		color.New(color.FgRed).Fprint(stderr, "(✗) ")
+		fmt.Fprintln(stderr, "Command failed: "+err.Error())
+		return 1
+	}
+	return 0
+}
+
+const migrateUsageText = `usage: templ migrate [<args> ...] [<path>]
+
+Rewrites the templ files in a file or directory using rewrite rules. The
+built-in rules update templates to the syntax of the current templ version.
+
+Preview the changes:
+
+  templ migrate -dry-run .
+
+Rename a component:
+
+  templ migrate -rule '@OldButton($text, $opts...) => @NewButton($text, $opts...)' .
+
+Args:
+  -rule
+    A rewrite rule, written as "pattern => replacement". Can be repeated.
+  -rules
+    A YAML file of rewrite rules.
+  -no-builtin
+    Don't apply the built-in rules. (default false)
+  -dry-run
+    Prints a diff of the changes, instead of updating files. (default false)
+  -w
+    Number of workers to use when migrating code. (default runtime.NumCPUs).
+  -v
+    Set log verbosity level to "debug". (default "info")
+  -log-level
+    Set log verbosity level. (default "info", options: "debug", "info", "warn", "error")
+  -help
+    Print help and exit.
+`
+
+func migrateCmd(stdout, stderr io.Writer, args []string) (code int) {
+	cmd := flag.NewFlagSet("migrate", flag.ExitOnError)
+	var rules []migratecmd.Rule
+	cmd.Func("rule", "", func(s string) error {
+		r, err := migratecmd.ParseRule(s)
+		rules = append(rules, r)
+		return err
+	})
+	rulesFileFlag := cmd.String("rules", "", "")
+	noBuiltinFlag := cmd.Bool("no-builtin", false, "")
+	dryRunFlag := cmd.Bool("dry-run", false, "")
+	workerCountFlag := cmd.Int("w", runtime.NumCPU(), "")
+	verboseFlag := cmd.Bool("v", false, "")
+	logLevelFlag := cmd.String("log-level", "info", "")
+	helpFlag := cmd.Bool("help", false, "")
+	err := cmd.Parse(args)
+	if err != nil {
+		fmt.Fprint(stderr, migrateUsageText)
+		return 64 // EX_USAGE
+	}
+	if *helpFlag {
+		fmt.Fprint(stdout, migrateUsageText)
+		return
+	}
+
+	log := newLogger(*logLevelFlag, *verboseFlag, stderr)
+
+	if *rulesFileFlag != "" {
+		f, err := os.Open(*rulesFileFlag)
+		if err != nil {
+			log.Error("Failed to open rules file", slog.Any("error", err))
+			return 1
+		}
+		defer f.Close()
+		fileRules, err := migratecmd.LoadRules(f)
+		if err != nil {
+			log.Error("Failed to load rules", slog.Any("error", err))
+			return 1
+		}
+		rules = append(fileRules, rules...)
+	}
+
+	path := "."
+	if cmd.NArg() > 0 {
+		path = cmd.Arg(0)
+	}
+	configDir := path
+	if fi, err := os.Stat(path); err == nil && !fi.IsDir() {
+		configDir = filepath.Dir(path)
+	}
+	cfg, err := config.Load(configDir)
+	if err != nil {
+		log.Error("Failed to load config", slog.Any("error", err))
+		return 1
+	}
+
+	err = migratecmd.Run(log, stdout, migratecmd.Arguments{
+		Path:          path,
+		Rules:         rules,
+		SkipBuiltin:   *noBuiltinFlag,
+		DryRun:        *dryRunFlag,
+		WorkerCount:   *workerCountFlag,
+		FormatOptions: cfg.Fmt.FormatOptions(),
+	})
+	if err != nil {
+		log.Error("Migration failed", slog.Any("error", err))
+		return 1
+	}
+	return 0
+}
+
+const docUsageText = `usage: templ doc [<args> ...] [<path>]
+
+Generates an HTML and JSON reference of the templ components in a directory,
+with their doc comments, parameters, and the examples from Example functions
+in _test.go files.
+
+  templ doc -o docs/components ./components
+
+Args:
+  -o
+    The directory to write index.html and components.json to. (default "templ-doc")
+  -v
+    Set log verbosity level to "debug". (default "info")
+  -log-level
+    Set log verbosity level. (default "info", options: "debug", "info", "warn", "error")
+  -help
+    Print help and exit.
+`
+
+func docCmd(stdout, stderr io.Writer, args []string) (code int) {
+	cmd := flag.NewFlagSet("doc", flag.ExitOnError)
+	outputFlag := cmd.String("o", "templ-doc", "")
+	verboseFlag := cmd.Bool("v", false, "")
+	logLevelFlag := cmd.String("log-level", "info", "")
+	helpFlag := cmd.Bool("help", false, "")
+	err := cmd.Parse(args)
+	if err != nil {
+		fmt.Fprint(stderr, docUsageText)
+		return 64 // EX_USAGE
+	}
+	if *helpFlag {
+		fmt.Fprint(stdout, docUsageText)
+		return
+	}
+
+	log := newLogger(*logLevelFlag, *verboseFlag, stderr)
+
+	path := "."
+	if cmd.NArg() > 0 {
+		path = cmd.Arg(0)
+	}
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	err = doccmd.Run(ctx, log, doccmd.Arguments{
+		Path:      path,
+		OutputDir: *outputFlag,
+	})
+	if err != nil {
+		log.Error("Failed to generate documentation", slog.Any("error", err))
+		return 1
+	}
+	return 0
+}
+
+const snapshotUsageText = `usage: templ snapshot [<args> ...]
+
+Renders every story of a storybook server to HTML, and compares it with the
+snapshots stored in a directory. Snapshots are written for new stories. The
+command fails if a story has changed, or a snapshot has no story.
+
+Start the storybook server, and compare the stories with their snapshots:
+
+  templ snapshot -cmd "go run ./storybook"
+
+Update the snapshots of changed stories:
+
+  templ snapshot -cmd "go run ./storybook" -update
+
+Args:
+  -url
+    The URL of the storybook server, including its route prefix, if it has one. (default "http://localhost:60606")
+  -cmd
+    A command that starts the storybook server. It's stopped when the snapshots have been compared.
+  -dir
+    The directory that contains the snapshots. (default "snapshots")
+  -update
+    Update the snapshots of changed stories, and remove snapshots that have no story. (default false)
+  -v
+    Set log verbosity level to "debug". (default "info")
+  -log-level
+    Set log verbosity level. (default "info", options: "debug", "info", "warn", "error")
+  -help
+    Print help and exit.
+`
+
+func snapshotCmd(stdout, stderr io.Writer, args []string) (code int) {
+	cmd := flag.NewFlagSet("snapshot", flag.ExitOnError)
+	urlFlag := cmd.String("url", "http://localhost:60606", "")
+	cmdFlag := cmd.String("cmd", "", "")
+	dirFlag := cmd.String("dir", "snapshots", "")
+	updateFlag := cmd.Bool("update", false, "")
+	verboseFlag := cmd.Bool("v", false, "")
+	logLevelFlag := cmd.String("log-level", "info", "")
+	helpFlag := cmd.Bool("help", false, "")
+	err := cmd.Parse(args)
+	if err != nil {
+		fmt.Fprint(stderr, snapshotUsageText)
+		return 64 // EX_USAGE
+	}
+	if *helpFlag {
+		fmt.Fprint(stdout, snapshotUsageText)
+		return
+	}
+
+	log := newLogger(*logLevelFlag, *verboseFlag, stderr)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	err = snapshotcmd.Run(ctx, log, stdout, snapshotcmd.Arguments{
+		URL:    *urlFlag,
+		Cmd:    *cmdFlag,
+		Dir:    *dirFlag,
+		Update: *updateFlag,
 	})
 	if err != nil {
+		log.Error("Snapshot failed", slog.Any("error", err))
 		return 1
 	}
 	return 0
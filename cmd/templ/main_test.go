@@ -6,7 +6,10 @@ import (
 	"testing"
 
 	"github.com/a-h/templ"
+	"github.com/a-h/templ/cmd/templ/config"
+	"github.com/a-h/templ/cmd/templ/generatecmd"
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 )
 
 func TestMain(t *testing.T) {
@@ -65,6 +68,24 @@ func TestMain(t *testing.T) {
 			expectedStdout: lspUsageText,
 			expectedCode:   0,
 		},
+		{
+			name:           `"templ migrate --help" prints usage`,
+			args:           []string{"templ", "migrate", "--help"},
+			expectedStdout: migrateUsageText,
+			expectedCode:   0,
+		},
+		{
+			name:           `"templ doc --help" prints usage`,
+			args:           []string{"templ", "doc", "--help"},
+			expectedStdout: docUsageText,
+			expectedCode:   0,
+		},
+		{
+			name:           `"templ snapshot --help" prints usage`,
+			args:           []string{"templ", "snapshot", "--help"},
+			expectedStdout: snapshotUsageText,
+			expectedCode:   0,
+		},
 		{
 			name:           `"templ info --help" prints usage`,
 			args:           []string{"templ", "info", "--help"},
@@ -100,3 +121,89 @@ func TestMain(t *testing.T) {
 		})
 	}
 }
+
+func TestApplyGenerateConfig(t *testing.T) {
+	yes, no := true, false
+	cfg := config.Config{
+		Generate: config.Generate{
+			Options: config.Options{
+				IncludeVersion: &no,
+				Minify:         &yes,
+			},
+			Workers: 2,
+			Directories: []config.Directory{
+				{Path: "/app/components", Options: config.Options{Minify: &no}},
+			},
+		},
+		Proxy: config.Proxy{
+			URL:  "http://localhost:8080",
+			Port: 7332,
+		},
+		Commands: []string{"go run ."},
+		Processes: []config.Process{
+			{Name: "api", Run: "go run ./api"},
+		},
+		Lint: config.Lint{
+			Rules: map[string]config.Severity{"legacy-call-syntax": config.SeverityOff},
+		},
+	}
+	args := generatecmd.Arguments{
+		IncludeVersion: true,
+		WorkerCount:    8,
+		ProxyPort:      7331,
+		Command:        "go build",
+	}
+	// Flags set on the command line take precedence over the config file.
+	applyGenerateConfig(cfg, map[string]bool{"w": true, "cmd": true}, &args)
+
+	expected := generatecmd.Arguments{
+		IncludeVersion: false,
+		Minify:         true,
+		WorkerCount:    8,
+		Proxy:          "http://localhost:8080",
+		ProxyPort:      7332,
+		Command:        "go build",
+		Directories: []generatecmd.DirectoryArguments{
+			{Path: "/app/components", Minify: &no},
+		},
+		LintRules: map[string]string{"legacy-call-syntax": "off"},
+	}
+	if diff := cmp.Diff(expected, args, cmpopts.IgnoreFields(generatecmd.Arguments{}, "FileWriter")); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestApplyGeneratorConfig(t *testing.T) {
+	yes := true
+	cfg := config.Config{
+		Generate: config.Generate{
+			Options: config.Options{
+				Minify: &yes,
+			},
+			Lazy: &yes,
+		},
+		Proxy: config.Proxy{
+			URL:         "http://localhost:8080",
+			OpenBrowser: &yes,
+			TLS:         true,
+		},
+		Commands: []string{"go run ."},
+		Processes: []config.Process{
+			{Name: "api", Run: "go run ./api"},
+		},
+		Watch: config.Watch{
+			Exclude: []string{"tmp"},
+		},
+	}
+	var args generatecmd.Arguments
+	// The watch mode settings aren't used by templ go.
+	applyGeneratorConfig(cfg, map[string]bool{}, &args)
+
+	expected := generatecmd.Arguments{
+		Minify: true,
+		Lazy:   true,
+	}
+	if diff := cmp.Diff(expected, args, cmpopts.IgnoreFields(generatecmd.Arguments{}, "FileWriter")); diff != "" {
+		t.Error(diff)
+	}
+}
@@ -0,0 +1,110 @@
+package migratecmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/a-h/templ/cmd/templ/processor"
+	"github.com/a-h/templ/cmd/templ/textdiff"
+	parser "github.com/a-h/templ/parser/v2"
+	"github.com/natefinch/atomic"
+)
+
+type Arguments struct {
+	// Path is a templ file, or a directory of templ files.
+	Path string
+	// Rules are applied after the built-in rules.
+	Rules []Rule
+	// SkipBuiltin disables the built-in rule sets.
+	SkipBuiltin bool
+	// DryRun writes a diff of the changes to stdout, instead of updating files.
+	DryRun      bool
+	WorkerCount int
+	// FormatOptions control the layout of migrated templates.
+	FormatOptions parser.FormatOptions
+}
+
+// Run applies the migration rules to the templ files in the path. Only files
+// that are matched by a rule are written, so running it again makes no
+// changes.
+func Run(log *slog.Logger, stdout io.Writer, args Arguments) (err error) {
+	var rules []Rule
+	if !args.SkipBuiltin {
+		for _, rs := range Builtin {
+			rules = append(rules, rs.Rules...)
+		}
+	}
+	rules = append(rules, args.Rules...)
+	m, err := NewMigration(rules)
+	if err != nil {
+		return err
+	}
+	if args.WorkerCount == 0 {
+		args.WorkerCount = runtime.NumCPU()
+	}
+
+	var mu sync.Mutex
+	process := func(fileName string) (error, bool) {
+		src, out, changed, err := migrate(m, fileName, args.FormatOptions)
+		if err != nil || !changed {
+			return err, false
+		}
+		if args.DryRun {
+			mu.Lock()
+			defer mu.Unlock()
+			_, err = io.WriteString(stdout, textdiff.Unified("a/"+fileName, "b/"+fileName, src, out))
+			return err, true
+		}
+		return atomic.WriteFile(fileName, bytes.NewBufferString(out)), true
+	}
+
+	start := time.Now()
+	results := make(chan processor.Result)
+	log.Debug("Walking directory", slog.String("path", args.Path))
+	go processor.Process(args.Path, process, args.WorkerCount, results)
+	var successCount, errorCount, changedCount int
+	for r := range results {
+		if r.ChangesMade {
+			changedCount++
+		}
+		if r.Error != nil {
+			log.Error(r.FileName, slog.Any("error", r.Error))
+			errorCount++
+			continue
+		}
+		log.Debug(r.FileName, slog.Duration("duration", r.Duration))
+		successCount++
+	}
+	log.Info("Migrate Complete", slog.Int("count", successCount+errorCount), slog.Int("errors", errorCount), slog.Int("changed", changedCount), slog.Duration("duration", time.Since(start)))
+	if errorCount > 0 {
+		return fmt.Errorf("migration failed")
+	}
+	return nil
+}
+
+func migrate(m *Migration, fileName string, opts parser.FormatOptions) (src, out string, changed bool, err error) {
+	b, err := os.ReadFile(fileName)
+	if err != nil {
+		return "", "", false, fmt.Errorf("failed to read file %q: %w", fileName, err)
+	}
+	src = string(b)
+	t, err := parser.ParseString(src)
+	if err != nil {
+		return src, "", false, err
+	}
+	t.Filepath = fileName
+	if t, changed = m.Apply(t); !changed {
+		return src, src, false, nil
+	}
+	w := new(bytes.Buffer)
+	if err = t.WriteWithOptions(w, opts); err != nil {
+		return src, "", false, fmt.Errorf("formatting error: %w", err)
+	}
+	return src, w.String(), src != w.String(), nil
+}
@@ -0,0 +1,93 @@
+package migratecmd
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const legacyTemplate = `package main
+
+templ page() {
+	{! header() }
+}
+`
+
+const migratedTemplate = `package main
+
+templ page() {
+	@header()
+}
+`
+
+func TestRun(t *testing.T) {
+	log := slog.New(slog.NewJSONHandler(io.Discard, nil))
+	t.Run("dry run prints a diff without changing files", func(t *testing.T) {
+		fileName := writeTemplate(t, legacyTemplate)
+		stdout := new(strings.Builder)
+		if err := Run(log, stdout, Arguments{Path: filepath.Dir(fileName), DryRun: true}); err != nil {
+			t.Fatalf("failed to run migrate command: %v", err)
+		}
+		if !strings.Contains(stdout.String(), "-\t{! header() }\n+\t@header()\n") {
+			t.Errorf("expected a diff, got:\n%s", stdout.String())
+		}
+		if diff := cmp.Diff(legacyTemplate, readTemplate(t, fileName)); diff != "" {
+			t.Error(diff)
+		}
+	})
+	t.Run("files are migrated in place", func(t *testing.T) {
+		fileName := writeTemplate(t, legacyTemplate)
+		if err := Run(log, io.Discard, Arguments{Path: filepath.Dir(fileName)}); err != nil {
+			t.Fatalf("failed to run migrate command: %v", err)
+		}
+		if diff := cmp.Diff(migratedTemplate, readTemplate(t, fileName)); diff != "" {
+			t.Error(diff)
+		}
+		stdout := new(strings.Builder)
+		if err := Run(log, stdout, Arguments{Path: filepath.Dir(fileName), DryRun: true}); err != nil {
+			t.Fatalf("failed to run migrate command: %v", err)
+		}
+		if stdout.Len() > 0 {
+			t.Errorf("expected no changes when migrating again, got:\n%s", stdout.String())
+		}
+	})
+	t.Run("files that don't match a rule aren't formatted", func(t *testing.T) {
+		unformatted := "package main\n\ntempl page() {\n<div>{ \"a\" }</div>\n}\n"
+		fileName := writeTemplate(t, unformatted)
+		if err := Run(log, io.Discard, Arguments{Path: fileName}); err != nil {
+			t.Fatalf("failed to run migrate command: %v", err)
+		}
+		if diff := cmp.Diff(unformatted, readTemplate(t, fileName)); diff != "" {
+			t.Error(diff)
+		}
+	})
+	t.Run("invalid rules are reported", func(t *testing.T) {
+		err := Run(log, io.Discard, Arguments{Path: t.TempDir(), Rules: []Rule{{Name: "invalid", Pattern: "@a(", Replacement: "@b()"}}})
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+}
+
+func writeTemplate(t *testing.T, contents string) string {
+	t.Helper()
+	fileName := filepath.Join(t.TempDir(), "page.templ")
+	if err := os.WriteFile(fileName, []byte(contents), 0660); err != nil {
+		t.Fatalf("failed to write template: %v", err)
+	}
+	return fileName
+}
+
+func readTemplate(t *testing.T, fileName string) string {
+	t.Helper()
+	b, err := os.ReadFile(fileName)
+	if err != nil {
+		t.Fatalf("failed to read template: %v", err)
+	}
+	return string(b)
+}
@@ -0,0 +1,236 @@
+package migratecmd
+
+import (
+	"go/ast"
+	goparser "go/parser"
+	"go/token"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// matcher matches a pattern against Go code, and records the code matched by
+// each metavariable.
+type matcher struct {
+	src      string
+	file     *token.File
+	bindings map[string]string
+}
+
+func newMatcher(src string, file *token.File) *matcher {
+	return &matcher{src: src, file: file, bindings: map[string]string{}}
+}
+
+var (
+	posType          = reflect.TypeOf(token.NoPos)
+	objectType       = reflect.TypeOf((*ast.Object)(nil))
+	scopeType        = reflect.TypeOf((*ast.Scope)(nil))
+	commentGroupType = reflect.TypeOf((*ast.CommentGroup)(nil))
+)
+
+// match returns true if the node matches the pattern. Positions, comments and
+// the formatting of the code are ignored, except whether a call is variadic.
+func (m *matcher) match(pattern, node ast.Node) bool {
+	return m.matchValue(reflect.ValueOf(pattern), reflect.ValueOf(node))
+}
+
+func (m *matcher) matchValue(p, n reflect.Value) bool {
+	if p.Kind() == reflect.Interface {
+		if p.IsNil() || n.IsNil() {
+			return p.IsNil() && n.IsNil()
+		}
+		p, n = p.Elem(), n.Elem()
+	}
+	if name, ok := metavariableName(p); ok {
+		e, isExpr := n.Interface().(ast.Expr)
+		return isExpr && !n.IsNil() && m.bind(name, m.text(e.Pos(), e.End()))
+	}
+	if p.Type() != n.Type() {
+		return false
+	}
+	switch p.Kind() {
+	case reflect.Pointer:
+		if p.IsNil() || n.IsNil() {
+			return p.IsNil() && n.IsNil()
+		}
+		if call, ok := p.Interface().(*ast.CallExpr); ok && isVariadicPattern(call) {
+			return m.matchVariadic(call, n.Interface().(*ast.CallExpr))
+		}
+		return m.matchValue(p.Elem(), n.Elem())
+	case reflect.Struct:
+		for i := 0; i < p.NumField(); i++ {
+			switch p.Type().Field(i).Type {
+			case posType:
+				// The position of the "..." of a call is only valid if the
+				// call is variadic, which changes the meaning of the code.
+				if p.Type().Field(i).Name == "Ellipsis" && p.Field(i).Interface().(token.Pos).IsValid() != n.Field(i).Interface().(token.Pos).IsValid() {
+					return false
+				}
+				continue
+			case objectType, scopeType, commentGroupType:
+				continue
+			}
+			if !m.matchValue(p.Field(i), n.Field(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Slice:
+		if p.Len() != n.Len() {
+			return false
+		}
+		for i := 0; i < p.Len(); i++ {
+			if !m.matchValue(p.Index(i), n.Index(i)) {
+				return false
+			}
+		}
+		return true
+	}
+	return p.Interface() == n.Interface()
+}
+
+// matchVariadic matches a call whose last argument is a metavariable followed
+// by "...", which matches any remaining arguments.
+func (m *matcher) matchVariadic(p, n *ast.CallExpr) bool {
+	fixed := len(p.Args) - 1
+	if len(n.Args) < fixed || !m.match(p.Fun, n.Fun) {
+		return false
+	}
+	for i := 0; i < fixed; i++ {
+		if !m.match(p.Args[i], n.Args[i]) {
+			return false
+		}
+	}
+	var rest string
+	if len(n.Args) > fixed {
+		rest = strings.TrimRight(m.text(n.Args[fixed].Pos(), n.Rparen), ", \t\r\n")
+	}
+	name, _ := metavariableName(reflect.ValueOf(p.Args[fixed]))
+	return m.bind(name, rest)
+}
+
+func isVariadicPattern(call *ast.CallExpr) bool {
+	if !call.Ellipsis.IsValid() || len(call.Args) == 0 {
+		return false
+	}
+	_, ok := metavariableName(reflect.ValueOf(call.Args[len(call.Args)-1]))
+	return ok
+}
+
+func metavariableName(v reflect.Value) (name string, ok bool) {
+	if v.Kind() == reflect.Interface && !v.IsNil() {
+		v = v.Elem()
+	}
+	if !v.IsValid() || !v.CanInterface() {
+		return "", false
+	}
+	id, isIdent := v.Interface().(*ast.Ident)
+	if !isIdent || id == nil {
+		return "", false
+	}
+	return strings.CutPrefix(id.Name, metavariablePrefix)
+}
+
+// bind records the code matched by a metavariable. A metavariable that's used
+// more than once must match the same code each time.
+func (m *matcher) bind(name, text string) bool {
+	if existing, ok := m.bindings[name]; ok {
+		return existing == text
+	}
+	m.bindings[name] = text
+	return true
+}
+
+func (m *matcher) text(from, to token.Pos) string {
+	if m.file == nil {
+		return ""
+	}
+	return m.src[m.file.Offset(from):m.file.Offset(to)]
+}
+
+// goContext is the Go code that surrounds a templ expression, so that it can be
+// parsed as a Go file.
+type goContext struct {
+	prefix, suffix string
+}
+
+var (
+	expressionContext = goContext{"package p\nvar _ = ", "\n"}
+	ifContext         = goContext{"package p\nfunc _() {\nif ", " {}\n}\n"}
+	forContext        = goContext{"package p\nfunc _() {\nfor ", " {}\n}\n"}
+	switchContext     = goContext{"package p\nfunc _() {\nswitch ", " {}\n}\n"}
+	caseContext       = goContext{"package p\nfunc _() {\nswitch {\n", "\n}\n}\n"}
+	statementContext  = goContext{"package p\nfunc _() {\n", "\n}\n"}
+	templateContext   = goContext{"package p\nfunc ", " {}\n"}
+	fileContext       = goContext{"package p\n", "\n"}
+)
+
+type edit struct {
+	start, end int
+	text       string
+}
+
+// rewriteGo applies the Go expression rules to the code. Matched expressions
+// are replaced, and the code within them isn't rewritten again. Code that
+// can't be parsed is returned unchanged.
+func rewriteGo(rules []compiledRule, code string, ctx goContext) (string, bool) {
+	if len(rules) == 0 || strings.TrimSpace(code) == "" {
+		return code, false
+	}
+	src := ctx.prefix + code + ctx.suffix
+	fset := token.NewFileSet()
+	f, err := goparser.ParseFile(fset, "", src, goparser.SkipObjectResolution)
+	if err != nil {
+		return code, false
+	}
+	file := fset.File(f.Pos())
+	var edits []edit
+	ast.Inspect(f, func(n ast.Node) bool {
+		e, isExpr := n.(ast.Expr)
+		if !isExpr {
+			return true
+		}
+		for _, r := range rules {
+			m := newMatcher(src, file)
+			if !m.match(r.pattern, e) {
+				continue
+			}
+			start, end := file.Offset(e.Pos())-len(ctx.prefix), file.Offset(e.End())-len(ctx.prefix)
+			if start < 0 || end > len(code) {
+				continue
+			}
+			edits = append(edits, edit{start: start, end: end, text: r.expand(m.bindings)})
+			return false
+		}
+		return true
+	})
+	if len(edits) == 0 {
+		return code, false
+	}
+	slices.SortFunc(edits, func(a, b edit) int { return a.start - b.start })
+	var sb strings.Builder
+	var last int
+	for _, e := range edits {
+		sb.WriteString(code[last:e.start])
+		sb.WriteString(e.text)
+		last = e.end
+	}
+	sb.WriteString(code[last:])
+	return sb.String(), true
+}
+
+// matchNode matches a node rule against the whole expression of a templ node.
+func matchNode(r compiledRule, code string) (replacement string, ok bool) {
+	src := expressionContext.prefix + code + expressionContext.suffix
+	fset := token.NewFileSet()
+	f, err := goparser.ParseFile(fset, "", src, goparser.SkipObjectResolution)
+	if err != nil {
+		return "", false
+	}
+	e := f.Decls[0].(*ast.GenDecl).Specs[0].(*ast.ValueSpec).Values[0]
+	m := newMatcher(src, fset.File(f.Pos()))
+	if !m.match(r.pattern, e) {
+		return "", false
+	}
+	return r.expand(m.bindings), true
+}
@@ -0,0 +1,147 @@
+package migratecmd
+
+import (
+	"errors"
+	"fmt"
+
+	parser "github.com/a-h/templ/parser/v2"
+)
+
+// Migration applies a list of rules to template files.
+type Migration struct {
+	expressionRules []compiledRule
+	nodeRules       []compiledRule
+}
+
+// NewMigration compiles the rules. Node rules are applied before expression
+// rules, so that the expression of a rewritten node can be rewritten too.
+func NewMigration(rules []Rule) (m *Migration, err error) {
+	m = &Migration{}
+	for _, r := range rules {
+		cr, compileErr := compile(r)
+		if compileErr != nil {
+			err = errors.Join(err, compileErr)
+			continue
+		}
+		if cr.kind == goExpression {
+			m.expressionRules = append(m.expressionRules, cr)
+			continue
+		}
+		m.nodeRules = append(m.nodeRules, cr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid rules: %w", err)
+	}
+	return m, nil
+}
+
+// Apply returns a copy of the template file with the rules applied, and
+// whether any rule matched.
+func (m *Migration) Apply(tf parser.TemplateFile) (result parser.TemplateFile, changed bool) {
+	rewrite := func(e *parser.Expression, ctx goContext) {
+		var ok bool
+		if e.Value, ok = rewriteGo(m.expressionRules, e.Value, ctx); ok {
+			changed = true
+		}
+	}
+	result = parser.Apply(tf, nil, func(c *parser.Cursor) bool {
+		if n, ok := m.applyNodeRules(c.Node()); ok {
+			c.Replace(n)
+			changed = true
+		}
+		switch n := c.Node().(type) {
+		case parser.TemplateFileGoExpression:
+			// The header only contains comments.
+			if c.Name() == "Header" {
+				return true
+			}
+			rewrite(&n.Expression, fileContext)
+			c.Replace(n)
+		case parser.HTMLTemplate:
+			rewrite(&n.Expression, templateContext)
+			c.Replace(n)
+		case parser.StringExpression:
+			rewrite(&n.Expression, expressionContext)
+			c.Replace(n)
+		case parser.TemplElementExpression:
+			rewrite(&n.Expression, expressionContext)
+			c.Replace(n)
+		case parser.CallTemplateExpression:
+			rewrite(&n.Expression, expressionContext)
+			c.Replace(n)
+		case parser.ExpressionAttribute:
+			rewrite(&n.Expression, expressionContext)
+			c.Replace(n)
+		case parser.BoolExpressionAttribute:
+			rewrite(&n.Expression, expressionContext)
+			c.Replace(n)
+		case parser.SpreadAttributes:
+			rewrite(&n.Expression, expressionContext)
+			c.Replace(n)
+		case parser.ConditionalAttribute:
+			rewrite(&n.Expression, ifContext)
+			c.Replace(n)
+		case parser.IfExpression:
+			rewrite(&n.Expression, ifContext)
+			c.Replace(n)
+		case parser.ElseIfExpression:
+			rewrite(&n.Expression, ifContext)
+			c.Replace(n)
+		case parser.ForExpression:
+			rewrite(&n.Expression, forContext)
+			c.Replace(n)
+		case parser.SwitchExpression:
+			rewrite(&n.Expression, switchContext)
+			c.Replace(n)
+		case parser.CaseExpression:
+			rewrite(&n.Expression, caseContext)
+			c.Replace(n)
+		case parser.GoCode:
+			rewrite(&n.Expression, statementContext)
+			c.Replace(n)
+		}
+		return true
+	}).(parser.TemplateFile)
+	return result, changed
+}
+
+// applyNodeRules returns the replacement of a templ node that matches a node
+// rule.
+func (m *Migration) applyNodeRules(node any) (replacement parser.Node, ok bool) {
+	var kind nodeKind
+	var expression parser.Expression
+	var children []parser.Node
+	switch n := node.(type) {
+	case parser.TemplElementExpression:
+		kind, expression, children = templElement, n.Expression, n.Children
+	case parser.CallTemplateExpression:
+		kind, expression = legacyCall, n.Expression
+	case parser.StringExpression:
+		kind, expression = stringExpression, n.Expression
+	default:
+		return nil, false
+	}
+	for _, r := range m.nodeRules {
+		// Children can only be kept by a templ element.
+		if r.kind != kind || (len(children) > 0 && r.replacementKind != templElement) {
+			continue
+		}
+		value, matched := matchNode(r, expression.Value)
+		if !matched {
+			continue
+		}
+		expression = parser.Expression{Value: value, Range: expression.Range}
+		switch r.replacementKind {
+		case templElement:
+			return parser.TemplElementExpression{Expression: expression, Children: children}, true
+		case legacyCall:
+			return parser.CallTemplateExpression{Expression: expression}, true
+		}
+		trailing := parser.SpaceVertical
+		if se, isStringExpression := node.(parser.StringExpression); isStringExpression {
+			trailing = se.TrailingSpace
+		}
+		return parser.StringExpression{Expression: expression, TrailingSpace: trailing}, true
+	}
+	return nil, false
+}
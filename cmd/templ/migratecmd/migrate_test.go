@@ -0,0 +1,199 @@
+package migratecmd
+
+import (
+	"strings"
+	"testing"
+
+	parser "github.com/a-h/templ/parser/v2"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMigration(t *testing.T) {
+	tests := []struct {
+		name     string
+		rules    []Rule
+		input    string
+		expected string
+	}{
+		{
+			name:  "legacy calls are rewritten by the built-in rules",
+			rules: Builtin[0].Rules,
+			input: `package main
+
+templ page() {
+	{! header("title") }
+}
+`,
+			expected: `package main
+
+templ page() {
+	@header("title")
+}
+`,
+		},
+		{
+			name:  "components can be renamed",
+			rules: []Rule{{Name: "rename", Pattern: "@OldButton($text, $opts...)", Replacement: "@NewButton($text, $opts...)"}},
+			input: `package main
+
+templ page() {
+	@OldButton("a")
+	@OldButton("b", primary, large)
+	@OldButton("c", opts...)
+	@Other("d")
+}
+`,
+			expected: `package main
+
+templ page() {
+	@NewButton("a")
+	@NewButton("b", primary, large)
+	@NewButton("c", opts...)
+	@Other("d")
+}
+`,
+		},
+		{
+			name:  "parameters can be reordered",
+			rules: []Rule{{Name: "swap", Pattern: "card($title, $body)", Replacement: "Card($body, $title)"}},
+			input: `package main
+
+templ page(title string) {
+	@card(title, content()) {
+		<p>{ title }</p>
+	}
+}
+`,
+			expected: `package main
+
+templ page(title string) {
+	@Card(content(), title) {
+		<p>{ title }</p>
+	}
+}
+`,
+		},
+		{
+			name:  "expressions are rewritten in attributes, statements and Go code",
+			rules: []Rule{{Name: "classes", Pattern: "templ.Classes($c...)", Replacement: "templ.KV($c...)"}},
+			input: `package main
+
+var c = templ.Classes("a")
+
+templ page(ok bool) {
+	<div class={ templ.Classes("b", ok) }></div>
+	if ok && templ.Classes("c") != nil {
+		<p></p>
+	}
+	for _, x := range templ.Classes("d") {
+		{ x }
+	}
+}
+`,
+			expected: `package main
+
+var c = templ.KV("a")
+
+templ page(ok bool) {
+	<div class={ templ.KV("b", ok) }></div>
+	if ok && templ.KV("c") != nil {
+		<p></p>
+	}
+	for _, x := range templ.KV("d") {
+		{ x }
+	}
+}
+`,
+		},
+		{
+			name:  "string expressions can be replaced with templ elements",
+			rules: []Rule{{Name: "script", Pattern: "{ renderScript($s) }", Replacement: "@templ.JSFuncCall($s)"}},
+			input: `package main
+
+templ page() {
+	{ renderScript("init") }
+}
+`,
+			expected: `package main
+
+templ page() {
+	@templ.JSFuncCall("init")
+}
+`,
+		},
+		{
+			name:  "repeated metavariables must match the same code",
+			rules: []Rule{{Name: "same", Pattern: "max($a, $a)", Replacement: "$a"}},
+			input: `package main
+
+templ page(a, b int) {
+	{ fmt.Sprint(max(a, a)) }
+	{ fmt.Sprint(max(a, b)) }
+}
+`,
+			expected: `package main
+
+templ page(a, b int) {
+	{ fmt.Sprint(a) }
+	{ fmt.Sprint(max(a, b)) }
+}
+`,
+		},
+		{
+			name:  "calls only match variadic patterns if they're variadic",
+			rules: []Rule{{Name: "rename", Pattern: "f($a)", Replacement: "g($a)"}},
+			input: `package main
+
+templ page(x string, xs []string) {
+	{ f(x) }
+	{ f(xs...) }
+}
+`,
+			expected: `package main
+
+templ page(x string, xs []string) {
+	{ g(x) }
+	{ f(xs...) }
+}
+`,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := NewMigration(tt.rules)
+			if err != nil {
+				t.Fatalf("failed to compile rules: %v", err)
+			}
+			actual := apply(t, m, tt.input)
+			if diff := cmp.Diff(tt.expected, actual); diff != "" {
+				t.Error(diff)
+			}
+			// Applying the rules again makes no changes.
+			tf, err := parser.ParseString(actual)
+			if err != nil {
+				t.Fatalf("failed to parse migrated template: %v", err)
+			}
+			if _, changed := m.Apply(tf); changed {
+				t.Error("expected no changes when the rules are applied again")
+			}
+		})
+	}
+}
+
+func apply(t *testing.T, m *Migration, input string) string {
+	t.Helper()
+	tf, err := parser.ParseString(input)
+	if err != nil {
+		t.Fatalf("failed to parse template: %v", err)
+	}
+	tf, changed := m.Apply(tf)
+	if !changed {
+		t.Fatal("expected the rules to change the template")
+	}
+	w := new(strings.Builder)
+	if err = tf.Write(w); err != nil {
+		t.Fatalf("failed to write template: %v", err)
+	}
+	return w.String()
+}
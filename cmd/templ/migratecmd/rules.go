@@ -0,0 +1,182 @@
+package migratecmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	goparser "go/parser"
+	"io"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Rule rewrites templ code that matches a pattern.
+//
+// Patterns are Go expressions, where $name is a metavariable that matches any
+// expression, and $name... matches the remaining arguments of a function call.
+// The replacement uses the metavariables of the pattern, e.g.
+//
+//	pattern: components.Button($text, $opts...)
+//	replacement: components.NewButton($text, $opts...)
+//
+// A Go expression pattern matches expressions anywhere in the Go code of a
+// template file. To match a templ node instead, the pattern can be written as
+// a templ element `@expr`, a string expression `{ expr }`, or a legacy call
+// `{! expr }`. Node patterns can be replaced with a different kind of node,
+// e.g. `{! $c }` with `@$c`.
+type Rule struct {
+	Name        string `yaml:"name"`
+	Pattern     string `yaml:"pattern"`
+	Replacement string `yaml:"replacement"`
+}
+
+// RuleSet is a named list of rules.
+type RuleSet struct {
+	// Name of the rule set. Built-in rule sets are named after the templ
+	// version that they migrate to.
+	Name  string
+	Rules []Rule
+}
+
+// Builtin rule sets, in version order. There are no rules for templ.Classes
+// or script templates, because templ doesn't have an API that replaces them.
+var Builtin = []RuleSet{
+	{
+		Name: "v0.2",
+		Rules: []Rule{
+			{Name: "legacy-call-syntax", Pattern: "{! $component }", Replacement: "@$component"},
+		},
+	},
+}
+
+// ParseRule parses a rule written as "pattern => replacement".
+func ParseRule(s string) (r Rule, err error) {
+	pattern, replacement, ok := strings.Cut(s, "=>")
+	if !ok {
+		return r, fmt.Errorf("invalid rule %q, expected \"pattern => replacement\"", s)
+	}
+	r = Rule{
+		Name:        s,
+		Pattern:     strings.TrimSpace(pattern),
+		Replacement: strings.TrimSpace(replacement),
+	}
+	_, err = compile(r)
+	return r, err
+}
+
+// LoadRules reads a YAML file of rules.
+//
+//	rules:
+//	  - name: rename-button
+//	    pattern: "@OldButton($args...)"
+//	    replacement: "@NewButton($args...)"
+func LoadRules(r io.Reader) (rules []Rule, err error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rules: %w", err)
+	}
+	var f struct {
+		Rules []Rule `yaml:"rules"`
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err = dec.Decode(&f); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse rules: %w", err)
+	}
+	for i, r := range f.Rules {
+		if _, compileErr := compile(r); compileErr != nil {
+			err = errors.Join(err, fmt.Errorf("rules[%d]: %w", i, compileErr))
+		}
+	}
+	return f.Rules, err
+}
+
+// nodeKind is the kind of templ node that a pattern matches.
+type nodeKind int
+
+const (
+	goExpression nodeKind = iota
+	templElement
+	stringExpression
+	legacyCall
+)
+
+// splitKind splits a pattern into the kind of node it matches, and its Go
+// expression.
+func splitKind(s string) (kind nodeKind, expr string) {
+	s = strings.TrimSpace(s)
+	switch {
+	case strings.HasPrefix(s, "@"):
+		return templElement, strings.TrimSpace(s[1:])
+	case strings.HasPrefix(s, "{!") && strings.HasSuffix(s, "}"):
+		return legacyCall, strings.TrimSpace(s[2 : len(s)-1])
+	case strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}"):
+		return stringExpression, strings.TrimSpace(s[1 : len(s)-1])
+	}
+	return goExpression, s
+}
+
+// metavariablePrefix replaces the $ of metavariables, so that patterns can be
+// parsed as Go.
+const metavariablePrefix = "__templ_migrate_"
+
+var metavariable = regexp.MustCompile(`(,\s*)?\$([A-Za-z_][A-Za-z0-9_]*)(\.\.\.)?`)
+
+type compiledRule struct {
+	Rule
+	kind, replacementKind nodeKind
+	pattern               ast.Expr
+	// replacement is the Go expression of the replacement, with metavariables.
+	replacement string
+}
+
+func compile(r Rule) (cr compiledRule, err error) {
+	cr.Rule = r
+	var pattern string
+	cr.kind, pattern = splitKind(r.Pattern)
+	cr.replacementKind, cr.replacement = splitKind(r.Replacement)
+	if pattern == "" {
+		return cr, fmt.Errorf("%s: pattern is required", r.Name)
+	}
+	if (cr.kind == goExpression) != (cr.replacementKind == goExpression) {
+		return cr, fmt.Errorf("%s: Go expressions can only be replaced with Go expressions, and templ nodes with templ nodes", r.Name)
+	}
+	if cr.pattern, err = goparser.ParseExpr(metavariable.ReplaceAllString(pattern, "${1}"+metavariablePrefix+"${2}${3}")); err != nil {
+		return cr, fmt.Errorf("%s: invalid pattern %q: %w", r.Name, r.Pattern, err)
+	}
+	replacement, err := goparser.ParseExpr(metavariable.ReplaceAllString(cr.replacement, "${1}"+metavariablePrefix+"${2}${3}"))
+	if err != nil {
+		return cr, fmt.Errorf("%s: invalid replacement %q: %w", r.Name, r.Replacement, err)
+	}
+	bound := map[string]bool{}
+	for _, m := range metavariable.FindAllStringSubmatch(pattern, -1) {
+		bound[m[2]] = true
+	}
+	for _, m := range metavariable.FindAllStringSubmatch(cr.replacement, -1) {
+		if !bound[m[2]] {
+			return cr, fmt.Errorf("%s: $%s is used in the replacement, but not the pattern", r.Name, m[2])
+		}
+	}
+	// Applying the rule again must not change the code.
+	if cr.kind == cr.replacementKind && newMatcher("", nil).match(cr.pattern, replacement) {
+		return cr, fmt.Errorf("%s: the replacement matches the pattern, so the rule would never finish being applied", r.Name)
+	}
+	return cr, nil
+}
+
+// expand returns the replacement, with the metavariables replaced by the code
+// that they matched.
+func (cr compiledRule) expand(bindings map[string]string) string {
+	return metavariable.ReplaceAllStringFunc(cr.replacement, func(s string) string {
+		m := metavariable.FindStringSubmatch(s)
+		value := bindings[m[2]]
+		if value == "" && m[3] != "" {
+			// Drop the separator of empty variadic arguments.
+			return ""
+		}
+		return m[1] + value
+	})
+}
@@ -0,0 +1,69 @@
+package migratecmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseRule(t *testing.T) {
+	r, err := ParseRule("@Old($x) => @New($x)")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := Rule{Name: "@Old($x) => @New($x)", Pattern: "@Old($x)", Replacement: "@New($x)"}
+	if diff := cmp.Diff(expected, r); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestParseRuleErrors(t *testing.T) {
+	tests := []struct {
+		rule     string
+		expected string
+	}{
+		{rule: "@Old($x)", expected: "expected \"pattern => replacement\""},
+		{rule: "@Old( => @New()", expected: "invalid pattern"},
+		{rule: "@Old() => @New(", expected: "invalid replacement"},
+		{rule: "@Old() => @New($x)", expected: "$x is used in the replacement, but not the pattern"},
+		{rule: "old($x) => @New($x)", expected: "Go expressions can only be replaced with Go expressions"},
+		{rule: "f($x) => f(f($x))", expected: "the replacement matches the pattern"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.rule, func(t *testing.T) {
+			_, err := ParseRule(tt.rule)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("expected error containing %q, got %q", tt.expected, err.Error())
+			}
+		})
+	}
+}
+
+func TestLoadRules(t *testing.T) {
+	rules, err := LoadRules(strings.NewReader(`rules:
+  - name: rename-button
+    pattern: "@OldButton($args...)"
+    replacement: "@NewButton($args...)"
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []Rule{{Name: "rename-button", Pattern: "@OldButton($args...)", Replacement: "@NewButton($args...)"}}
+	if diff := cmp.Diff(expected, rules); diff != "" {
+		t.Error(diff)
+	}
+
+	_, err = LoadRules(strings.NewReader(`rules:
+  - name: invalid
+    pattern: "@Old("
+    replacement: "@New()"
+`))
+	if err == nil || !strings.Contains(err.Error(), "rules[0]: invalid: invalid pattern") {
+		t.Errorf("expected an invalid pattern error, got %v", err)
+	}
+}
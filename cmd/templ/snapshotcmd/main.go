@@ -0,0 +1,203 @@
+package snapshotcmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/a-h/htmlformat"
+	"github.com/a-h/templ/cmd/templ/generatecmd/run"
+	"github.com/a-h/templ/generator/htmldiff"
+	"github.com/a-h/templ/storybook"
+	"github.com/natefinch/atomic"
+)
+
+type Arguments struct {
+	// URL of the storybook server, e.g. http://localhost:60606.
+	URL string
+	// Cmd starts the storybook server, e.g. "go run ./storybook". If empty,
+	// the server must already be running.
+	Cmd string
+	// Dir is where the snapshots are stored.
+	Dir string
+	// Update writes the snapshots of changed stories, and removes the
+	// snapshots of stories that no longer exist.
+	Update bool
+}
+
+// ErrChanged is returned when stories don't match their snapshots.
+var ErrChanged = errors.New("snapshots have changed, run with -update to update them")
+
+// Run renders every story of a storybook server, and compares the HTML with the
+// snapshots in the directory. Snapshots of new stories are written. The diffs
+// of changed stories are written to stdout.
+func Run(ctx context.Context, log *slog.Logger, stdout io.Writer, args Arguments) (err error) {
+	start := time.Now()
+	baseURL := strings.TrimSuffix(args.URL, "/")
+	if args.Cmd != "" {
+		s := run.NewSupervisor(log, ".", run.Process{
+			Name:  "storybook",
+			Run:   args.Cmd,
+			Ready: baseURL + "/storybook_stories",
+		})
+		s.Stdout = os.Stderr
+		defer func() {
+			err = errors.Join(err, s.Stop())
+		}()
+		if err = s.Restart(ctx); err != nil {
+			return fmt.Errorf("failed to start storybook: %w", err)
+		}
+	}
+
+	var stories []storybook.StoryPreview
+	if err = getJSON(ctx, baseURL+"/storybook_stories", &stories); err != nil {
+		return fmt.Errorf("failed to list stories: %w", err)
+	}
+	// The story URLs are absolute paths that include the route prefix of the
+	// storybook, so only the scheme and host of the URL are used.
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return fmt.Errorf("invalid storybook URL: %w", err)
+	}
+
+	var added, changed, removed int
+	// seen maps the snapshot file names to the stories they're used by.
+	seen := map[string]string{}
+	for _, story := range stories {
+		fileName := filepath.Join(args.Dir, fileNameOf(story.Component), fileNameOf(story.Story)+".html")
+		name := story.Component + "/" + story.Story
+		if other, ok := seen[fileName]; ok {
+			return fmt.Errorf("%s: the snapshot file %s is also used by %s, rename one of the stories", name, fileName, other)
+		}
+		seen[fileName] = name
+		storyURL, err := url.Parse(story.URL)
+		if err != nil {
+			return fmt.Errorf("%s/%s: invalid story URL: %w", story.Component, story.Story, err)
+		}
+		actual, err := render(ctx, base.ResolveReference(storyURL).String())
+		if err != nil {
+			return fmt.Errorf("%s/%s: %w", story.Component, story.Story, err)
+		}
+		expected, err := os.ReadFile(fileName)
+		if errors.Is(err, os.ErrNotExist) {
+			log.Info("Added snapshot", slog.String("component", story.Component), slog.String("story", story.Story))
+			added++
+			if err = writeSnapshot(fileName, actual); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read snapshot: %w", err)
+		}
+		diff, err := htmldiff.DiffStrings(string(expected), actual)
+		if err != nil {
+			return fmt.Errorf("%s/%s: %w", story.Component, story.Story, err)
+		}
+		if diff == "" {
+			continue
+		}
+		changed++
+		if args.Update {
+			log.Info("Updated snapshot", slog.String("component", story.Component), slog.String("story", story.Story))
+			if err = writeSnapshot(fileName, actual); err != nil {
+				return err
+			}
+			continue
+		}
+		fmt.Fprintf(stdout, "%s/%s has changed (-snapshot +render):\n%s\n", story.Component, story.Story, diff)
+	}
+
+	// Report snapshots of stories that don't exist any more.
+	err = filepath.WalkDir(args.Dir, func(fileName string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(fileName) != ".html" || seen[fileName] != "" {
+			return err
+		}
+		removed++
+		if !args.Update {
+			fmt.Fprintf(stdout, "%s has no story\n", fileName)
+			return nil
+		}
+		log.Info("Removed snapshot", slog.String("file", fileName))
+		return os.Remove(fileName)
+	})
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to find snapshots: %w", err)
+	}
+
+	log.Info("Snapshot complete", slog.Int("stories", len(stories)), slog.Int("added", added), slog.Int("changed", changed), slog.Int("removed", removed), slog.Duration("duration", time.Since(start)))
+	if !args.Update && (changed > 0 || removed > 0) {
+		return ErrChanged
+	}
+	return nil
+}
+
+// render returns the formatted HTML of the story.
+func render(ctx context.Context, storyURL string) (html string, err error) {
+	body, err := get(ctx, storyURL)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+	var sb strings.Builder
+	if err = htmlformat.Fragment(&sb, body); err != nil {
+		return "", fmt.Errorf("failed to format HTML: %w", err)
+	}
+	return sb.String(), nil
+}
+
+func getJSON(ctx context.Context, u string, v any) (err error) {
+	body, err := get(ctx, u)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	return json.NewDecoder(body).Decode(v)
+}
+
+func get(ctx context.Context, u string) (body io.ReadCloser, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s: unexpected status %d: %s", u, resp.StatusCode, strings.TrimSpace(string(b)))
+	}
+	return resp.Body, nil
+}
+
+func writeSnapshot(fileName, html string) error {
+	if err := os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+		return fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+	if err := atomic.WriteFile(fileName, bytes.NewBufferString(html)); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return nil
+}
+
+var unsafeFileNameChars = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// fileNameOf returns a file name for a component or story name. Different
+// names can have the same file name, e.g. "Button A" and "Button_A".
+func fileNameOf(name string) string {
+	return strings.Trim(unsafeFileNameChars.ReplaceAllString(name, "_"), "._")
+}
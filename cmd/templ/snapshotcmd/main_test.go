@@ -0,0 +1,143 @@
+package snapshotcmd
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/a-h/templ"
+	"github.com/a-h/templ/storybook"
+	"github.com/google/go-cmp/cmp"
+)
+
+func button(text string, primary bool) templ.Component {
+	return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		class := "btn"
+		if primary {
+			class += " btn-primary"
+		}
+		_, err := io.WriteString(w, `<button class="`+class+`">`+templ.EscapeString(text)+`</button>`)
+		return err
+	})
+}
+
+func newStorybook(constructor interface{}) *httptest.Server {
+	sb := storybook.New()
+	sb.AddComponent("Button", constructor, storybook.TextArg("text", "Save"), storybook.BooleanArg("primary", false))
+	sb.Config["Button"].AddStory("Primary", storybook.BooleanArg("primary", true))
+	return httptest.NewServer(sb)
+}
+
+func TestRun(t *testing.T) {
+	log := slog.New(slog.NewJSONHandler(io.Discard, nil))
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	// The first run writes the snapshots.
+	server := newStorybook(button)
+	defer server.Close()
+	stdout := new(strings.Builder)
+	if err := Run(ctx, log, stdout, Arguments{URL: server.URL, Dir: dir}); err != nil {
+		t.Fatalf("failed to run snapshot command: %v", err)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "Button", "Primary.html"))
+	if err != nil {
+		t.Fatalf("failed to read snapshot: %v", err)
+	}
+	if diff := cmp.Diff("<button class=\"btn btn-primary\">\n Save\n</button>\n", string(b)); diff != "" {
+		t.Error(diff)
+	}
+
+	// Unchanged stories pass.
+	if err = Run(ctx, log, stdout, Arguments{URL: server.URL, Dir: dir}); err != nil {
+		t.Fatalf("expected unchanged snapshots to pass: %v", err)
+	}
+	if stdout.Len() > 0 {
+		t.Errorf("expected no output, got:\n%s", stdout.String())
+	}
+
+	// Changed stories are reported.
+	changedServer := newStorybook(func(text string, primary bool) templ.Component {
+		return button(strings.ToUpper(text), primary)
+	})
+	defer changedServer.Close()
+	err = Run(ctx, log, stdout, Arguments{URL: changedServer.URL, Dir: dir})
+	if !errors.Is(err, ErrChanged) {
+		t.Fatalf("expected ErrChanged, got %v", err)
+	}
+	for _, expected := range []string{"Button/Default has changed", "Button/Primary has changed", "SAVE"} {
+		if !strings.Contains(stdout.String(), expected) {
+			t.Errorf("expected output to contain %q, got:\n%s", expected, stdout.String())
+		}
+	}
+
+	// Snapshots without a story are reported.
+	orphan := filepath.Join(dir, "Removed", "Default.html")
+	if err = os.MkdirAll(filepath.Dir(orphan), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(orphan, []byte("<p></p>\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stdout.Reset()
+	err = Run(ctx, log, stdout, Arguments{URL: server.URL, Dir: dir})
+	if !errors.Is(err, ErrChanged) || !strings.Contains(stdout.String(), "Default.html has no story") {
+		t.Fatalf("expected the removed story to be reported, got %v:\n%s", err, stdout.String())
+	}
+
+	// Update rewrites changed snapshots, and removes orphaned ones.
+	if err = Run(ctx, log, io.Discard, Arguments{URL: changedServer.URL, Dir: dir, Update: true}); err != nil {
+		t.Fatalf("failed to update snapshots: %v", err)
+	}
+	if _, err = os.Stat(orphan); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected orphaned snapshot to be removed, got %v", err)
+	}
+	stdout.Reset()
+	if err = Run(ctx, log, stdout, Arguments{URL: changedServer.URL, Dir: dir}); err != nil {
+		t.Fatalf("expected updated snapshots to pass: %v\n%s", err, stdout.String())
+	}
+}
+
+func TestRunWithRoutePrefix(t *testing.T) {
+	log := slog.New(slog.NewJSONHandler(io.Discard, nil))
+	dir := t.TempDir()
+
+	sb := storybook.New()
+	sb.RoutePrefix = "/components"
+	sb.AddComponent("Button", button, storybook.TextArg("text", "Save"), storybook.BooleanArg("primary", false))
+	server := httptest.NewServer(sb)
+	defer server.Close()
+
+	if err := Run(context.Background(), log, io.Discard, Arguments{URL: server.URL + "/components/", Dir: dir}); err != nil {
+		t.Fatalf("failed to run snapshot command: %v", err)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "Button", "Default.html"))
+	if err != nil {
+		t.Fatalf("failed to read snapshot: %v", err)
+	}
+	if diff := cmp.Diff("<button class=\"btn\">\n Save\n</button>\n", string(b)); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestRunWithCollidingStoryNames(t *testing.T) {
+	log := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	sb := storybook.New()
+	sb.AddComponent("Button", button, storybook.TextArg("text", "Save"), storybook.BooleanArg("primary", false))
+	sb.Config["Button"].AddStory("Button A", storybook.BooleanArg("primary", true))
+	sb.Config["Button"].AddStory("Button_A", storybook.BooleanArg("primary", false))
+	server := httptest.NewServer(sb)
+	defer server.Close()
+
+	err := Run(context.Background(), log, io.Discard, Arguments{URL: server.URL, Dir: t.TempDir()})
+	if err == nil || !strings.Contains(err.Error(), "is also used by Button/Button A") {
+		t.Fatalf("expected an error for the stories with the same file name, got %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package textdiff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	lines := func(from, to int) (s string) {
+		var sb strings.Builder
+		for i := from; i <= to; i++ {
+			sb.WriteString(strings.Repeat("x", i) + "\n")
+		}
+		return sb.String()
+	}
+	tests := []struct {
+		name     string
+		a, b     string
+		expected string
+	}{
+		{
+			name:     "equal files have no diff",
+			a:        "a\nb\n",
+			b:        "a\nb\n",
+			expected: "",
+		},
+		{
+			name: "a changed line is shown with context",
+			a:    "1\n2\n3\n4\n5\n6\n7\n",
+			b:    "1\n2\n3\nfour\n5\n6\n7\n",
+			expected: `--- a
++++ b
+@@ -1,7 +1,7 @@
+ 1
+ 2
+ 3
+-4
++four
+ 5
+ 6
+ 7
+`,
+		},
+		{
+			name: "new files are diffed against an empty file",
+			a:    "",
+			b:    "package main\n",
+			expected: `--- a
++++ b
+@@ -0,0 +1 @@
++package main
+`,
+		},
+		{
+			name: "distant changes are shown in separate hunks",
+			a:    lines(1, 20),
+			b:    "changed\n" + lines(2, 19) + "changed\n",
+			expected: `--- a
++++ b
+@@ -1,4 +1,4 @@
+-x
++changed
+ xx
+ xxx
+ xxxx
+@@ -17,4 +17,4 @@
+ xxxxxxxxxxxxxxxxx
+ xxxxxxxxxxxxxxxxxx
+ xxxxxxxxxxxxxxxxxxx
+-xxxxxxxxxxxxxxxxxxxx
++changed
+`,
+		},
+		{
+			name: "inserted lines",
+			a:    "a\nc\n",
+			b:    "a\nb\nc\n",
+			expected: `--- a
++++ b
+@@ -1,2 +1,3 @@
+ a
++b
+ c
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := Unified("a", "b", tt.a, tt.b)
+			if diff := cmp.Diff(tt.expected, actual); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,324 @@
+package visualize
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/a-h/templ"
+	"github.com/a-h/templ/cmd/templ/generatecmd/sse"
+	"github.com/a-h/templ/parser/v2"
+)
+
+// File is a template, the Go code generated from it, and the source map
+// between them.
+type File struct {
+	// Name of the template, e.g. a file name or LSP document URI.
+	Name  string
+	Templ string
+	// Go is the generated code, before it's formatted, because the source map
+	// refers to the unformatted code.
+	Go        string
+	SourceMap *parser.SourceMap
+}
+
+// Source provides the files shown by the Viewer.
+type Source interface {
+	Names() []string
+	File(name string) (f File, ok bool)
+}
+
+// Store is a Source that keeps files in memory.
+type Store struct {
+	m     sync.Mutex
+	files map[string]File
+	// OnChange is called after a file is set or deleted.
+	OnChange func(name string)
+}
+
+func NewStore() *Store {
+	return &Store{
+		files: map[string]File{},
+	}
+}
+
+func (s *Store) Set(f File) {
+	s.m.Lock()
+	s.files[f.Name] = f
+	s.m.Unlock()
+	if s.OnChange != nil {
+		s.OnChange(f.Name)
+	}
+}
+
+func (s *Store) Delete(name string) {
+	s.m.Lock()
+	delete(s.files, name)
+	s.m.Unlock()
+	if s.OnChange != nil {
+		s.OnChange(name)
+	}
+}
+
+func (s *Store) Names() (names []string) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	for name := range s.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (s *Store) File(name string) (f File, ok bool) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	f, ok = s.files[name]
+	return f, ok
+}
+
+// Viewer is an interactive source map viewer. It shows a template and its
+// generated Go code side by side, highlights the mapped ranges of both when
+// one of them is hovered, and lists the expressions that aren't mapped. Open
+// pages are updated when Changed is called.
+//
+// The viewer uses relative URLs, so it can be served under any path that ends
+// with a slash, using http.StripPrefix.
+type Viewer struct {
+	Source Source
+	sse    *sse.Handler
+}
+
+func NewViewer(s Source) *Viewer {
+	return &Viewer{
+		Source: s,
+		sse:    sse.New(),
+	}
+}
+
+// Changed updates the pages that show the file.
+func (v *Viewer) Changed(name string) {
+	v.sse.Send("changed", name)
+}
+
+func (v *Viewer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch strings.TrimPrefix(r.URL.Path, "/") {
+	case "":
+	case "events":
+		v.sse.ServeHTTP(w, r)
+		return
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		templ.Handler(viewerIndex(v.Source.Names())).ServeHTTP(w, r)
+		return
+	}
+	f, ok := v.Source.File(name)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	templ.Handler(viewerPage(newView(f))).ServeHTTP(w, r)
+}
+
+// view is a file, split into spans of text for display.
+type view struct {
+	Name     string
+	Templ    []line
+	Go       []line
+	Unmapped []unmappedExpression
+}
+
+type line []span
+
+type span struct {
+	Text string
+	// Segment is the ID of the mapped range the text is part of, or -1.
+	Segment  int
+	Unmapped bool
+}
+
+// unmappedExpression is a Go expression in the template that has no position
+// in the generated code.
+type unmappedExpression struct {
+	Line, Col uint32
+	Value     string
+}
+
+// textRange is a range of bytes within a line.
+type textRange struct {
+	From, To int
+	Segment  int
+	Unmapped bool
+}
+
+func newView(f File) view {
+	templLines := strings.Split(f.Templ, "\n")
+	goLines := strings.Split(f.Go, "\n")
+	templRanges := map[int][]textRange{}
+	goRanges := map[int][]textRange{}
+	if f.SourceMap != nil {
+		for i, s := range segments(f.SourceMap) {
+			srcLine, tgtLine := lineOf(templLines, s.SrcLine), lineOf(goLines, s.TgtLine)
+			length := int(s.SrcTo - s.SrcFrom)
+			templRanges[int(s.SrcLine)] = append(templRanges[int(s.SrcLine)], textRange{From: int(s.SrcFrom), To: min(int(s.SrcTo), len(srcLine)), Segment: i})
+			goRanges[int(s.TgtLine)] = append(goRanges[int(s.TgtLine)], textRange{From: int(s.TgtFrom), To: min(int(s.TgtFrom)+length, len(tgtLine)), Segment: i})
+		}
+	}
+	v := view{
+		Name:     f.Name,
+		Unmapped: unmappedExpressions(f),
+	}
+	for _, e := range v.Unmapped {
+		l := lineOf(templLines, e.Line)
+		to := int(e.Col) + len(e.Value)
+		if i := strings.IndexByte(e.Value, '\n'); i >= 0 {
+			to = int(e.Col) + i
+		}
+		templRanges[int(e.Line)] = append(templRanges[int(e.Line)], textRange{From: int(e.Col), To: min(to, len(l)), Segment: -1, Unmapped: true})
+	}
+	v.Templ = split(templLines, templRanges)
+	v.Go = split(goLines, goRanges)
+	return v
+}
+
+func lineOf(lines []string, i uint32) string {
+	if int(i) < len(lines) {
+		return lines[i]
+	}
+	return ""
+}
+
+// split the lines into spans, using the ranges of each line. Overlapping ranges
+// are skipped.
+func split(lines []string, ranges map[int][]textRange) (result []line) {
+	result = make([]line, len(lines))
+	for i, text := range lines {
+		lr := ranges[i]
+		sort.SliceStable(lr, func(a, b int) bool { return lr[a].From < lr[b].From })
+		var pos int
+		for _, r := range lr {
+			if r.From < pos || r.From >= r.To || r.To > len(text) {
+				continue
+			}
+			if r.From > pos {
+				result[i] = append(result[i], span{Text: text[pos:r.From], Segment: -1})
+			}
+			result[i] = append(result[i], span{Text: text[r.From:r.To], Segment: r.Segment, Unmapped: r.Unmapped})
+			pos = r.To
+		}
+		if pos < len(text) {
+			result[i] = append(result[i], span{Text: text[pos:], Segment: -1})
+		}
+	}
+	return result
+}
+
+// segment is a range of a line in the template that's mapped to a range of a
+// line in the Go code. The columns are byte offsets. The source map includes
+// the position after the end of each expression, so SrcTo is exclusive.
+type segment struct {
+	SrcLine, SrcFrom, SrcTo uint32
+	TgtLine, TgtFrom        uint32
+}
+
+// segments groups the character mappings of the source map into ranges, in the
+// order of the template.
+func segments(sm *parser.SourceMap) (result []segment) {
+	srcLines := make([]uint32, 0, len(sm.SourceLinesToTarget))
+	for l := range sm.SourceLinesToTarget {
+		srcLines = append(srcLines, l)
+	}
+	sort.Slice(srcLines, func(i, j int) bool { return srcLines[i] < srcLines[j] })
+	for _, l := range srcLines {
+		cols := sm.SourceLinesToTarget[l]
+		srcCols := make([]uint32, 0, len(cols))
+		for c := range cols {
+			srcCols = append(srcCols, c)
+		}
+		sort.Slice(srcCols, func(i, j int) bool { return srcCols[i] < srcCols[j] })
+		var current *segment
+		for _, c := range srcCols {
+			tgt := cols[c]
+			// Characters are in the same segment if they're consecutive in both
+			// the template and the Go code.
+			if current != nil && tgt.Line == current.TgtLine && c-current.SrcTo <= utf8.UTFMax && tgt.Col-current.TgtFrom == c-current.SrcFrom {
+				current.SrcTo = c
+				continue
+			}
+			result = append(result, segment{SrcLine: l, SrcFrom: c, SrcTo: c, TgtLine: tgt.Line, TgtFrom: tgt.Col})
+			current = &result[len(result)-1]
+		}
+	}
+	return result
+}
+
+// unmappedExpressions returns the Go expressions in the template that don't
+// have a position in the generated code.
+func unmappedExpressions(f File) (unmapped []unmappedExpression) {
+	tf, err := parser.ParseString(f.Templ)
+	if err != nil || f.SourceMap == nil {
+		return nil
+	}
+	parser.Inspect(tf, func(n any) bool {
+		e, ok := expressionOf(n)
+		if !ok || strings.TrimSpace(e.Value) == "" {
+			return true
+		}
+		if _, ok := f.SourceMap.TargetPositionFromSource(e.Range.From.Line, e.Range.From.Col); !ok {
+			unmapped = append(unmapped, unmappedExpression{Line: e.Range.From.Line, Col: e.Range.From.Col, Value: e.Value})
+		}
+		return true
+	})
+	sort.Slice(unmapped, func(i, j int) bool {
+		if unmapped[i].Line != unmapped[j].Line {
+			return unmapped[i].Line < unmapped[j].Line
+		}
+		return unmapped[i].Col < unmapped[j].Col
+	})
+	return unmapped
+}
+
+// expressionOf returns the Go expression of a node.
+func expressionOf(n any) (e parser.Expression, ok bool) {
+	switch n := n.(type) {
+	case parser.TemplateFileGoExpression:
+		return n.Expression, true
+	case parser.HTMLTemplate:
+		return n.Expression, true
+	case *parser.HTMLTemplate:
+		return n.Expression, true
+	case parser.StringExpression:
+		return n.Expression, true
+	case parser.TemplElementExpression:
+		return n.Expression, true
+	case parser.CallTemplateExpression:
+		return n.Expression, true
+	case parser.ExpressionAttribute:
+		return n.Expression, true
+	case parser.BoolExpressionAttribute:
+		return n.Expression, true
+	case parser.SpreadAttributes:
+		return n.Expression, true
+	case parser.ConditionalAttribute:
+		return n.Expression, true
+	case parser.IfExpression:
+		return n.Expression, true
+	case parser.ElseIfExpression:
+		return n.Expression, true
+	case parser.ForExpression:
+		return n.Expression, true
+	case parser.SwitchExpression:
+		return n.Expression, true
+	case parser.CaseExpression:
+		return n.Expression, true
+	case parser.GoCode:
+		return n.Expression, true
+	}
+	return e, false
+}
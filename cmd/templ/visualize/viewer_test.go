@@ -0,0 +1,129 @@
+package visualize
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/a-h/templ/generator"
+	"github.com/a-h/templ/parser/v2"
+)
+
+const testTemplate = `package example
+
+templ Greeting(name string, items []string) {
+	<h1>Hello, { name }</h1>
+	for _, item := range items {
+		if item != "" {
+			<p class={ item }>{ item }</p>
+		}
+	}
+}
+`
+
+func newTestFile(t *testing.T, src string) File {
+	t.Helper()
+	tf, err := parser.ParseString(src)
+	if err != nil {
+		t.Fatalf("failed to parse template: %v", err)
+	}
+	var sb strings.Builder
+	sm, _, err := generator.Generate(tf, &sb)
+	if err != nil {
+		t.Fatalf("failed to generate code: %v", err)
+	}
+	return File{Name: "example.templ", Templ: src, Go: sb.String(), SourceMap: sm}
+}
+
+func TestViewer(t *testing.T) {
+	s := NewStore()
+	s.Set(newTestFile(t, testTemplate))
+	v := NewViewer(s)
+
+	get := func(url string) (code int, body string) {
+		w := httptest.NewRecorder()
+		v.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+		return w.Code, w.Body.String()
+	}
+
+	t.Run("the index lists the files", func(t *testing.T) {
+		code, body := get("/")
+		if code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", code)
+		}
+		if !strings.Contains(body, `<a href="?name=example.templ">example.templ</a>`) {
+			t.Errorf("expected a link to the file, got:\n%s", body)
+		}
+	})
+	t.Run("unknown files are not found", func(t *testing.T) {
+		if code, _ := get("/?name=unknown.templ"); code != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", code)
+		}
+	})
+	t.Run("mapped ranges have the same segment in both panes", func(t *testing.T) {
+		code, body := get("/?name=example.templ")
+		if code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", code)
+		}
+		view := newView(newTestFile(t, testTemplate))
+		segment := -1
+		for _, s := range view.Templ[3] {
+			if s.Text == "name" && s.Segment >= 0 {
+				segment = s.Segment
+			}
+		}
+		if segment < 0 {
+			t.Fatalf("expected the name expression to be mapped, got %#v", view.Templ[3])
+		}
+		var inGo bool
+		for _, l := range view.Go {
+			for _, s := range l {
+				if s.Segment == segment && s.Text == "name" {
+					inGo = true
+				}
+			}
+		}
+		if !inGo {
+			t.Error("expected the name expression to be mapped to the Go code")
+		}
+		if strings.Contains(body, `class="unmapped"`) {
+			t.Errorf("expected no unmapped expressions, got:\n%s", body)
+		}
+	})
+}
+
+func TestUnmappedExpressions(t *testing.T) {
+	f := newTestFile(t, testTemplate)
+	if unmapped := unmappedExpressions(f); len(unmapped) != 0 {
+		t.Fatalf("expected all expressions to be mapped, got %v", unmapped)
+	}
+	// Remove the mapping of the first line of the for loop.
+	delete(f.SourceMap.SourceLinesToTarget, 4)
+	unmapped := unmappedExpressions(f)
+	if len(unmapped) != 1 || unmapped[0].Line != 4 || unmapped[0].Value != "_, item := range items" {
+		t.Fatalf("expected the for expression to be unmapped, got %v", unmapped)
+	}
+	view := newView(f)
+	var flagged bool
+	for _, s := range view.Templ[4] {
+		flagged = flagged || (s.Unmapped && s.Text == "_, item := range items")
+	}
+	if !flagged {
+		t.Errorf("expected the for expression to be flagged, got %#v", view.Templ[4])
+	}
+}
+
+func TestStoreNotifiesChanges(t *testing.T) {
+	s := NewStore()
+	var changed []string
+	s.OnChange = func(name string) { changed = append(changed, name) }
+	s.Set(File{Name: "a.templ"})
+	s.Delete("a.templ")
+	if len(changed) != 2 || changed[0] != "a.templ" || changed[1] != "a.templ" {
+		t.Errorf("expected two changes to a.templ, got %v", changed)
+	}
+	if names := s.Names(); len(names) != 0 {
+		t.Errorf("expected no files, got %v", names)
+	}
+}
@@ -15,6 +15,7 @@ import (
 
 	_ "embed"
 
+	"github.com/a-h/templ"
 	"github.com/a-h/templ/parser/v2"
 )
 
@@ -67,6 +68,18 @@ func WithSkipCodeGeneratedComment() GenerateOpt {
 	}
 }
 
+// WithMinify minifies the HTML and CSS literals written by the generated code.
+// Insignificant whitespace is collapsed, whitespace next to block elements is
+// removed, HTML comments are removed unless they start with <!--!, constant CSS
+// is minified, and constant attributes are shortened. The contents of <pre> and <textarea> elements, and raw elements,
+// are not changed.
+func WithMinify() GenerateOpt {
+	return func(g *generator) error {
+		g.minify = true
+		return nil
+	}
+}
+
 // Generate generates Go code from the input template file to w, and returns a map of the location of Go expressions in the template
 // to the location of the generated Go code in the output.
 func Generate(template parser.TemplateFile, w io.Writer, opts ...GenerateOpt) (sm *parser.SourceMap, literals string, err error) {
@@ -101,6 +114,18 @@ type generator struct {
 	fileName string
 	// skipCodeGeneratedComment skips the code generated comment at the top of the file.
 	skipCodeGeneratedComment bool
+	// minify the HTML and CSS output.
+	minify bool
+	// preformattedDepth is the number of <pre> or <textarea> elements that the
+	// current node is within. Whitespace is not minified within these elements.
+	preformattedDepth int
+	// inBlockElement is true when minifying the children of a block element.
+	inBlockElement bool
+	// staticName is the name of the static variables of the current template.
+	staticName string
+	// staticElements are the package level []byte variables of the static
+	// elements within the current template, written after the template.
+	staticElements []string
 }
 
 func (g *generator) generate() (err error) {
@@ -218,6 +243,9 @@ func (g *generator) writeTemplateNodes() error {
 			if err := g.writeScript(n); err != nil {
 				return err
 			}
+		case parser.ErrorNode:
+			// Code that couldn't be parsed isn't generated.
+			continue
 		default:
 			return fmt.Errorf("unknown node type: %v", reflect.TypeOf(n))
 		}
@@ -251,6 +279,9 @@ func (g *generator) writeCSS(n parser.CSSTemplate) error {
 		for i := 0; i < len(n.Properties); i++ {
 			switch p := n.Properties[i].(type) {
 			case parser.ConstantCSSProperty:
+				if g.minify {
+					p.Value = minifyCSSValue(p.Value)
+				}
 				// Constant CSS property values are not sanitized.
 				if _, err = g.w.WriteIndent(indentLevel, "templ_7745c5c3_CSSBuilder.WriteString("+createGoString(p.String(true))+")\n"); err != nil {
 					return err
@@ -380,6 +411,15 @@ func (g *generator) writeTemplate(nodeIdx int, t parser.HTMLTemplate) error {
 	var err error
 	var indentLevel int
 
+	// The name is derived from the template signature, which is unique within the package.
+	sum := sha256.Sum256([]byte(t.Expression.Value))
+	g.staticName = "templ_7745c5c3_Static_" + hex.EncodeToString(sum[:])[0:8]
+
+	// Templates without Go expressions are rendered once, at generation time.
+	if g.canWriteStaticTemplate(t) {
+		return g.writeStaticTemplate(nodeIdx, t)
+	}
+
 	// func
 	if _, err = g.w.Write("func "); err != nil {
 		return err
@@ -464,18 +504,158 @@ func (g *generator) writeTemplate(nodeIdx int, t parser.HTMLTemplate) error {
 	}
 	indentLevel--
 	// }
+	if _, err = g.w.WriteIndent(indentLevel, "}\n"); err != nil {
+		return err
+	}
+	// var templ_7745c5c3_Static_a6250d46_1 = []byte("<div></div>")
+	if len(g.staticElements) > 0 {
+		if _, err = g.w.Write("\n" + strings.Join(g.staticElements, "")); err != nil {
+			return err
+		}
+		g.staticElements = nil
+	}
+	// Note: gofmt wants to remove a single empty line at the end of a file
+	// so we have to make sure we don't output one if this is the last node.
+	if nodeIdx+1 < len(g.tf.Nodes) {
+		if _, err = g.w.Write("\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
+// canWriteStaticTemplate returns true if the output of the template is always the same.
+// In watch mode, the literals are read from the _templ.txt file at runtime instead.
+func (g *generator) canWriteStaticTemplate(t parser.HTMLTemplate) bool {
+	if _, isWatchMode := g.w.literalWriter.(*watchLiteralWriter); isWatchMode {
+		return false
+	}
+	return isStaticNodes(t.Children)
+}
+
+// writeStaticTemplate writes the output of a template that doesn't contain Go expressions
+// to a package level templ.StaticComponent, so that the HTTP handler can precompute an ETag
+// and compressed response.
+func (g *generator) writeStaticTemplate(nodeIdx int, t parser.HTMLTemplate) (err error) {
+	// Render the template to collect its literal output.
+	slw := &staticLiteralWriter{}
+	sg := *g
+	sg.w = &RangeWriter{w: io.Discard, literalWriter: slw}
+	if err = sg.writeNodes(0, stripWhitespace(t.Children), nil); err != nil {
+		return err
+	}
+	if _, err = sg.w.WriteIndent(0, ""); err != nil {
+		return err
+	}
+
+	// var templ_7745c5c3_Static_a6250d46 = templ.NewStaticComponent([]byte("<div></div>"))
+	vn := g.staticName
+	if _, err = g.w.Write("var " + vn + " = templ.NewStaticComponent([]byte(\"" + slw.literals() + "\"))\n\n"); err != nil {
+		return err
+	}
+	// func
+	if _, err = g.w.Write("func "); err != nil {
+		return err
+	}
+	// (r *Receiver) Name(params []string)
+	var r parser.Range
+	if r, err = g.w.Write(t.Expression.Value); err != nil {
+		return err
+	}
+	g.sourceMap.Add(t.Expression, r)
+	// templ.Component {
+	if _, err = g.w.Write(" templ.Component {\n"); err != nil {
+		return err
+	}
+	// return templ_7745c5c3_Static1
+	if _, err = g.w.WriteIndent(1, "return "+vn+"\n"); err != nil {
+		return err
+	}
 	// Note: gofmt wants to remove a single empty line at the end of a file
 	// so we have to make sure we don't output one if this is the last node.
 	closingBrace := "}\n\n"
 	if nodeIdx+1 >= len(g.tf.Nodes) {
 		closingBrace = "}\n"
 	}
+	_, err = g.w.Write(closingBrace)
+	return err
+}
+
+// canWriteStaticElement returns true if the element is a static sub-tree that
+// can be written as a package level []byte variable. Elements that don't contain
+// other elements are written as part of the surrounding literal instead.
+func (g *generator) canWriteStaticElement(n parser.Element) bool {
+	if _, isProdMode := g.w.literalWriter.(prodLiteralWriter); !isProdMode {
+		return false
+	}
+	return hasChildElements(n) && isStaticNodes([]parser.Node{n})
+}
+
+func hasChildElements(n parser.Element) bool {
+	for _, child := range n.Children {
+		if _, isElement := child.(parser.Element); isElement {
+			return true
+		}
+	}
+	return false
+}
 
-	if _, err = g.w.WriteIndent(indentLevel, closingBrace); err != nil {
+// writeStaticElement writes the output of an element that doesn't contain Go
+// expressions to a package level []byte variable, and writes the variable to the
+// buffer.
+func (g *generator) writeStaticElement(indentLevel int, n parser.Element) (err error) {
+	// Render the element to collect its literal output.
+	slw := &staticLiteralWriter{}
+	sg := *g
+	sg.w = &RangeWriter{w: io.Discard, literalWriter: slw}
+	if err = sg.writeElement(0, n); err != nil {
 		return err
 	}
-	return nil
+	if _, err = sg.w.WriteIndent(0, ""); err != nil {
+		return err
+	}
+
+	vn := g.staticName + "_" + strconv.Itoa(len(g.staticElements)+1)
+	g.staticElements = append(g.staticElements, "var "+vn+" = []byte(\""+slw.literals()+"\")\n")
+
+	// _, templ_7745c5c3_Err = templ_7745c5c3_Buffer.Write(templ_7745c5c3_Static_a6250d46_1)
+	if _, err = g.w.WriteIndent(indentLevel, "_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.Write("+vn+")\n"); err != nil {
+		return err
+	}
+	return g.writeErrorHandler(indentLevel)
+}
+
+// isStaticNodes returns true if the nodes don't contain any Go expressions.
+func isStaticNodes(nodes []parser.Node) bool {
+	for _, node := range nodes {
+		switch n := node.(type) {
+		case parser.DocType, parser.HTMLComment, parser.Text, parser.Whitespace, parser.GoComment, parser.ErrorNode:
+			continue
+		case parser.Element:
+			if !isStaticAttributes(n.Attributes) || !isStaticNodes(n.Children) {
+				return false
+			}
+		case parser.RawElement:
+			if !isStaticAttributes(n.Attributes) {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func isStaticAttributes(attrs []parser.Attribute) bool {
+	for _, attr := range attrs {
+		switch attr.(type) {
+		case parser.BoolConstantAttribute, parser.ConstantAttribute:
+			continue
+		default:
+			return false
+		}
+	}
+	return true
 }
 
 func stripWhitespace(input []parser.Node) (output []parser.Node) {
@@ -520,6 +700,13 @@ func (g *generator) writeNodes(indentLevel int, nodes []parser.Node, next parser
 		if nextNode == nil {
 			nextNode = next
 		}
+		if t, isText := curr.(parser.Text); isText && g.minify && g.preformattedDepth == 0 {
+			var prevNode parser.Node
+			if i > 0 {
+				prevNode = nodes[i-1]
+			}
+			curr = g.minifyText(t, prevNode, nextNode)
+		}
 		if err := g.writeNode(indentLevel, curr, nextNode); err != nil {
 			return err
 		}
@@ -556,10 +743,16 @@ func (g *generator) writeNode(indentLevel int, current parser.Node, next parser.
 	case parser.Whitespace:
 		err = g.writeWhitespace(indentLevel, n)
 	case parser.Text:
+		if n.Value == "" {
+			return
+		}
 		err = g.writeText(indentLevel, n)
 	case parser.GoComment:
 		// Do not render Go comments in the output HTML.
 		return
+	case parser.ErrorNode:
+		// Code that couldn't be parsed isn't generated.
+		return
 	default:
 		return fmt.Errorf("unhandled type: %v", reflect.TypeOf(n))
 	}
@@ -905,6 +1098,9 @@ func copyAttributes(attr []parser.Attribute) []parser.Attribute {
 }
 
 func (g *generator) writeElement(indentLevel int, n parser.Element) (err error) {
+	if g.canWriteStaticElement(n) {
+		return g.writeStaticElement(indentLevel, n)
+	}
 	if len(n.Attributes) == 0 {
 		// <div>
 		if _, err = g.w.WriteStringLiteral(indentLevel, fmt.Sprintf(`<%s>`, html.EscapeString(n.Name))); err != nil {
@@ -924,7 +1120,7 @@ func (g *generator) writeElement(indentLevel int, n parser.Element) (err error)
 		if _, err = g.w.WriteStringLiteral(indentLevel, fmt.Sprintf(`<%s`, html.EscapeString(n.Name))); err != nil {
 			return err
 		}
-		if err = g.writeElementAttributes(indentLevel, n.Name, attrs); err != nil {
+		if err = g.writeTagAttributes(indentLevel, n.Name, attrs); err != nil {
 			return err
 		}
 		// >
@@ -937,6 +1133,15 @@ func (g *generator) writeElement(indentLevel int, n parser.Element) (err error)
 		return nil
 	}
 	// Children.
+	if isPreformattedElement(n.Name) {
+		g.preformattedDepth++
+		defer func() { g.preformattedDepth-- }()
+	}
+	if g.minify {
+		inBlockElement := g.inBlockElement
+		g.inBlockElement = n.IsBlockElement()
+		defer func() { g.inBlockElement = inBlockElement }()
+	}
 	if err = g.writeNodes(indentLevel, stripWhitespace(n.Children), nil); err != nil {
 		return err
 	}
@@ -1070,8 +1275,16 @@ func (g *generator) writeBoolConstantAttribute(indentLevel int, attr parser.Bool
 }
 
 func (g *generator) writeConstantAttribute(indentLevel int, attr parser.ConstantAttribute) (err error) {
+	if g.minify && isBooleanAttribute(attr.Name) && (attr.Value == "" || strings.EqualFold(attr.Value, attr.Name)) {
+		// disabled="disabled" is equivalent to disabled.
+		return g.writeBoolConstantAttribute(indentLevel, parser.BoolConstantAttribute{Name: attr.Name})
+	}
 	name := html.EscapeString(attr.Name)
 	value := html.EscapeString(attr.Value)
+	if g.minify && canBeUnquoted(value) {
+		_, err = g.w.WriteStringLiteral(indentLevel, fmt.Sprintf(` %s=%s`, name, value))
+		return err
+	}
 	value = strconv.Quote(value)
 	value = value[1 : len(value)-1]
 	if _, err = g.w.WriteStringLiteral(indentLevel, fmt.Sprintf(` %s=\"%s\"`, name, value)); err != nil {
@@ -1207,27 +1420,6 @@ func (g *generator) writeExpressionAttribute(indentLevel int, elementName string
 	return nil
 }
 
-func (g *generator) writeSpreadAttributes(indentLevel int, attr parser.SpreadAttributes) (err error) {
-	// templ.RenderAttributes(ctx, w, spreadAttrs)
-	if _, err = g.w.WriteIndent(indentLevel, `templ_7745c5c3_Err = templ.RenderAttributes(ctx, templ_7745c5c3_Buffer, `); err != nil {
-		return err
-	}
-	// spreadAttrs
-	var r parser.Range
-	if r, err = g.w.Write(attr.Expression.Value); err != nil {
-		return err
-	}
-	g.sourceMap.Add(attr.Expression, r)
-	// )
-	if _, err = g.w.Write(")\n"); err != nil {
-		return err
-	}
-	if err = g.writeErrorHandler(indentLevel); err != nil {
-		return err
-	}
-	return nil
-}
-
 func (g *generator) writeConditionalAttribute(indentLevel int, elementName string, attr parser.ConditionalAttribute) (err error) {
 	// if
 	if _, err = g.w.WriteIndent(indentLevel, `if `); err != nil {
@@ -1281,8 +1473,6 @@ func (g *generator) writeElementAttributes(indentLevel int, name string, attrs [
 			err = g.writeBoolExpressionAttribute(indentLevel, attr)
 		case parser.ExpressionAttribute:
 			err = g.writeExpressionAttribute(indentLevel, name, attr)
-		case parser.SpreadAttributes:
-			err = g.writeSpreadAttributes(indentLevel, attr)
 		case parser.ConditionalAttribute:
 			err = g.writeConditionalAttribute(indentLevel, name, attr)
 		default:
@@ -1292,6 +1482,187 @@ func (g *generator) writeElementAttributes(indentLevel int, name string, attrs [
 	return
 }
 
+// writeTagAttributes writes the attributes of an element's open tag. If any of
+// the attributes are spread attributes, the attributes are collected and merged
+// at runtime, so that spread values combine with the literal attributes.
+func (g *generator) writeTagAttributes(indentLevel int, name string, attrs []parser.Attribute) (err error) {
+	if !hasSpreadAttributes(attrs) {
+		return g.writeElementAttributes(indentLevel, name, attrs)
+	}
+	// var templ_7745c5c3_Var1 templ.OrderedAttributes
+	vn := g.createVariableName()
+	if _, err = g.w.WriteIndent(indentLevel, "var "+vn+" templ.OrderedAttributes\n"); err != nil {
+		return err
+	}
+	if err = g.writeMergedAttributes(indentLevel, name, vn, attrs); err != nil {
+		return err
+	}
+	// templ_7745c5c3_Err = templ.RenderAttributes(ctx, templ_7745c5c3_Buffer, templ.MergeAttributes(templ_7745c5c3_Var1))
+	if _, err = g.w.WriteIndent(indentLevel, "templ_7745c5c3_Err = templ.RenderAttributes(ctx, templ_7745c5c3_Buffer, templ.MergeAttributes("+vn+"))\n"); err != nil {
+		return err
+	}
+	return g.writeErrorHandler(indentLevel)
+}
+
+func hasSpreadAttributes(attrs []parser.Attribute) bool {
+	for _, attr := range attrs {
+		switch attr := attr.(type) {
+		case parser.SpreadAttributes:
+			return true
+		case parser.ConditionalAttribute:
+			if hasSpreadAttributes(attr.Then) || hasSpreadAttributes(attr.Else) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// writeMergedAttributes appends each attribute to the templ.OrderedAttributes variable vn.
+func (g *generator) writeMergedAttributes(indentLevel int, elementName, vn string, attrs []parser.Attribute) (err error) {
+	var r parser.Range
+	for _, attr := range attrs {
+		switch attr := attr.(type) {
+		case parser.BoolConstantAttribute:
+			// templ_7745c5c3_Var1 = append(templ_7745c5c3_Var1, templ.KV[string, any]("name", true))
+			if _, err = g.w.WriteIndent(indentLevel, vn+" = append("+vn+", templ.KV[string, any]("+createGoString(attr.Name)+", true))\n"); err != nil {
+				return err
+			}
+		case parser.ConstantAttribute:
+			// templ_7745c5c3_Var1 = append(templ_7745c5c3_Var1, templ.KV[string, any]("name", "value"))
+			value := createGoString(attr.Value)
+			if templ.IsURLAttribute(attr.Name) {
+				// Constant URLs are written by the template author, so they're not sanitized.
+				value = "templ.SafeURL(" + value + ")"
+			}
+			if _, err = g.w.WriteIndent(indentLevel, vn+" = append("+vn+", templ.KV[string, any]("+createGoString(attr.Name)+", "+value+"))\n"); err != nil {
+				return err
+			}
+		case parser.BoolExpressionAttribute:
+			// templ_7745c5c3_Var1 = append(templ_7745c5c3_Var1, templ.KV[string, any]("name", x == y))
+			if _, err = g.w.WriteIndent(indentLevel, vn+" = append("+vn+", templ.KV[string, any]("+createGoString(attr.Name)+", "); err != nil {
+				return err
+			}
+			if r, err = g.w.Write(attr.Expression.Value); err != nil {
+				return err
+			}
+			g.sourceMap.Add(attr.Expression, r)
+			if _, err = g.w.Write("))\n"); err != nil {
+				return err
+			}
+		case parser.ExpressionAttribute:
+			if err = g.writeMergedExpressionAttribute(indentLevel, elementName, vn, attr); err != nil {
+				return err
+			}
+		case parser.SpreadAttributes:
+			// templ_7745c5c3_Var1 = append(templ_7745c5c3_Var1, templ.SpreadItems(spreadAttrs)...)
+			if _, err = g.w.WriteIndent(indentLevel, vn+" = append("+vn+", templ.SpreadItems("); err != nil {
+				return err
+			}
+			if r, err = g.w.Write(attr.Expression.Value); err != nil {
+				return err
+			}
+			g.sourceMap.Add(attr.Expression, r)
+			if _, err = g.w.Write(")...)\n"); err != nil {
+				return err
+			}
+		case parser.ConditionalAttribute:
+			// if
+			if _, err = g.w.WriteIndent(indentLevel, `if `); err != nil {
+				return err
+			}
+			// x == y
+			if r, err = g.w.Write(attr.Expression.Value); err != nil {
+				return err
+			}
+			g.sourceMap.Add(attr.Expression, r)
+			// {
+			if _, err = g.w.Write(` {` + "\n"); err != nil {
+				return err
+			}
+			if err = g.writeMergedAttributes(indentLevel+1, elementName, vn, attr.Then); err != nil {
+				return err
+			}
+			if len(attr.Else) > 0 {
+				// } else {
+				if _, err = g.w.WriteIndent(indentLevel, `} else {`+"\n"); err != nil {
+					return err
+				}
+				if err = g.writeMergedAttributes(indentLevel+1, elementName, vn, attr.Else); err != nil {
+					return err
+				}
+			}
+			// }
+			if _, err = g.w.WriteIndent(indentLevel, `}`+"\n"); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unknown attribute type %s", reflect.TypeOf(attr))
+		}
+	}
+	return nil
+}
+
+func (g *generator) writeMergedExpressionAttribute(indentLevel int, elementName, vn string, attr parser.ExpressionAttribute) (err error) {
+	valueName := g.createVariableName()
+	var r parser.Range
+	switch {
+	case (elementName == "a" && attr.Name == "href") || (elementName == "form" && attr.Name == "action"):
+		// var vn templ.SafeURL = p.URL
+		if _, err = g.w.WriteIndent(indentLevel, "var "+valueName+" templ.SafeURL = "); err != nil {
+			return err
+		}
+		if r, err = g.w.Write(attr.Expression.Value); err != nil {
+			return err
+		}
+		g.sourceMap.Add(attr.Expression, r)
+		if _, err = g.w.Write("\n"); err != nil {
+			return err
+		}
+	case isScriptAttribute(attr.Name):
+		// var vn templ.ComponentScript = onClick()
+		if _, err = g.w.WriteIndent(indentLevel, "var "+valueName+" templ.ComponentScript = "); err != nil {
+			return err
+		}
+		if r, err = g.w.Write(attr.Expression.Value); err != nil {
+			return err
+		}
+		g.sourceMap.Add(attr.Expression, r)
+		if _, err = g.w.Write("\n"); err != nil {
+			return err
+		}
+	default:
+		// var vn string
+		if _, err = g.w.WriteIndent(indentLevel, "var "+valueName+" string\n"); err != nil {
+			return err
+		}
+		// vn, templ_7745c5c3_Err = templ.JoinStringErrs(
+		if _, err = g.w.WriteIndent(indentLevel, valueName+", templ_7745c5c3_Err = templ.JoinStringErrs("); err != nil {
+			return err
+		}
+		// p.Name()
+		if r, err = g.w.Write(attr.Expression.Value); err != nil {
+			return err
+		}
+		g.sourceMap.Add(attr.Expression, r)
+		// )
+		if _, err = g.w.Write(")\n"); err != nil {
+			return err
+		}
+		if err = g.writeExpressionErrorHandler(indentLevel, attr.Expression); err != nil {
+			return err
+		}
+		if templ.IsURLAttribute(attr.Name) {
+			// String expressions are escaped, but not sanitized, the same as
+			// they are on elements without spread attributes.
+			valueName = "templ.SafeURL(" + valueName + ")"
+		}
+	}
+	// templ_7745c5c3_Var1 = append(templ_7745c5c3_Var1, templ.KV[string, any]("name", vn))
+	_, err = g.w.WriteIndent(indentLevel, vn+" = append("+vn+", templ.KV[string, any]("+createGoString(attr.Name)+", "+valueName+"))\n")
+	return err
+}
+
 func (g *generator) writeRawElement(indentLevel int, n parser.RawElement) (err error) {
 	if len(n.Attributes) == 0 {
 		// <div>
@@ -1307,7 +1678,7 @@ func (g *generator) writeRawElement(indentLevel int, n parser.RawElement) (err e
 		if _, err = g.w.WriteStringLiteral(indentLevel, fmt.Sprintf(`<%s`, html.EscapeString(n.Name))); err != nil {
 			return err
 		}
-		if err = g.writeElementAttributes(indentLevel, n.Name, n.Attributes); err != nil {
+		if err = g.writeTagAttributes(indentLevel, n.Name, n.Attributes); err != nil {
 			return err
 		}
 		// >
@@ -1327,6 +1698,10 @@ func (g *generator) writeRawElement(indentLevel int, n parser.RawElement) (err e
 }
 
 func (g *generator) writeComment(indentLevel int, c parser.HTMLComment) (err error) {
+	// Comments that start with <!--! are kept when minifying, e.g. for licenses.
+	if g.minify && !strings.HasPrefix(c.Contents, "!") {
+		return nil
+	}
 	// <!--
 	if _, err = g.w.WriteStringLiteral(indentLevel, "<!--"); err != nil {
 		return err
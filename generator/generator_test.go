@@ -2,6 +2,7 @@ package generator
 
 import (
 	"bytes"
+	"strings"
 	"testing"
 
 	"github.com/a-h/templ/parser/v2"
@@ -47,3 +48,142 @@ templ h1() {
 		t.Fatalf("failed to write Go expression: %v", err)
 	}
 }
+
+func TestGeneratorMinify(t *testing.T) {
+	template := `package main
+
+css red() {
+	border:   1px  solid   red ;
+	font-family: "a ,  b";
+}
+
+templ page() {
+	<div>
+		<!-- removed -->
+		<!--! kept -->
+		<p>
+			Hello   there,
+			friend
+		</p>
+		<input type="checkbox" checked="checked" disabled="" class="a b"/>
+		<pre>a   b</pre>
+		<textarea>x   y</textarea>
+		<script>  var   x = 1;  </script>
+	</div>
+}
+`
+	tf, err := parser.ParseString(template)
+	if err != nil {
+		t.Fatalf("failed to parse template: %v", err)
+	}
+	w := new(bytes.Buffer)
+	if _, _, err = Generate(tf, w, WithMinify()); err != nil {
+		t.Fatalf("failed to generate: %v", err)
+	}
+	actual := w.String()
+	expected := []string{
+		"border:1px solid red;",
+		`font-family:"a ,  b";`,
+		`<div><!--! kept --><p>Hello there, friend</p><input type=checkbox checked disabled class=\"a b\"><pre>a   b</pre><textarea>x   y</textarea><script>  var   x = 1;  </script></div>`,
+	}
+	for _, e := range expected {
+		if !strings.Contains(actual, e) {
+			t.Errorf("expected output to contain %q, got:\n%s", e, actual)
+		}
+	}
+}
+
+func TestGeneratorMinifyBlockWhitespace(t *testing.T) {
+	template := `package main
+
+templ page(name string) {
+	<section>
+		<div>a</div> after a block
+		<div>  b  </div>
+		before a block <div>c</div>
+		<span>{ name }</span> <div>d</div>
+		<p>
+			Hello 
+			<b>bold</b> and <em>{ name }</em> 
+		</p>
+		<span>inline </span>
+	</section>
+}
+`
+	tf, err := parser.ParseString(template)
+	if err != nil {
+		t.Fatalf("failed to parse template: %v", err)
+	}
+	w := new(bytes.Buffer)
+	if _, _, err = Generate(tf, w, WithMinify()); err != nil {
+		t.Fatalf("failed to generate: %v", err)
+	}
+	actual := w.String()
+	expected := []string{
+		`"<section><div>a</div>after a block<div>b</div>before a block<div>c</div><span>"`,
+		`"</span><div>d</div><p>Hello <b>bold</b> and <em>"`,
+		`"</em></p><span>inline </span></section>"`,
+	}
+	for _, e := range expected {
+		if !strings.Contains(actual, e) {
+			t.Errorf("expected output to contain %q, got:\n%s", e, actual)
+		}
+	}
+}
+
+func TestGeneratorStaticTemplates(t *testing.T) {
+	template := `package main
+
+templ static() {
+	<div class="a">Static</div>
+}
+
+templ dynamic(name string) {
+	<div>{ name }</div>
+	<ul>
+		<li>Static</li>
+	</ul>
+}
+`
+	tf, err := parser.ParseString(template)
+	if err != nil {
+		t.Fatalf("failed to parse template: %v", err)
+	}
+	t.Run("templates without Go expressions are static", func(t *testing.T) {
+		w := new(bytes.Buffer)
+		if _, _, err = Generate(tf, w); err != nil {
+			t.Fatalf("failed to generate: %v", err)
+		}
+		if actual := w.String(); strings.Count(actual, "templ.NewStaticComponent(") != 1 {
+			t.Errorf("expected a single static component, got:\n%s", actual)
+		}
+		if actual := w.String(); !strings.Contains(actual, `[]byte("<div class=\"a\">Static</div>")`) {
+			t.Errorf("expected static content, got:\n%s", actual)
+		}
+	})
+	t.Run("static elements within dynamic templates are written as byte slices", func(t *testing.T) {
+		w := new(bytes.Buffer)
+		if _, _, err = Generate(tf, w); err != nil {
+			t.Fatalf("failed to generate: %v", err)
+		}
+		actual := w.String()
+		expected := []string{
+			`_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.Write(templ_7745c5c3_Static_`,
+			`_1 = []byte("<ul><li>Static</li></ul>")`,
+		}
+		for _, e := range expected {
+			if !strings.Contains(actual, e) {
+				t.Errorf("expected output to contain %q, got:\n%s", e, actual)
+			}
+		}
+	})
+	t.Run("static templates are not used in watch mode", func(t *testing.T) {
+		w := new(bytes.Buffer)
+		if _, _, err = Generate(tf, w, WithExtractStrings()); err != nil {
+			t.Fatalf("failed to generate: %v", err)
+		}
+		if actual := w.String(); strings.Contains(actual, "templ.NewStaticComponent(") || strings.Contains(actual, "[]byte(") {
+			t.Errorf("expected no static components or elements, got:\n%s", actual)
+		}
+	})
+}
@@ -0,0 +1,119 @@
+package generator
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/a-h/templ/parser/v2"
+)
+
+// collapseWhitespace replaces each run of whitespace with a single space.
+// In HTML, a single space is equivalent to any number of spaces, tabs, or newlines.
+func collapseWhitespace(s string) string {
+	var sb strings.Builder
+	sb.Grow(len(s))
+	var inWhitespace bool
+	for _, r := range s {
+		if unicode.IsSpace(r) {
+			if !inWhitespace {
+				sb.WriteRune(' ')
+			}
+			inWhitespace = true
+			continue
+		}
+		inWhitespace = false
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// minifyText collapses the whitespace of the text. Whitespace next to a block
+// element, or at the start or end of a block element's children, isn't
+// rendered, so it's removed.
+func (g *generator) minifyText(t parser.Text, prev, next parser.Node) parser.Text {
+	t.Value = collapseWhitespace(t.Value)
+	if isBlockElement(prev) || (prev == nil && g.inBlockElement) {
+		t.Value = strings.TrimLeftFunc(t.Value, unicode.IsSpace)
+	}
+	if isBlockElement(next) || (next == nil && g.inBlockElement) {
+		t.Value = strings.TrimRightFunc(t.Value, unicode.IsSpace)
+		t.TrailingSpace = parser.SpaceNone
+	}
+	if strings.HasSuffix(t.Value, " ") {
+		// The text already ends with a space.
+		t.TrailingSpace = parser.SpaceNone
+	}
+	return t
+}
+
+func isBlockElement(n parser.Node) bool {
+	e, ok := n.(parser.Element)
+	return ok && e.IsBlockElement()
+}
+
+// minifyCSSValue removes insignificant whitespace from a CSS property value.
+// Values that contain strings are not changed.
+func minifyCSSValue(s string) string {
+	if strings.ContainsAny(s, `"'`) {
+		return s
+	}
+	s = strings.TrimSpace(collapseWhitespace(s))
+	s = strings.ReplaceAll(s, ", ", ",")
+	s = strings.ReplaceAll(s, " ,", ",")
+	return strings.ReplaceAll(s, " !", "!")
+}
+
+// isPreformattedElement returns true if whitespace is significant within the element.
+func isPreformattedElement(name string) bool {
+	return strings.EqualFold(name, "pre") || strings.EqualFold(name, "textarea")
+}
+
+// https://html.spec.whatwg.org/multipage/indices.html#attributes-3
+var booleanAttributes = map[string]struct{}{
+	"allowfullscreen": {},
+	"async":           {},
+	"autofocus":       {},
+	"autoplay":        {},
+	"checked":         {},
+	"controls":        {},
+	"default":         {},
+	"defer":           {},
+	"disabled":        {},
+	"formnovalidate":  {},
+	"hidden":          {},
+	"inert":           {},
+	"ismap":           {},
+	"itemscope":       {},
+	"loop":            {},
+	"multiple":        {},
+	"muted":           {},
+	"nomodule":        {},
+	"novalidate":      {},
+	"open":            {},
+	"playsinline":     {},
+	"readonly":        {},
+	"required":        {},
+	"reversed":        {},
+	"selected":        {},
+}
+
+func isBooleanAttribute(name string) bool {
+	_, ok := booleanAttributes[strings.ToLower(name)]
+	return ok
+}
+
+// canBeUnquoted returns true if the HTML escaped attribute value can be
+// written without quotes.
+// https://html.spec.whatwg.org/multipage/syntax.html#unquoted
+func canBeUnquoted(value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, r := range value {
+		if unicode.IsSpace(r) || strings.ContainsRune("\"'=<>`\\", r) {
+			return false
+		}
+	}
+	// A trailing slash would be read as a self-closing tag.
+	return !strings.HasSuffix(value, "/")
+}
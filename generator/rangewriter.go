@@ -72,6 +72,24 @@ func (prodLiteralWriter) literals() string {
 	return ""
 }
 
+// staticLiteralWriter collects the literals of templates that don't contain Go expressions.
+type staticLiteralWriter struct {
+	builder strings.Builder
+}
+
+func (w *staticLiteralWriter) closeLiteral(indent int) string {
+	return ""
+}
+
+func (w *staticLiteralWriter) writeLiteral(inLiteral bool, s string) string {
+	w.builder.WriteString(s)
+	return ""
+}
+
+func (w *staticLiteralWriter) literals() string {
+	return w.builder.String()
+}
+
 func (rw *RangeWriter) closeLiteral(indent int) (r parser.Range, err error) {
 	rw.inLiteral = false
 	if _, err := rw.write(rw.literalWriter.closeLiteral(indent)); err != nil {
@@ -56,6 +56,95 @@ func Test(t *testing.T) {
 	}
 }
 
+func TestMerged(t *testing.T) {
+	component := MergedTemplate(templ.OrderedAttributes{
+		// Should be appended to the literal class value.
+		templ.KV[string, any]("class", "btn-primary btn"),
+		// Should be combined with the literal style value.
+		templ.KV[string, any]("style", "font-weight: bold;"),
+		// Should replace the literal type value, in the position of the literal.
+		templ.KV[string, any]("type", "submit"),
+		// Should render in insertion order, rather than sorted.
+		templ.KV[string, any]("z-index", 10),
+		templ.KV[string, any]("aria-valuenow", 1.5),
+		// Should be sanitized, because it's a URL attribute.
+		templ.KV[string, any]("formaction", "javascript:alert(1)"),
+		// Should not be sanitized, because it's a SafeURL.
+		templ.KV[string, any]("data-url", templ.SafeURL("/submit")),
+		// Should be overridden by the later literal disabled attribute.
+		templ.KV[string, any]("disabled", true),
+	})
+
+	diff, err := htmldiff.Diff(component, `<button type="submit" class="btn btn-primary" style="color: red; font-weight: bold" z-index="10" aria-valuenow="1.5" formaction="about:invalid#TemplFailedSanitizationURL" data-url="/submit">text</button>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestNilSpread(t *testing.T) {
+	// A constant URL isn't sanitized, even when the element has spread attributes.
+	component := LinkTemplate(nil)
+
+	diff, err := htmldiff.Diff(component, `<a href="javascript:history.back()">back</a>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestMapSpread(t *testing.T) {
+	tests := []struct {
+		name     string
+		spread   map[string]any
+		expected string
+	}{
+		{
+			name:     "nil maps render no attributes",
+			spread:   nil,
+			expected: `<img src="/logo.png">`,
+		},
+		{
+			name:     "map values are rendered in key order",
+			spread:   map[string]any{"width": 10, "alt": "logo"},
+			expected: `<img src="/logo.png" alt="logo" width="10">`,
+		},
+		{
+			name:     "URLs from spread attributes are sanitized",
+			spread:   map[string]any{"src": "javascript:alert(1)"},
+			expected: `<img src="about:invalid#TemplFailedSanitizationURL">`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff, err := htmldiff.Diff(MapTemplate(tt.spread), tt.expected)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestExpressionURLWithSpread(t *testing.T) {
+	// Expressions are escaped the same way with or without spread attributes.
+	component := ExpressionURLTemplate("javascript:alert(1)", templ.Attributes{"alt": "logo"})
+
+	diff, err := htmldiff.Diff(component, `<img src="javascript:alert(1)"><img src="javascript:alert(1)" alt="logo">`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff != "" {
+		t.Error(diff)
+	}
+}
+
 func nilPtr[T any]() *T {
 	return nil
 }
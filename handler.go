@@ -1,6 +1,16 @@
 package templ
 
-import "net/http"
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andybalholm/brotli"
+)
 
 // ComponentHandler is a http.Handler that renders components.
 type ComponentHandler struct {
@@ -9,6 +19,20 @@ type ComponentHandler struct {
 	ContentType    string
 	ErrorHandler   func(r *http.Request, err error) http.Handler
 	StreamResponse bool
+	// ETag computes a strong ETag from the rendered response, and responds to
+	// requests with a matching If-None-Match header with 304 Not Modified.
+	// Not applicable to streamed responses.
+	ETag bool
+	// LastModified sets the Last-Modified header, and responds to requests
+	// with an If-Modified-Since header that is not before it with 304 Not Modified.
+	LastModified time.Time
+	// CacheControl sets the Cache-Control header, if not empty.
+	CacheControl string
+	// Headers are added to the response.
+	Headers http.Header
+	// Compression compresses responses with brotli or gzip, if supported by
+	// the client. Not applicable to streamed responses.
+	Compression bool
 }
 
 const componentHandlerErrorMessage = "templ: failed to render template"
@@ -28,20 +52,28 @@ func (ch *ComponentHandler) ServeHTTPBuffered(w http.ResponseWriter, r *http.Req
 		http.Error(w, componentHandlerErrorMessage, http.StatusInternalServerError)
 		return
 	}
-	w.Header().Set("Content-Type", ch.ContentType)
-	if ch.Status != 0 {
-		w.WriteHeader(ch.Status)
+	body := buf.Bytes()
+	var etag string
+	if ch.ETag {
+		etag = computeETag(body)
 	}
-	// Ignore write error like http.Error() does, because there is
-	// no way to recover at this point.
-	_, _ = w.Write(buf.Bytes())
+	var encodings []string
+	if ch.Compression {
+		encodings = []string{"br", "gzip"}
+	}
+	ch.writeBody(w, r, body, etag, encodings, func(encoding string) ([]byte, error) {
+		return compress(encoding, body)
+	})
 }
 
 func (ch *ComponentHandler) ServeHTTPStreamed(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", ch.ContentType)
+	ch.writeHeaders(w)
 	if ch.Status != 0 {
 		w.WriteHeader(ch.Status)
 	}
+	if r.Method == http.MethodHead {
+		return
+	}
 	if err := ch.Component.Render(r.Context(), w); err != nil {
 		if ch.ErrorHandler != nil {
 			w.Header().Set("Content-Type", ch.ContentType)
@@ -52,12 +84,192 @@ func (ch *ComponentHandler) ServeHTTPStreamed(w http.ResponseWriter, r *http.Req
 	}
 }
 
+// ServeHTTPStatic serves a static component. If the ETag and Compression options
+// are enabled, the precomputed ETag and precompressed content are used.
+func (ch *ComponentHandler) ServeHTTPStatic(w http.ResponseWriter, r *http.Request, sc *StaticComponent) {
+	var etag string
+	if ch.ETag {
+		etag = sc.ETag()
+	}
+	var encodings []string
+	if ch.Compression {
+		encodings = []string{"br", "gzip"}
+	}
+	ch.writeBody(w, r, sc.Bytes(), etag, encodings, func(encoding string) ([]byte, error) {
+		if encoding == "br" {
+			return sc.Brotli(), nil
+		}
+		return sc.Gzip(), nil
+	})
+}
+
+// writeHeaders writes the headers that are common to all responses.
+func (ch *ComponentHandler) writeHeaders(w http.ResponseWriter) {
+	for k, values := range ch.Headers {
+		for _, v := range values {
+			w.Header().Add(k, v)
+		}
+	}
+	w.Header().Set("Content-Type", ch.ContentType)
+	if ch.CacheControl != "" {
+		w.Header().Set("Cache-Control", ch.CacheControl)
+	}
+	if !ch.LastModified.IsZero() {
+		w.Header().Set("Last-Modified", ch.LastModified.UTC().Format(http.TimeFormat))
+	}
+}
+
+// writeBody writes a rendered body to the response, handling compression,
+// conditional requests and HEAD requests.
+//
+// If etag is not empty, it's used as the ETag of the uncompressed body.
+// The body is compressed with the first of the encodings accepted by the client.
+func (ch *ComponentHandler) writeBody(w http.ResponseWriter, r *http.Request, body []byte, etag string, encodings []string, compressed func(encoding string) ([]byte, error)) {
+	ch.writeHeaders(w)
+	if len(encodings) > 0 {
+		w.Header().Add("Vary", "Accept-Encoding")
+	}
+	encoding := negotiateEncoding(r, encodings...)
+	if etag != "" {
+		if encoding != "" {
+			// Each encoding of the content requires a different strong ETag.
+			etag = strings.TrimSuffix(etag, `"`) + "-" + encoding + `"`
+		}
+		w.Header().Set("ETag", etag)
+	}
+	if ch.isNotModified(r, etag) {
+		w.Header().Del("Content-Type")
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	if encoding != "" {
+		if cb, err := compressed(encoding); err == nil {
+			body = cb
+			w.Header().Set("Content-Encoding", encoding)
+		}
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	if ch.Status != 0 {
+		w.WriteHeader(ch.Status)
+	}
+	if r.Method == http.MethodHead {
+		return
+	}
+	// Ignore write error like http.Error() does, because there is
+	// no way to recover at this point.
+	_, _ = w.Write(body)
+}
+
+// isNotModified returns true if the conditional request headers show that the
+// client already has the content.
+func (ch *ComponentHandler) isNotModified(r *http.Request, etag string) bool {
+	if ch.Status != 0 && ch.Status != http.StatusOK {
+		return false
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	// If-None-Match takes precedence over If-Modified-Since.
+	// https://www.rfc-editor.org/rfc/rfc9110#section-13.1.3
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		return etag != "" && etagMatches(inm, etag)
+	}
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" && !ch.LastModified.IsZero() {
+		t, err := http.ParseTime(ims)
+		if err != nil {
+			return false
+		}
+		// HTTP dates have a resolution of one second.
+		return !ch.LastModified.Truncate(time.Second).After(t)
+	}
+	return false
+}
+
+func compress(encoding string, body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	var cw io.WriteCloser
+	switch encoding {
+	case "br":
+		cw = brotli.NewWriter(&buf)
+	case "gzip":
+		cw = gzip.NewWriter(&buf)
+	}
+	if _, err := cw.Write(body); err != nil {
+		return nil, err
+	}
+	if err := cw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// negotiateEncoding returns the first of the encodings with the highest
+// quality value in the Accept-Encoding header of the request, or an empty
+// string if none of the encodings are accepted.
+func negotiateEncoding(r *http.Request, encodings ...string) (encoding string) {
+	var bestQ float64
+	for _, e := range encodings {
+		if q := acceptedEncodingQuality(r, e); q > bestQ {
+			encoding, bestQ = e, q
+		}
+	}
+	return encoding
+}
+
+// acceptedEncodingQuality returns the quality value of the encoding in the
+// Accept-Encoding header of the request. Zero means not accepted.
+func acceptedEncodingQuality(r *http.Request, encoding string) (q float64) {
+	for _, value := range r.Header.Values("Accept-Encoding") {
+		for _, item := range strings.Split(value, ",") {
+			name, params, _ := strings.Cut(strings.TrimSpace(item), ";")
+			name = strings.TrimSpace(name)
+			if !strings.EqualFold(name, encoding) && name != "*" {
+				continue
+			}
+			itemQ := 1.0
+			if qs, hasQ := strings.CutPrefix(strings.TrimSpace(params), "q="); hasQ {
+				f, err := strconv.ParseFloat(qs, 64)
+				if err != nil {
+					continue
+				}
+				itemQ = f
+			}
+			// An explicit entry for the encoding takes precedence over *.
+			if strings.EqualFold(name, encoding) {
+				return itemQ
+			}
+			q = itemQ
+		}
+	}
+	return q
+}
+
+// etagMatches returns true if the If-None-Match header value matches the ETag,
+// using the weak comparison function.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
 // ServeHTTP implements the http.Handler interface.
 func (ch ComponentHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if ch.StreamResponse {
 		ch.ServeHTTPStreamed(w, r)
 		return
 	}
+	if sc, ok := ch.Component.(*StaticComponent); ok && (ch.ETag || ch.Compression) {
+		ch.ServeHTTPStatic(w, r, sc)
+		return
+	}
 	ch.ServeHTTPBuffered(w, r)
 }
 
@@ -100,3 +312,47 @@ func WithStreaming() func(*ComponentHandler) {
 		ch.StreamResponse = true
 	}
 }
+
+// WithETag sets the ComponentHandler to compute an ETag from the rendered response,
+// and to respond with 304 Not Modified if the request's If-None-Match header matches.
+func WithETag() func(*ComponentHandler) {
+	return func(ch *ComponentHandler) {
+		ch.ETag = true
+	}
+}
+
+// WithLastModified sets the Last-Modified header returned by the ComponentHandler,
+// and responds with 304 Not Modified if the request's If-Modified-Since header
+// is not before it.
+func WithLastModified(t time.Time) func(*ComponentHandler) {
+	return func(ch *ComponentHandler) {
+		ch.LastModified = t
+	}
+}
+
+// WithCacheControl sets the Cache-Control header returned by the ComponentHandler.
+func WithCacheControl(cacheControl string) func(*ComponentHandler) {
+	return func(ch *ComponentHandler) {
+		ch.CacheControl = cacheControl
+	}
+}
+
+// WithHeaders adds headers to the responses of the ComponentHandler.
+func WithHeaders(headers http.Header) func(*ComponentHandler) {
+	return func(ch *ComponentHandler) {
+		if ch.Headers == nil {
+			ch.Headers = http.Header{}
+		}
+		for k, v := range headers {
+			ch.Headers[k] = append(ch.Headers[k], v...)
+		}
+	}
+}
+
+// WithCompression sets the ComponentHandler to compress responses using
+// brotli or gzip, depending on the request's Accept-Encoding header.
+func WithCompression() func(*ComponentHandler) {
+	return func(ch *ComponentHandler) {
+		ch.Compression = true
+	}
+}
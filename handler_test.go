@@ -1,14 +1,18 @@
 package templ_test
 
 import (
+	"compress/gzip"
 	"context"
 	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/a-h/templ"
+	"github.com/andybalholm/brotli"
 	"github.com/google/go-cmp/cmp"
 )
 
@@ -205,3 +209,270 @@ func TestHandler(t *testing.T) {
 		}
 	})
 }
+
+func TestHandlerStaticComponent(t *testing.T) {
+	component := templ.NewStaticComponent([]byte("<h1>Hello</h1>"))
+
+	t.Run("static components are served with an ETag", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/test", nil)
+		templ.Handler(component, templ.WithETag(), templ.WithCompression()).ServeHTTP(w, r)
+		if got := w.Result().StatusCode; got != http.StatusOK {
+			t.Errorf("expected status %d, got %d", http.StatusOK, got)
+		}
+		if etag := w.Result().Header.Get("ETag"); etag != component.ETag() {
+			t.Errorf("expected ETag %q, got %q", component.ETag(), etag)
+		}
+		if diff := cmp.Diff("<h1>Hello</h1>", w.Body.String()); diff != "" {
+			t.Error(diff)
+		}
+	})
+	t.Run("matching If-None-Match headers return 304", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/test", nil)
+		r.Header.Set("If-None-Match", `"other", `+component.ETag())
+		templ.Handler(component, templ.WithETag(), templ.WithCompression()).ServeHTTP(w, r)
+		if got := w.Result().StatusCode; got != http.StatusNotModified {
+			t.Errorf("expected status %d, got %d", http.StatusNotModified, got)
+		}
+		if w.Body.Len() != 0 {
+			t.Errorf("expected empty body, got %q", w.Body.String())
+		}
+	})
+	t.Run("If-None-Match headers are ignored for non-200 status codes", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/test", nil)
+		r.Header.Set("If-None-Match", component.ETag())
+		templ.Handler(component, templ.WithETag(), templ.WithStatus(http.StatusNotFound)).ServeHTTP(w, r)
+		if got := w.Result().StatusCode; got != http.StatusNotFound {
+			t.Errorf("expected status %d, got %d", http.StatusNotFound, got)
+		}
+	})
+	t.Run("gzip encoded content is served if accepted", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/test", nil)
+		r.Header.Set("Accept-Encoding", "br;q=0.5, gzip;q=0.8")
+		templ.Handler(component, templ.WithETag(), templ.WithCompression()).ServeHTTP(w, r)
+		if encoding := w.Result().Header.Get("Content-Encoding"); encoding != "gzip" {
+			t.Fatalf("expected gzip encoding, got %q", encoding)
+		}
+		if etag := w.Result().Header.Get("ETag"); etag == component.ETag() {
+			t.Errorf("expected gzip encoded content to have a different ETag")
+		}
+		gr, err := gzip.NewReader(w.Body)
+		if err != nil {
+			t.Fatalf("failed to create gzip reader: %v", err)
+		}
+		body, err := io.ReadAll(gr)
+		if err != nil {
+			t.Fatalf("failed to read body: %v", err)
+		}
+		if diff := cmp.Diff("<h1>Hello</h1>", string(body)); diff != "" {
+			t.Error(diff)
+		}
+	})
+	t.Run("encoded content is not served if q=0", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/test", nil)
+		r.Header.Set("Accept-Encoding", "*;q=0")
+		templ.Handler(component, templ.WithETag(), templ.WithCompression()).ServeHTTP(w, r)
+		if encoding := w.Result().Header.Get("Content-Encoding"); encoding != "" {
+			t.Errorf("expected no encoding, got %q", encoding)
+		}
+	})
+	t.Run("static components are served without an ETag or compression by default", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/test", nil)
+		r.Header.Set("Accept-Encoding", "br, gzip")
+		templ.Handler(component).ServeHTTP(w, r)
+		for _, name := range []string{"ETag", "Vary", "Content-Encoding"} {
+			if value := w.Result().Header.Get(name); value != "" {
+				t.Errorf("expected no %s header, got %q", name, value)
+			}
+		}
+		if diff := cmp.Diff("<h1>Hello</h1>", w.Body.String()); diff != "" {
+			t.Error(diff)
+		}
+	})
+	t.Run("static components can be streamed", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/test", nil)
+		templ.Handler(component, templ.WithStreaming(), templ.WithETag()).ServeHTTP(w, r)
+		if etag := w.Result().Header.Get("ETag"); etag != "" {
+			t.Errorf("expected no ETag for streamed responses, got %q", etag)
+		}
+		if diff := cmp.Diff("<h1>Hello</h1>", w.Body.String()); diff != "" {
+			t.Error(diff)
+		}
+	})
+}
+
+func TestHandlerCaching(t *testing.T) {
+	hello := templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		_, err := io.WriteString(w, "Hello")
+		return err
+	})
+	lastModified := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name            string
+		input           *templ.ComponentHandler
+		method          string
+		requestHeaders  http.Header
+		expectedStatus  int
+		expectedHeaders http.Header
+		expectedBody    string
+	}{
+		{
+			name:           "ETags are not computed by default",
+			input:          templ.Handler(hello),
+			expectedStatus: http.StatusOK,
+			expectedHeaders: http.Header{
+				"Etag": nil,
+			},
+			expectedBody: "Hello",
+		},
+		{
+			name:           "ETags can be computed from the body",
+			input:          templ.Handler(hello, templ.WithETag()),
+			expectedStatus: http.StatusOK,
+			expectedHeaders: http.Header{
+				"Etag": []string{`"185f8db32271fe25f561a6fc938b2e26"`},
+			},
+			expectedBody: "Hello",
+		},
+		{
+			name:           "matching If-None-Match headers return 304",
+			input:          templ.Handler(hello, templ.WithETag()),
+			requestHeaders: http.Header{"If-None-Match": []string{`W/"185f8db32271fe25f561a6fc938b2e26"`}},
+			expectedStatus: http.StatusNotModified,
+			expectedBody:   "",
+		},
+		{
+			name:           "non-matching If-None-Match headers return 200",
+			input:          templ.Handler(hello, templ.WithETag()),
+			requestHeaders: http.Header{"If-None-Match": []string{`"other"`}},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "Hello",
+		},
+		{
+			name:           "If-Modified-Since headers that are not before Last-Modified return 304",
+			input:          templ.Handler(hello, templ.WithLastModified(lastModified)),
+			requestHeaders: http.Header{"If-Modified-Since": []string{lastModified.Format(http.TimeFormat)}},
+			expectedStatus: http.StatusNotModified,
+			expectedHeaders: http.Header{
+				"Last-Modified": []string{"Mon, 01 Jan 2024 12:00:00 GMT"},
+			},
+			expectedBody: "",
+		},
+		{
+			name:           "If-Modified-Since headers that are before Last-Modified return 200",
+			input:          templ.Handler(hello, templ.WithLastModified(lastModified)),
+			requestHeaders: http.Header{"If-Modified-Since": []string{lastModified.Add(-time.Hour).Format(http.TimeFormat)}},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "Hello",
+		},
+		{
+			name:           "HEAD requests return headers without a body",
+			input:          templ.Handler(hello),
+			method:         http.MethodHead,
+			expectedStatus: http.StatusOK,
+			expectedHeaders: http.Header{
+				"Content-Length": []string{"5"},
+			},
+			expectedBody: "",
+		},
+		{
+			name: "Cache-Control and custom headers can be set",
+			input: templ.Handler(hello,
+				templ.WithCacheControl("public, max-age=60"),
+				templ.WithHeaders(http.Header{"X-Custom": []string{"value"}}),
+			),
+			expectedStatus: http.StatusOK,
+			expectedHeaders: http.Header{
+				"Cache-Control": []string{"public, max-age=60"},
+				"X-Custom":      []string{"value"},
+			},
+			expectedBody: "Hello",
+		},
+		{
+			name:           "responses are not compressed by default",
+			input:          templ.Handler(hello),
+			requestHeaders: http.Header{"Accept-Encoding": []string{"gzip"}},
+			expectedStatus: http.StatusOK,
+			expectedHeaders: http.Header{
+				"Content-Encoding": nil,
+			},
+			expectedBody: "Hello",
+		},
+		{
+			name:           "responses are not compressed if the client doesn't support it",
+			input:          templ.Handler(hello, templ.WithCompression()),
+			expectedStatus: http.StatusOK,
+			expectedHeaders: http.Header{
+				"Content-Encoding": nil,
+				"Vary":             []string{"Accept-Encoding"},
+			},
+			expectedBody: "Hello",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(method, "/test", nil)
+			for k, v := range tt.requestHeaders {
+				r.Header[k] = v
+			}
+			tt.input.ServeHTTP(w, r)
+			if got := w.Result().StatusCode; tt.expectedStatus != got {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, got)
+			}
+			for k, v := range tt.expectedHeaders {
+				if diff := cmp.Diff(v, w.Result().Header[k]); diff != "" {
+					t.Errorf("header %s: %s", k, diff)
+				}
+			}
+			if diff := cmp.Diff(tt.expectedBody, w.Body.String()); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+
+	t.Run("responses are compressed with the preferred encoding", func(t *testing.T) {
+		for _, encoding := range []string{"gzip", "br"} {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/test", nil)
+			r.Header.Set("Accept-Encoding", encoding)
+			templ.Handler(hello, templ.WithCompression(), templ.WithETag()).ServeHTTP(w, r)
+			if actual := w.Result().Header.Get("Content-Encoding"); actual != encoding {
+				t.Fatalf("expected %s encoding, got %q", encoding, actual)
+			}
+			if etag := w.Result().Header.Get("ETag"); !strings.HasSuffix(etag, "-"+encoding+`"`) {
+				t.Errorf("expected ETag to include the encoding, got %q", etag)
+			}
+			var cr io.Reader
+			switch encoding {
+			case "gzip":
+				gr, err := gzip.NewReader(w.Body)
+				if err != nil {
+					t.Fatalf("failed to create gzip reader: %v", err)
+				}
+				cr = gr
+			case "br":
+				cr = brotli.NewReader(w.Body)
+			}
+			body, err := io.ReadAll(cr)
+			if err != nil {
+				t.Fatalf("failed to read body: %v", err)
+			}
+			if diff := cmp.Diff("Hello", string(body)); diff != "" {
+				t.Error(diff)
+			}
+		}
+	})
+}
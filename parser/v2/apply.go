@@ -0,0 +1,239 @@
+package parser
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// An ApplyFunc is invoked by Apply for each node, before and/or after the
+// node's children, using a Cursor describing the current node and providing
+// operations on it.
+//
+// The return value of ApplyFunc controls the syntax tree traversal.
+// See Apply for details.
+type ApplyFunc func(*Cursor) bool
+
+// Apply traverses a syntax tree recursively, starting with root, and calling
+// pre and post for each node as described below. Apply returns the syntax
+// tree, possibly modified.
+//
+// Nodes are values, so the tree isn't modified in place. Nodes with modified
+// children are copied, and the copies are returned in place of the originals.
+//
+// If pre is not nil, it is called for each node before the node's children
+// are traversed (pre-order). If pre returns false, no children are traversed,
+// and post is not called for that node.
+//
+// If post is not nil, and a prior call of pre didn't return false, post is
+// called for each node after its children are traversed (post-order). If post
+// returns false, traversal is terminated and Apply returns immediately.
+//
+// Children are traversed in the order described by Walk. Nodes that are
+// inserted or used as replacements aren't traversed.
+func Apply(root any, pre, post ApplyFunc) (result any) {
+	a := &applier{pre: pre, post: post}
+	c := &Cursor{name: "Root", index: -1, node: root}
+	c.set = func(n any) { result = n }
+	result = root
+	a.applyCursor(c)
+	return result
+}
+
+// A Cursor describes a node encountered during Apply.
+// Information about the node and its parent is available
+// from the Node, Parent, Name, and Index methods.
+type Cursor struct {
+	parent any
+	name   string
+	index  int
+	node   any
+	set    func(any)
+	// insert appends a node to the slice that contains the current node. It's
+	// nil if the current node isn't part of a slice.
+	insert func(any)
+	after  []any
+	// replaced and deleted nodes aren't walked.
+	replaced, deleted bool
+}
+
+// Node returns the current node. After Replace, it returns the replacement.
+func (c *Cursor) Node() any { return c.node }
+
+// Parent returns the parent of the current node, before any of its children
+// were modified.
+func (c *Cursor) Parent() any { return c.parent }
+
+// Name returns the name of the parent field that contains the current node,
+// e.g. "Children". If the parent is a slice, Name returns the name of the
+// slice field.
+func (c *Cursor) Name() string { return c.name }
+
+// Index reports the index, within the original slice, of the current node
+// if it is part of a slice. Otherwise it returns a value < 0.
+func (c *Cursor) Index() int { return c.index }
+
+// Replace replaces the current node with n. The replacement node is not
+// walked by Apply. Replace panics if n can't be stored in the parent field,
+// e.g. if a Node is replaced with a CSSProperty.
+func (c *Cursor) Replace(n any) {
+	if c.deleted {
+		panic("parser: Replace called after Delete")
+	}
+	c.set(n)
+	c.node = n
+	c.replaced = true
+}
+
+// Delete deletes the current node from its containing slice. If the current
+// node is not part of a slice, Delete panics.
+func (c *Cursor) Delete() {
+	if c.insert == nil {
+		panic(fmt.Sprintf("parser: Delete of %s, which is not a slice element", c.name))
+	}
+	c.deleted = true
+}
+
+// InsertAfter inserts n after the current node in its containing slice. If
+// the current node is not part of a slice, InsertAfter panics. Apply does not
+// walk n.
+func (c *Cursor) InsertAfter(n any) {
+	if c.insert == nil {
+		panic(fmt.Sprintf("parser: InsertAfter of %s, which is not a slice element", c.name))
+	}
+	c.after = append(c.after, n)
+}
+
+// InsertBefore inserts n before the current node in its containing slice. If
+// the current node is not part of a slice, InsertBefore panics. Apply does not
+// walk n.
+func (c *Cursor) InsertBefore(n any) {
+	if c.insert == nil {
+		panic(fmt.Sprintf("parser: InsertBefore of %s, which is not a slice element", c.name))
+	}
+	c.insert(n)
+}
+
+type applier struct {
+	pre, post ApplyFunc
+	aborted   bool
+}
+
+// applyCursor calls pre and post for the current node, and applies them to
+// its children.
+func (a *applier) applyCursor(c *Cursor) {
+	if a.pre != nil && !a.pre(c) {
+		return
+	}
+	if c.deleted {
+		return
+	}
+	if !c.replaced {
+		n := a.children(c.node)
+		c.set(n)
+		c.node = n
+		if a.aborted {
+			return
+		}
+	}
+	if a.post != nil && !a.post(c) {
+		a.aborted = true
+	}
+}
+
+// children applies pre and post to the children of n, and returns n with the
+// modified children.
+func (a *applier) children(n any) any {
+	switch n := n.(type) {
+	case TemplateFile:
+		n.Header = applyList(a, n, "Header", n.Header)
+		applySingle(a, n, "Package", &n.Package)
+		n.Nodes = applyList(a, n, "Nodes", n.Nodes)
+		return n
+	case CSSTemplate:
+		n.Properties = applyList(a, n, "Properties", n.Properties)
+		return n
+	case ExpressionCSSProperty:
+		applySingle(a, n, "Value", &n.Value)
+		return n
+	case HTMLTemplate:
+		n.Children = applyList(a, n, "Children", n.Children)
+		return n
+	case Element:
+		n.Attributes = applyList(a, n, "Attributes", n.Attributes)
+		n.Children = applyList(a, n, "Children", n.Children)
+		return n
+	case RawElement:
+		n.Attributes = applyList(a, n, "Attributes", n.Attributes)
+		return n
+	case ConditionalAttribute:
+		n.Then = applyList(a, n, "Then", n.Then)
+		n.Else = applyList(a, n, "Else", n.Else)
+		return n
+	case TemplElementExpression:
+		n.Children = applyList(a, n, "Children", n.Children)
+		return n
+	case IfExpression:
+		n.Then = applyList(a, n, "Then", n.Then)
+		n.ElseIfs = applyList(a, n, "ElseIfs", n.ElseIfs)
+		n.Else = applyList(a, n, "Else", n.Else)
+		return n
+	case ElseIfExpression:
+		n.Then = applyList(a, n, "Then", n.Then)
+		return n
+	case SwitchExpression:
+		n.Cases = applyList(a, n, "Cases", n.Cases)
+		return n
+	case CaseExpression:
+		n.Children = applyList(a, n, "Children", n.Children)
+		return n
+	case ForExpression:
+		n.Children = applyList(a, n, "Children", n.Children)
+		return n
+	}
+	return n
+}
+
+// applySingle applies pre and post to a field that isn't a slice.
+func applySingle[T any](a *applier, parent any, name string, field *T) {
+	if a.aborted {
+		return
+	}
+	c := &Cursor{parent: parent, name: name, index: -1, node: *field}
+	c.set = func(n any) { *field = convert[T](name, n) }
+	a.applyCursor(c)
+}
+
+// applyList applies pre and post to each element of the list, and returns the
+// modified list. The list isn't modified in place.
+func applyList[T any](a *applier, parent any, name string, list []T) []T {
+	if len(list) == 0 {
+		return list
+	}
+	result := make([]T, 0, len(list))
+	insert := func(n any) { result = append(result, convert[T](name, n)) }
+	for i, n := range list {
+		if a.aborted {
+			result = append(result, list[i:]...)
+			break
+		}
+		current := n
+		c := &Cursor{parent: parent, name: name, index: i, node: n, insert: insert}
+		c.set = func(n any) { current = convert[T](name, n) }
+		a.applyCursor(c)
+		if !c.deleted {
+			result = append(result, current)
+		}
+		for _, n := range c.after {
+			insert(n)
+		}
+	}
+	return result
+}
+
+func convert[T any](name string, n any) T {
+	v, ok := n.(T)
+	if !ok {
+		panic(fmt.Sprintf("parser: can't use %T as %v in %s", n, reflect.TypeOf((*T)(nil)).Elem(), name))
+	}
+	return v
+}
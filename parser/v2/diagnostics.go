@@ -8,30 +8,12 @@ type diagnoser func(Node) ([]Diagnostic, error)
 
 // Diagnostic for template file.
 type Diagnostic struct {
+	// Rule is the name of the check that reported the diagnostic, e.g. "legacy-call-syntax".
+	Rule    string
 	Message string
 	Range   Range
 }
 
-func walkTemplate(t TemplateFile, f func(Node) bool) {
-	for _, n := range t.Nodes {
-		hn, ok := n.(HTMLTemplate)
-		if !ok {
-			continue
-		}
-		walkNodes(hn.Children, f)
-	}
-}
-func walkNodes(t []Node, f func(Node) bool) {
-	for _, n := range t {
-		if !f(n) {
-			continue
-		}
-		if h, ok := n.(CompositeNode); ok {
-			walkNodes(h.ChildNodes(), f)
-		}
-	}
-}
-
 var diagnosers = []diagnoser{
 	useOfLegacyCallSyntaxDiagnoser,
 }
@@ -39,7 +21,11 @@ var diagnosers = []diagnoser{
 func Diagnose(t TemplateFile) ([]Diagnostic, error) {
 	var diags []Diagnostic
 	var errs error
-	walkTemplate(t, func(n Node) bool {
+	Inspect(t, func(node any) bool {
+		n, ok := node.(Node)
+		if !ok {
+			return true
+		}
 		for _, d := range diagnosers {
 			diag, err := d(n)
 			if err != nil {
@@ -56,6 +42,7 @@ func Diagnose(t TemplateFile) ([]Diagnostic, error) {
 func useOfLegacyCallSyntaxDiagnoser(n Node) ([]Diagnostic, error) {
 	if c, ok := n.(CallTemplateExpression); ok {
 		return []Diagnostic{{
+			Rule:    "legacy-call-syntax",
 			Message: "`{! foo }` syntax is deprecated. Use `@foo` syntax instead. Run `templ fmt .` to fix all instances.",
 			Range:   c.Expression.Range,
 		}}, nil
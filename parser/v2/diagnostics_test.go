@@ -34,6 +34,7 @@ templ template () {
 	{! templ.Raw("foo") }
 }`,
 			want: []Diagnostic{{
+				Rule:    "legacy-call-syntax",
 				Message: "`{! foo }` syntax is deprecated. Use `@foo` syntax instead. Run `templ fmt .` to fix all instances.",
 				Range:   Range{Position{39, 4, 4}, Position{55, 4, 20}},
 			}},
@@ -49,6 +50,7 @@ templ template () {
 	</div>
 }`,
 			want: []Diagnostic{{
+				Rule:    "legacy-call-syntax",
 				Message: "`{! foo }` syntax is deprecated. Use `@foo` syntax instead. Run `templ fmt .` to fix all instances.",
 				Range:   Range{Position{47, 5, 5}, Position{63, 5, 21}},
 			}},
@@ -64,6 +66,7 @@ templ template () {
 	}
 }`,
 			want: []Diagnostic{{
+				Rule:    "legacy-call-syntax",
 				Message: "`{! foo }` syntax is deprecated. Use `@foo` syntax instead. Run `templ fmt .` to fix all instances.",
 				Range:   Range{Position{51, 5, 5}, Position{67, 5, 21}},
 			}},
@@ -79,6 +82,7 @@ templ template () {
 	}
 }`,
 			want: []Diagnostic{{
+				Rule:    "legacy-call-syntax",
 				Message: "`{! foo }` syntax is deprecated. Use `@foo` syntax instead. Run `templ fmt .` to fix all instances.",
 				Range:   Range{Position{60, 5, 5}, Position{76, 5, 21}},
 			}},
@@ -98,10 +102,12 @@ templ template () {
 }`,
 			want: []Diagnostic{
 				{
+					Rule:    "legacy-call-syntax",
 					Message: "`{! foo }` syntax is deprecated. Use `@foo` syntax instead. Run `templ fmt .` to fix all instances.",
 					Range:   Range{Position{61, 6, 5}, Position{77, 6, 21}},
 				},
 				{
+					Rule:    "legacy-call-syntax",
 					Message: "`{! foo }` syntax is deprecated. Use `@foo` syntax instead. Run `templ fmt .` to fix all instances.",
 					Range:   Range{Position{95, 8, 5}, Position{96, 8, 6}},
 				},
@@ -118,6 +124,7 @@ templ template () {
 	}
 }`,
 			want: []Diagnostic{{
+				Rule:    "legacy-call-syntax",
 				Message: "`{! foo }` syntax is deprecated. Use `@foo` syntax instead. Run `templ fmt .` to fix all instances.",
 				Range:   Range{Position{59, 5, 5}, Position{75, 5, 21}},
 			}},
@@ -0,0 +1,202 @@
+package parser
+
+import (
+	"io"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// FormatOptions configure how templates are formatted. The zero value formats
+// templates in the default templ style.
+type FormatOptions struct {
+	// MaxLineWidth is the maximum width of an element's open tag. Open tags
+	// that would be wider are written with one attribute per line. Zero means
+	// that open tags are never wrapped.
+	MaxLineWidth int
+	// Indent is written once for each level of indentation. Defaults to a tab.
+	Indent string
+	// TabWidth is the width of a tab when measuring line width. Defaults to 4.
+	TabWidth int
+	// AttributeOrder sets the order of element attributes. By default,
+	// attributes are written in the order they appear in the template.
+	AttributeOrder AttributeOrder
+	// SortClasses sorts the classes within constant class attributes.
+	SortClasses bool
+}
+
+// AttributeOrder is the order that attributes are written in.
+type AttributeOrder string
+
+const (
+	// AttributeOrderNone keeps attributes in the order they were written.
+	AttributeOrderNone AttributeOrder = ""
+	// AttributeOrderAlphabetical sorts attributes by name.
+	AttributeOrderAlphabetical AttributeOrder = "alphabetical"
+	// AttributeOrderCanonical puts the id attribute first, then class, then
+	// the other attributes in the order they were written.
+	AttributeOrderCanonical AttributeOrder = "canonical"
+)
+
+// WriteWithOptions formats the template file using the options, and writes it
+// to w. The template file isn't modified.
+//
+// Spread and conditional attributes aren't moved when attributes are sorted.
+// Only the attributes between them are reordered, because the order of
+// attributes that can override each other is significant.
+func (tf TemplateFile) WriteWithOptions(w io.Writer, opts FormatOptions) error {
+	tf = opts.rewrite(tf).(TemplateFile)
+	return tf.Write(&formatWriter{Writer: w, opts: opts})
+}
+
+// rewrite returns a copy of the node, with its attributes ordered, and its
+// classes sorted.
+func (opts FormatOptions) rewrite(node any) any {
+	if opts.AttributeOrder == AttributeOrderNone && !opts.SortClasses {
+		return node
+	}
+	return Apply(node, nil, func(c *Cursor) bool {
+		switch n := c.Node().(type) {
+		case Element:
+			n.Attributes = opts.orderAttributes(n.Attributes)
+			c.Replace(n)
+		case RawElement:
+			n.Attributes = opts.orderAttributes(n.Attributes)
+			c.Replace(n)
+		case ConditionalAttribute:
+			n.Then = opts.orderAttributes(n.Then)
+			n.Else = opts.orderAttributes(n.Else)
+			c.Replace(n)
+		case ConstantAttribute:
+			if opts.SortClasses && strings.EqualFold(n.Name, "class") {
+				n.Value = sortClasses(n.Value)
+				c.Replace(n)
+			}
+		}
+		return true
+	})
+}
+
+// formatWriter carries the format options to the Write methods of the nodes.
+type formatWriter struct {
+	io.Writer
+	opts FormatOptions
+}
+
+// formatOptions returns the options of w, or the default options.
+func formatOptions(w io.Writer) FormatOptions {
+	if fw, ok := w.(*formatWriter); ok {
+		return fw.opts
+	}
+	return FormatOptions{}
+}
+
+func (opts FormatOptions) indent() string {
+	if opts.Indent == "" {
+		return "\t"
+	}
+	return opts.Indent
+}
+
+// goIndent replaces the tabs that indent a line of Go code with the configured
+// indent. gofmt always indents with tabs.
+func (opts FormatOptions) goIndent(line string) string {
+	if opts.Indent == "" {
+		return line
+	}
+	trimmed := strings.TrimLeft(line, "\t")
+	return strings.Repeat(opts.Indent, len(line)-len(trimmed)) + trimmed
+}
+
+// width returns the display width of s.
+func (opts FormatOptions) width(s string) int {
+	tabWidth := opts.TabWidth
+	if tabWidth <= 0 {
+		tabWidth = 4
+	}
+	return utf8.RuneCountInString(s) + strings.Count(s, "\t")*(tabWidth-1)
+}
+
+// orderAttributes returns the attributes in the configured order. Attributes
+// without a name act as barriers that other attributes aren't moved across.
+func (opts FormatOptions) orderAttributes(attrs []Attribute) []Attribute {
+	if opts.AttributeOrder == AttributeOrderNone || len(attrs) < 2 {
+		return attrs
+	}
+	attrs = slices.Clone(attrs)
+	for start := 0; start < len(attrs); {
+		end := start
+		for end < len(attrs) && attributeName(attrs[end]) != "" {
+			end++
+		}
+		slices.SortStableFunc(attrs[start:end], func(a, b Attribute) int {
+			return opts.compareAttributes(attributeName(a), attributeName(b))
+		})
+		start = end + 1
+	}
+	return attrs
+}
+
+func (opts FormatOptions) compareAttributes(a, b string) int {
+	if opts.AttributeOrder == AttributeOrderAlphabetical {
+		return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+	}
+	return canonicalRank(a) - canonicalRank(b)
+}
+
+func canonicalRank(name string) int {
+	switch strings.ToLower(name) {
+	case "id":
+		return 0
+	case "class":
+		return 1
+	}
+	return 2
+}
+
+func attributeName(attr Attribute) string {
+	switch attr := attr.(type) {
+	case BoolConstantAttribute:
+		return attr.Name
+	case ConstantAttribute:
+		return attr.Name
+	case BoolExpressionAttribute:
+		return attr.Name
+	case ExpressionAttribute:
+		return attr.Name
+	}
+	return ""
+}
+
+func sortClasses(value string) string {
+	classes := strings.Fields(value)
+	slices.Sort(classes)
+	return strings.Join(classes, " ")
+}
+
+// openTagTooWide returns true if the element's open tag, with all of its
+// attributes on one line, would be wider than the maximum line width.
+func (e Element) openTagTooWide(w io.Writer, indent int) bool {
+	opts := formatOptions(w)
+	if opts.MaxLineWidth <= 0 || len(e.Attributes) == 0 {
+		return false
+	}
+	sb := new(strings.Builder)
+	fw := &formatWriter{Writer: sb, opts: opts}
+	_ = writeIndent(fw, indent, "<", e.Name)
+	for _, a := range e.Attributes {
+		sb.WriteString(" ")
+		_ = a.Write(fw, 0)
+	}
+	if e.IsVoidElement() && !e.hasNonWhitespaceChildren() {
+		sb.WriteString("/>")
+	} else {
+		sb.WriteString(">")
+	}
+	for _, line := range strings.Split(sb.String(), "\n") {
+		if opts.width(line) > opts.MaxLineWidth {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,269 @@
+package parser
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWriteWithOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     FormatOptions
+		input    string
+		expected string
+	}{
+		{
+			name: "the zero value formats in the default style",
+			input: `package main
+
+templ a() {
+	<div id="a" class="c b"><span>text</span></div>
+}
+`,
+			expected: `package main
+
+templ a() {
+	<div id="a" class="c b"><span>text</span></div>
+}
+`,
+		},
+		{
+			name: "indentation can be set",
+			opts: FormatOptions{Indent: "  "},
+			input: `package main
+
+templ a() {
+<div>
+<span>text</span>
+</div>
+}
+`,
+			expected: `package main
+
+templ a() {
+  <div>
+    <span>text</span>
+  </div>
+}
+`,
+		},
+		{
+			name: "go code is indented with the configured indentation",
+			opts: FormatOptions{Indent: "  "},
+			input: `package main
+
+templ a() {
+	<div>
+		<div>
+			@B(
+				"a",
+			)
+			{{
+				x := map[string]int{
+					"a": 1,
+				}
+			}}
+			<a
+				href={ fmt.Sprintf(
+					"/%s",
+					x,
+				) }
+			>link</a>
+		</div>
+	</div>
+}
+`,
+			expected: `package main
+
+templ a() {
+  <div>
+    <div>
+      @B(
+        "a",
+      )
+      {{
+        x := map[string]int{
+          "a": 1,
+        }
+      }}
+      <a
+        href={ fmt.Sprintf(
+          "/%s",
+          x,
+        ) }
+      >link</a>
+    </div>
+  </div>
+}
+`,
+		},
+		{
+			name: "multi-line strings in go code are not indented",
+			opts: FormatOptions{Indent: "  "},
+			input: `package main
+
+templ a() {
+	<div>
+		{{
+			x := ` + "`" + `
+	a
+` + "`" + `
+		}}
+	</div>
+}
+`,
+			expected: `package main
+
+templ a() {
+  <div>
+    {{
+      x := ` + "`" + `
+	a
+` + "`" + `
+    }}
+  </div>
+}
+`,
+		},
+		{
+			name: "attributes are placed on separate lines when the open tag is too wide",
+			opts: FormatOptions{MaxLineWidth: 40},
+			input: `package main
+
+templ a() {
+	<div id="a" class="first second third" hidden>
+		<input type="text" name="q"/>
+	</div>
+}
+`,
+			expected: `package main
+
+templ a() {
+	<div
+		id="a"
+		class="first second third"
+		hidden
+	>
+		<input type="text" name="q"/>
+	</div>
+}
+`,
+		},
+		{
+			name: "tab width is used to measure the line width",
+			opts: FormatOptions{MaxLineWidth: 30, TabWidth: 8},
+			input: `package main
+
+templ a() {
+	<div>
+		<input type="text" name="q"/>
+	</div>
+}
+`,
+			expected: `package main
+
+templ a() {
+	<div>
+		<input
+			type="text"
+			name="q"
+		/>
+	</div>
+}
+`,
+		},
+		{
+			name: "attributes can be sorted alphabetically",
+			opts: FormatOptions{AttributeOrder: AttributeOrderAlphabetical},
+			input: `package main
+
+templ a() {
+	<a title="t" href={ url } id="a" { attrs... } class="b" data-x="x"></a>
+}
+`,
+			expected: `package main
+
+templ a() {
+	<a href={ url } id="a" title="t" { attrs... } class="b" data-x="x"></a>
+}
+`,
+		},
+		{
+			name: "attributes can be sorted canonically",
+			opts: FormatOptions{AttributeOrder: AttributeOrderCanonical},
+			input: `package main
+
+templ a() {
+	<a title="t" href="/" class="b" id="a"></a>
+}
+`,
+			expected: `package main
+
+templ a() {
+	<a id="a" class="b" title="t" href="/"></a>
+}
+`,
+		},
+		{
+			name: "attributes within conditional attributes are sorted",
+			opts: FormatOptions{AttributeOrder: AttributeOrderAlphabetical},
+			input: `package main
+
+templ a() {
+	<a
+		title="t"
+		if x {
+			role="link"
+			aria-label="a"
+		}
+	></a>
+}
+`,
+			expected: `package main
+
+templ a() {
+	<a
+		title="t"
+		if x {
+			aria-label="a"
+			role="link"
+		}
+	></a>
+}
+`,
+		},
+		{
+			name: "classes can be sorted",
+			opts: FormatOptions{SortClasses: true},
+			input: `package main
+
+templ a() {
+	<div class="p-4  flex bg-red" title="z y"></div>
+}
+`,
+			expected: `package main
+
+templ a() {
+	<div class="bg-red flex p-4" title="z y"></div>
+}
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tf, err := ParseString(tt.input)
+			if err != nil {
+				t.Fatalf("failed to parse template: %v", err)
+			}
+			var actual bytes.Buffer
+			if err := tf.WriteWithOptions(&actual, tt.opts); err != nil {
+				t.Fatalf("failed to write template: %v", err)
+			}
+			if diff := cmp.Diff(tt.expected, actual.String()); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/a-h/parse"
+)
+
+// FormatRange formats the part of a template file that encloses a range, and
+// leaves the rest of the file as it is.
+//
+// The smallest list of sibling nodes that encloses the range is found, e.g. the
+// children of an element, or of an if statement. The siblings that overlap the
+// range are written at the indentation of the list. The formatted text, and the
+// range of src that it replaces, are returned.
+//
+// ok is false if the range isn't within the body of a single templ template.
+// Only the Line and Col of from and to are used.
+func FormatRange(src string, from, to Position, opts FormatOptions) (formatted string, replaced Range, ok bool, err error) {
+	tf, err := ParseString(src)
+	if err != nil {
+		return "", replaced, false, err
+	}
+	start, end := indexAt(src, from), indexAt(src, to)
+	if end < start {
+		start, end = end, start
+	}
+	// Whitespace around the range, e.g. the indentation of the first selected
+	// line, doesn't need to be part of the enclosing nodes.
+	selection := strings.TrimSpace(src[start:end])
+	if selection != "" {
+		start += strings.Index(src[start:end], selection)
+		end = start + len(selection)
+	}
+	pi := parse.NewInput(src)
+	for _, n := range tf.Nodes {
+		t, isTemplate := n.(HTMLTemplate)
+		if !isTemplate || start < int(t.Range.From.Index) || end > int(t.Range.To.Index) {
+			continue
+		}
+		pi.Seek(int(t.Range.From.Index))
+		if _, _, err = templateExpressionParser.Parse(pi); err != nil {
+			return "", replaced, false, err
+		}
+		body, err := nodeSpans(src, pi, closeBraceWithOptionalPadding)
+		if err != nil {
+			return "", replaced, false, err
+		}
+		list, level := enclosingList(body, 1, start, end)
+		var selected []nodeSpan
+		for _, s := range list {
+			if s.start <= end && start <= s.end {
+				selected = append(selected, s)
+			}
+		}
+		if len(selected) == 0 {
+			return "", replaced, false, nil
+		}
+		return formatSpans(src, pi, selected, level, opts)
+	}
+	return "", replaced, false, nil
+}
+
+func formatSpans(src string, pi *parse.Input, selected []nodeSpan, level int, opts FormatOptions) (formatted string, replaced Range, ok bool, err error) {
+	nodes := make([]Node, len(selected))
+	for i, s := range selected {
+		nodes[i] = opts.rewrite(s.node).(Node)
+	}
+	sb := new(strings.Builder)
+	if err = writeNodesIndented(&formatWriter{Writer: sb, opts: opts}, level, nodes); err != nil {
+		return "", replaced, false, err
+	}
+	formatted = strings.TrimRight(sb.String(), " \t\r\n")
+
+	// Replace the indentation of the first node, unless it's preceded by
+	// other content on the same line.
+	start, end := selected[0].start, selected[len(selected)-1].end
+	if lineStart := strings.LastIndexByte(src[:start], '\n') + 1; strings.TrimSpace(src[lineStart:start]) == "" {
+		start = lineStart
+	} else {
+		formatted = strings.TrimLeft(formatted, " \t")
+	}
+	return formatted, NewRange(pi.PositionAt(start), pi.PositionAt(end)), true, nil
+}
+
+// nodeSpan is the position of a node within the source, without any
+// surrounding whitespace.
+type nodeSpan struct {
+	node       Node
+	start, end int
+	// children are the lists of child nodes that are written on their own
+	// lines, e.g. the children of an element, or the branches of an if.
+	children [][]nodeSpan
+}
+
+// enclosingList returns the smallest list of siblings that encloses the range,
+// and the indentation level of the list.
+func enclosingList(list []nodeSpan, level, start, end int) ([]nodeSpan, int) {
+	for _, s := range list {
+		if start < s.start || end > s.end {
+			continue
+		}
+		for _, children := range s.children {
+			if len(children) > 0 && start >= children[0].start && end <= children[len(children)-1].end {
+				return enclosingList(children, level+1, start, end)
+			}
+		}
+	}
+	return list, level
+}
+
+// nodeSpans parses template nodes until the until parser matches, and returns
+// their positions. The input is expected to be valid.
+func nodeSpans[T any](src string, pi *parse.Input, until parse.Parser[T]) (list []nodeSpan, err error) {
+outer:
+	for !matches(pi, until) {
+		for _, p := range templateNodeSkipParsers {
+			if _, matched, _ := p.Parse(pi); matched {
+				continue outer
+			}
+		}
+		from := pi.Index()
+		for _, p := range templateNodeParsers {
+			n, matched, err := p.Parse(pi)
+			if err != nil {
+				return nil, err
+			}
+			if !matched {
+				continue
+			}
+			next := pi.Index()
+			s := nodeSpan{
+				node:  n,
+				start: from + len(src[from:next]) - len(strings.TrimLeft(src[from:next], " \t\r\n")),
+				end:   from + len(strings.TrimRight(src[from:next], " \t\r\n")),
+			}
+			if _, isWhitespace := n.(Whitespace); !isWhitespace && s.start < s.end {
+				if s.children, err = childSpans(src, pi, n, s.start); err != nil {
+					return nil, err
+				}
+				list = append(list, s)
+			}
+			pi.Seek(next)
+			continue outer
+		}
+		break
+	}
+	return list, nil
+}
+
+// childSpans returns the positions of the children of the node at start.
+func childSpans(src string, pi *parse.Input, n Node, start int) (children [][]nodeSpan, err error) {
+	block := func(from Position, open parse.Parser[any], until parse.Parser[any]) error {
+		pi.Seek(int(from.Index))
+		if _, _, err := open.Parse(pi); err != nil {
+			return err
+		}
+		list, err := nodeSpans(src, pi, until)
+		children = append(children, list)
+		return err
+	}
+	openBlock := StripType(parse.All(openBraceWithOptionalPadding, parse.NewLine))
+	switch n := n.(type) {
+	case Element:
+		if !n.IndentChildren || !n.hasNonWhitespaceChildren() {
+			return nil, nil
+		}
+		pi.Seek(start)
+		if _, _, err = elementOpenTagParser.Parse(pi); err != nil {
+			return nil, err
+		}
+		list, err := nodeSpans(src, pi, parse.String("</"+n.Name+">"))
+		return [][]nodeSpan{list}, err
+	case ForExpression:
+		err = block(n.Expression.Range.To, openBlock, StripType(closeBraceWithOptionalPadding))
+	case TemplElementExpression:
+		if len(n.Children) > 0 {
+			err = block(n.Expression.Range.To, StripType(openBraceWithOptionalPadding), StripType(closeBraceWithOptionalPadding))
+		}
+	case IfExpression:
+		if err = block(n.Expression.Range.To, openBlock, untilElseIfElseOrEnd); err != nil {
+			return nil, err
+		}
+		for _, elseIf := range n.ElseIfs {
+			if err = block(elseIf.Expression.Range.To, openBlock, untilElseIfElseOrEnd); err != nil {
+				return nil, err
+			}
+		}
+		if len(n.Else) > 0 {
+			_, _, _ = parse.OptionalWhitespace.Parse(pi)
+			err = block(Position{Index: int64(pi.Index())}, StripType(endElseParser), StripType(closeBraceWithOptionalPadding))
+		}
+	}
+	return children, err
+}
+
+// indexAt returns the index of the line and column of the position in src.
+func indexAt(src string, p Position) int {
+	var index int
+	for line := uint32(0); line < p.Line; line++ {
+		next := strings.IndexByte(src[index:], '\n')
+		if next < 0 {
+			return len(src)
+		}
+		index += next + 1
+	}
+	lineEnd := strings.IndexByte(src[index:], '\n')
+	if lineEnd < 0 {
+		lineEnd = len(src) - index
+	}
+	return index + min(int(p.Col), lineEnd)
+}
@@ -0,0 +1,98 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFormatRange(t *testing.T) {
+	src := `package main
+
+templ page(x bool) {
+	<div>
+  <p   class="a">A</p>
+<span>B</span>
+		if x {
+			<b>X</b>
+      } else {
+<i>Y</i>
+		}
+	</div>
+}
+
+func f() {
+}
+`
+	tests := []struct {
+		name             string
+		from, to         Position
+		expected         string
+		expectedReplaced string
+		expectedOK       bool
+	}{
+		{
+			name:             "a position within a node formats the node",
+			from:             Position{Line: 4, Col: 4},
+			to:               Position{Line: 4, Col: 4},
+			expected:         "\t\t<p class=\"a\">A</p>",
+			expectedReplaced: "  <p   class=\"a\">A</p>",
+			expectedOK:       true,
+		},
+		{
+			name:             "selected lines format the nodes that overlap them",
+			from:             Position{Line: 4, Col: 0},
+			to:               Position{Line: 5, Col: 3},
+			expected:         "\t\t<p class=\"a\">A</p>\n\t\t<span>B</span>",
+			expectedReplaced: "  <p   class=\"a\">A</p>\n<span>B</span>",
+			expectedOK:       true,
+		},
+		{
+			name:             "nodes within if statements are formatted at the indentation of the branch",
+			from:             Position{Line: 9, Col: 1},
+			to:               Position{Line: 9, Col: 2},
+			expected:         "\t\t\t<i>Y</i>",
+			expectedReplaced: "<i>Y</i>",
+			expectedOK:       true,
+		},
+		{
+			name:             "the closing brace of an if statement formats the whole statement",
+			from:             Position{Line: 8, Col: 0},
+			to:               Position{Line: 8, Col: 7},
+			expected:         "\t\tif x {\n\t\t\t<b>X</b>\n\t\t} else {\n\t\t\t<i>Y</i>\n\t\t}",
+			expectedReplaced: "\t\tif x {\n\t\t\t<b>X</b>\n      } else {\n<i>Y</i>\n\t\t}",
+			expectedOK:       true,
+		},
+		{
+			name:       "ranges outside of templates aren't formatted",
+			from:       Position{Line: 14, Col: 0},
+			to:         Position{Line: 14, Col: 4},
+			expectedOK: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, replaced, ok, err := FormatRange(src, tt.from, tt.to, FormatOptions{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if ok != tt.expectedOK {
+				t.Fatalf("expected ok=%v, got %v", tt.expectedOK, ok)
+			}
+			if diff := cmp.Diff(tt.expected, actual); diff != "" {
+				t.Error(diff)
+			}
+			if !ok {
+				return
+			}
+			if diff := cmp.Diff(tt.expectedReplaced, src[replaced.From.Index:replaced.To.Index]); diff != "" {
+				t.Errorf("unexpected replaced text:\n%s", diff)
+			}
+		})
+	}
+	t.Run("files with syntax errors return an error", func(t *testing.T) {
+		if _, _, _, err := FormatRange("package main\n\ntempl a() {\n\t<div>\n}\n", Position{Line: 3}, Position{Line: 3}, FormatOptions{}); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
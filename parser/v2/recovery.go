@@ -0,0 +1,355 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/a-h/parse"
+)
+
+// ParseStringRecovering parses a template file, and carries on after syntax
+// errors, so that all of them are reported. Code that can't be parsed is
+// replaced by an ErrorNode, and a Diagnostic is returned for each error. The
+// error is only returned if the file isn't a template file at all.
+func ParseStringRecovering(template string) (tf TemplateFile, diagnostics []Diagnostic, err error) {
+	r := &recoverer{src: template, positions: parse.NewInput(template)}
+	tf, ok, err := NewTemplateFileParser("main").parse(parse.NewInput(template), r)
+	if err != nil {
+		return tf, nil, err
+	}
+	if !ok {
+		return tf, nil, ErrTemplateNotFound
+	}
+	return tf, r.diagnostics, nil
+}
+
+// SyntaxErrorRule is the rule of diagnostics for syntax errors.
+const SyntaxErrorRule = "syntax"
+
+// recoverer records syntax errors, and skips the code that couldn't be parsed.
+//
+// Within templates, it synchronises on element boundaries and closing braces.
+// At the top level of the file, it synchronises on the closing brace of the
+// broken declaration, or the next templ, css or script declaration.
+type recoverer struct {
+	src string
+	// positions is used to convert indices to positions.
+	positions   *parse.Input
+	diagnostics []Diagnostic
+}
+
+// template parses a templ template, recovering from errors in its body.
+func (r *recoverer) template(pi *parse.Input) (t HTMLTemplate, ok bool, err error) {
+	start := pi.Position()
+
+	// templ FuncName(p Person, other Other) {
+	var te templateExpression
+	if te, ok, err = templateExpressionParser.Parse(pi); err != nil || !ok {
+		return
+	}
+	t.Expression = te.Expression
+	// The Go parser reads until it finds the end of the function declaration,
+	// which may be in a later declaration, e.g. "templ A( {".
+	if containsDeclaration(te.Expression.Value) {
+		return t, false, parse.Error("templ: malformed templ expression, expected `templ functionName() {`", start)
+	}
+
+	var found bool
+	t.Children, found = r.nodes(pi, closeBraceWithOptionalPadding, "template closing brace", r.atDeclarationBoundary)
+	if !found {
+		r.diagnose(parse.Error("template closing brace not found", pi.Position()), pi.Index(), pi.Index())
+		t.Range = NewRange(start, pi.Position())
+		return t, true, nil
+	}
+
+	// Eat any whitespace, and the }.
+	_, _, _ = parse.OptionalWhitespace.Parse(pi)
+	_, _, _ = closeBraceWithOptionalPadding.Parse(pi)
+	t.Range = NewRange(start, pi.Position())
+
+	return t, true, nil
+}
+
+// nodes parses template nodes until the until parser matches, or stop returns
+// true. Nodes that fail to parse are recovered, or replaced by an ErrorNode.
+func (r *recoverer) nodes(pi *parse.Input, until parse.Parser[string], untilName string, stop func(pi *parse.Input) bool) (nodes []Node, found bool) {
+outer:
+	for {
+		if matches(pi, until) {
+			return nodes, true
+		}
+		if stop(pi) {
+			return nodes, false
+		}
+
+		// Skip any nodes that we don't care about.
+		for _, p := range templateNodeSkipParsers {
+			if _, matched, _ := p.Parse(pi); matched {
+				continue outer
+			}
+		}
+
+		start := pi.Index()
+		for _, p := range templateNodeParsers {
+			node, matched, err := p.Parse(pi)
+			if err != nil {
+				pi.Seek(start)
+				nodes = append(nodes, r.recoverNode(pi, err, until, stop))
+				continue outer
+			}
+			if matched {
+				nodes = append(nodes, node)
+				continue outer
+			}
+		}
+
+		err := parse.Error(fmt.Sprintf("%v not found", untilName), pi.Position())
+		nodes = append(nodes, r.skip(pi, err, until, stop))
+	}
+}
+
+// recoverNode recovers from an error in the node at the current position.
+// Elements are recovered by parsing their children, so that an error doesn't
+// hide the rest of the element. Other nodes are skipped.
+func (r *recoverer) recoverNode(pi *parse.Input, err error, until parse.Parser[string], stop func(pi *parse.Input) bool) Node {
+	start := pi.Index()
+	diagnosticCount := len(r.diagnostics)
+	if n, ok := r.element(pi, until, stop); ok {
+		// The error may not be in any of the children, e.g. an invalid attribute.
+		if len(r.diagnostics) == diagnosticCount {
+			r.diagnose(err, start, pi.Index())
+		}
+		return n
+	}
+	pi.Seek(start)
+	return r.skip(pi, err, until, stop)
+}
+
+// element parses an element, recovering from errors in its children.
+func (r *recoverer) element(pi *parse.Input, parentUntil parse.Parser[string], parentStop func(pi *parse.Input) bool) (n Node, ok bool) {
+	start := pi.Position()
+	ot, ok, err := elementOpenTagParser.Parse(pi)
+	if err != nil || !ok {
+		return nil, false
+	}
+	e := Element{
+		Name:        ot.Name,
+		Attributes:  ot.Attributes,
+		IndentAttrs: ot.IndentAttrs,
+		NameRange:   ot.NameRange,
+	}
+	// The contents of raw elements aren't template nodes.
+	if ot.Void || e.IsVoidElement() || ot.Name == "script" || ot.Name == "style" {
+		return nil, false
+	}
+
+	l := pi.Position().Line
+	closer := parse.StringFrom(parse.String("</"), parse.String(ot.Name), parse.String(">"))
+	stop := func(pi *parse.Input) bool {
+		return matches(pi, parentUntil) || parentStop(pi)
+	}
+	var found bool
+	e.Children, found = r.nodes(pi, closer, fmt.Sprintf("<%s>: close tag", ot.Name), stop)
+	e.IndentChildren = l != pi.Position().Line
+	if found {
+		_, _, _ = closer.Parse(pi)
+	} else {
+		r.diagnose(parse.Error(fmt.Sprintf("<%s>: close tag not found", ot.Name), start), start.Index, start.Index)
+	}
+
+	n, _, err = addTrailingSpaceAndValidate(start, e, pi)
+	if err != nil {
+		r.diagnose(err, start.Index, pi.Index())
+	}
+	return n, true
+}
+
+// skip records the error, and skips the node at the current position. It
+// synchronises on:
+//
+//   - a close tag that ends the current nodes, on the same line.
+//   - a closing brace or tag at the same indentation, which is the end of the
+//     node, e.g. the closing brace of an if statement.
+//   - a closing brace or tag at a lower indentation, which is the end of the
+//     parent.
+//   - an element at the same or lower indentation.
+//   - the end of the template.
+func (r *recoverer) skip(pi *parse.Input, err error, until parse.Parser[string], stop func(pi *parse.Input) bool) ErrorNode {
+	start := pi.Index()
+	end := r.closeTagOnLine(pi, start, until)
+	if end < 0 {
+		end = r.skipLines(pi, start, until, stop)
+	}
+	pi.Seek(end)
+	r.diagnose(err, start, end)
+	return r.errorNode(err, start, end)
+}
+
+// closeTagOnLine returns the index of the first close tag that matches until
+// on the rest of the line, or -1.
+func (r *recoverer) closeTagOnLine(pi *parse.Input, start int, until parse.Parser[string]) int {
+	line := r.src[start:r.nextLineStart(start)]
+	for i := strings.Index(line, "</"); i >= 0; {
+		pi.Seek(start + i)
+		if matches(pi, until) {
+			return start + i
+		}
+		next := strings.Index(line[i+2:], "</")
+		if next < 0 {
+			break
+		}
+		i += 2 + next
+	}
+	return -1
+}
+
+func (r *recoverer) skipLines(pi *parse.Input, start int, until parse.Parser[string], stop func(pi *parse.Input) bool) (end int) {
+	indent := r.indentation(r.lineStart(start))
+	for end = r.nextLineStart(start); end < len(r.src); end = r.nextLineStart(end) {
+		lineIndent := r.indentation(end)
+		if lineIndent > indent {
+			continue
+		}
+		content := end + lineIndent
+		pi.Seek(content)
+		if r.atDeclarationBoundary(pi) {
+			return end
+		}
+		if strings.HasPrefix(r.src[content:], "}") || strings.HasPrefix(r.src[content:], "</") {
+			if lineIndent == indent {
+				return r.nextLineStart(end)
+			}
+			return end
+		}
+		if matches(pi, until) || stop(pi) || r.atElement(content) {
+			return end
+		}
+	}
+	return end
+}
+
+// skipDeclaration records the error, and skips the broken templ, css or script
+// declaration at start, up to and including its closing brace.
+func (r *recoverer) skipDeclaration(pi *parse.Input, err error, start int) ErrorNode {
+	end := r.nextLineStart(start)
+	for end < len(r.src) {
+		line := r.line(end)
+		if isTemplateDeclaration(line) {
+			break
+		}
+		end = r.nextLineStart(end)
+		if strings.TrimRight(line, " \t\r") == "}" {
+			break
+		}
+	}
+	pi.Seek(end)
+	r.diagnose(err, start, end)
+	return r.errorNode(err, start, end)
+}
+
+func (r *recoverer) errorNode(err error, start, end int) ErrorNode {
+	msg, _ := errorMessageAndPosition(err)
+	return ErrorNode{
+		Message: msg,
+		Value:   r.src[start:end],
+		Range:   NewRange(r.positions.PositionAt(start), r.positions.PositionAt(end)),
+	}
+}
+
+// diagnose records a diagnostic for the error. If the position of the error is
+// outside of the code that was skipped, the diagnostic is reported at the start
+// of the skipped code.
+func (r *recoverer) diagnose(err error, start, end int) {
+	msg, index := errorMessageAndPosition(err)
+	if index < start || index > end {
+		index = start
+	}
+	lineEnd := strings.IndexByte(r.src[index:], '\n')
+	if lineEnd < 0 {
+		lineEnd = len(r.src) - index
+	}
+	r.diagnostics = append(r.diagnostics, Diagnostic{
+		Rule:    SyntaxErrorRule,
+		Message: msg,
+		Range:   NewRange(r.positions.PositionAt(index), r.positions.PositionAt(index+lineEnd)),
+	})
+}
+
+func errorMessageAndPosition(err error) (msg string, index int) {
+	switch e := err.(type) {
+	case parse.ParseError:
+		return e.Msg, e.Pos.Index
+	case UntilNotFoundError:
+		return e.Msg, e.Pos.Index
+	}
+	return err.Error(), -1
+}
+
+// atDeclarationBoundary returns true at the end of the input, at a closing
+// brace at the start of a line, or at the start of a templ, css or script
+// declaration.
+func (r *recoverer) atDeclarationBoundary(pi *parse.Input) bool {
+	i := pi.Index()
+	if i >= len(r.src) {
+		return true
+	}
+	if i != r.lineStart(i) {
+		return false
+	}
+	return r.src[i] == '}' || isTemplateDeclaration(r.line(i))
+}
+
+// containsDeclaration returns true if any line after the first line of the
+// expression is a templ, css or script declaration.
+func containsDeclaration(expression string) bool {
+	lines := strings.Split(expression, "\n")
+	for _, line := range lines[1:] {
+		if isTemplateDeclaration(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// atElement returns true if an element open tag starts at i.
+func (r *recoverer) atElement(i int) bool {
+	if i+1 >= len(r.src) || r.src[i] != '<' {
+		return false
+	}
+	next := rune(r.src[i+1])
+	return unicode.IsLetter(next) || next == '!'
+
+}
+
+func (r *recoverer) lineStart(i int) int {
+	return strings.LastIndexByte(r.src[:i], '\n') + 1
+}
+
+func (r *recoverer) nextLineStart(i int) int {
+	n := strings.IndexByte(r.src[i:], '\n')
+	if n < 0 {
+		return len(r.src)
+	}
+	return i + n + 1
+}
+
+func (r *recoverer) line(i int) string {
+	return strings.TrimSuffix(r.src[i:r.nextLineStart(i)], "\n")
+}
+
+func (r *recoverer) indentation(lineStart int) (n int) {
+	for lineStart+n < len(r.src) && (r.src[lineStart+n] == ' ' || r.src[lineStart+n] == '\t') {
+		n++
+	}
+	return n
+}
+
+// matches returns true if p matches at the current position, without
+// consuming any input.
+func matches[T any](pi *parse.Input, p parse.Parser[T]) bool {
+	start := pi.Index()
+	_, ok, err := p.Parse(pi)
+	pi.Seek(start)
+	return ok && err == nil
+}
@@ -0,0 +1,190 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseStringRecovering(t *testing.T) {
+	type diagnostic struct {
+		Message string
+		Line    uint32
+	}
+	tests := []struct {
+		name     string
+		template string
+		// templates are the names of the templates that were parsed.
+		templates []string
+		// errors are the values of the error nodes at the top level of the file.
+		errors      []string
+		diagnostics []diagnostic
+	}{
+		{
+			name: "valid templates have no diagnostics",
+			template: `package main
+
+templ A() {
+	<div>{ "A" }</div>
+}
+`,
+			templates: []string{"A()"},
+		},
+		{
+			name: "errors in elements are recovered within the element",
+			template: `package main
+
+templ A() {
+	<div>
+		<span>{ x </span>
+		<p>{ y }</p>
+	</div>
+	<b>{ z }</b>
+}
+`,
+			templates: []string{"A()"},
+			diagnostics: []diagnostic{
+				{Message: "string expression: missing close brace", Line: 4},
+			},
+		},
+		{
+			name: "statements are skipped up to their closing brace",
+			template: `package main
+
+templ A() {
+	if x {
+		<a>
+	}
+	<i>{ w }</i>
+}
+
+templ B() {
+	<br/>
+}
+`,
+			templates: []string{"A()", "B()"},
+			diagnostics: []diagnostic{
+				{Message: "if: expected nodes, but none were found", Line: 5},
+			},
+		},
+		{
+			name: "all errors in a file are reported",
+			template: `package main
+
+templ A() {
+	<div>{ a </div>
+	<div>{ b </div>
+}
+
+templ B( {
+	<div></div>
+}
+
+func helper() {
+}
+
+css C() {
+	color: red
+}
+
+templ D() {
+	<p>{ d }</p>
+}
+`,
+			templates: []string{"A()", "D()"},
+			errors: []string{
+				"templ B( {\n\t<div></div>\n}\n",
+				"css C() {\n\tcolor: red\n}\n",
+			},
+			diagnostics: []diagnostic{
+				{Message: "string expression: missing close brace", Line: 3},
+				{Message: "string expression: missing close brace", Line: 4},
+				{Message: "templ: malformed templ expression, expected `templ functionName() {`", Line: 7},
+				{Message: "missing expected semicolon and linebreak (;\\n", Line: 15},
+			},
+		},
+		{
+			name: "templates without a closing brace end at the next declaration",
+			template: `package main
+
+templ A() {
+	<div>
+templ B() {
+	<p>B</p>
+}
+`,
+			templates: []string{"A()", "B()"},
+			diagnostics: []diagnostic{
+				{Message: "<div>: close tag not found", Line: 3},
+				{Message: "template closing brace not found", Line: 4},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tf, diags, err := ParseStringRecovering(tt.template)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var templates, errors []string
+			for _, n := range tf.Nodes {
+				switch n := n.(type) {
+				case HTMLTemplate:
+					templates = append(templates, n.Expression.Value)
+				case ErrorNode:
+					errors = append(errors, n.Value)
+				}
+			}
+			if diff := cmp.Diff(tt.templates, templates); diff != "" {
+				t.Errorf("unexpected templates:\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.errors, errors); diff != "" {
+				t.Errorf("unexpected error nodes:\n%s", diff)
+			}
+			var actual []diagnostic
+			for _, d := range diags {
+				if d.Rule != SyntaxErrorRule {
+					t.Errorf("expected rule %q, got %q", SyntaxErrorRule, d.Rule)
+				}
+				actual = append(actual, diagnostic{Message: d.Message, Line: d.Range.From.Line})
+			}
+			if diff := cmp.Diff(tt.diagnostics, actual); diff != "" {
+				t.Errorf("unexpected diagnostics:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseStringRecoveringMatchesParseString(t *testing.T) {
+	fileNames, err := filepath.Glob("../../generator/test-*/template.templ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fileNames) == 0 {
+		t.Fatal("no templates found")
+	}
+	for _, fileName := range fileNames {
+		t.Run(fileName, func(t *testing.T) {
+			src, err := os.ReadFile(fileName)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected, err := ParseString(string(src))
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+			actual, diags, err := ParseStringRecovering(string(src))
+			if err != nil {
+				t.Fatalf("failed to parse with recovery: %v", err)
+			}
+			if len(diags) != 0 {
+				t.Errorf("expected no diagnostics, got %v", diags)
+			}
+			if diff := cmp.Diff(expected, actual); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
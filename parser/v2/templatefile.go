@@ -70,6 +70,12 @@ type TemplateFileParser struct {
 var legacyPackageParser = parse.String("{% package")
 
 func (p TemplateFileParser) Parse(pi *parse.Input) (tf TemplateFile, ok bool, err error) {
+	return p.parse(pi, nil)
+}
+
+// parse the template file. If r is not nil, errors in templ, css and script
+// declarations are recorded by r, and parsing continues.
+func (p TemplateFileParser) parse(pi *parse.Input, r *recoverer) (tf TemplateFile, ok bool, err error) {
 	// If we're parsing a legacy file, complain that migration needs to happen.
 	_, ok, err = legacyPackageParser.Parse(pi)
 	if err != nil {
@@ -112,10 +118,20 @@ outer:
 	for {
 		// Optional templates, CSS, and script templates.
 		// templ Name(p Parameter)
+		from := pi.Index()
 		var tn HTMLTemplate
-		tn, ok, err = template.Parse(pi)
+		if r != nil {
+			tn, ok, err = r.template(pi)
+		} else {
+			tn, ok, err = template.Parse(pi)
+		}
 		if err != nil {
-			return tf, false, err
+			if r == nil {
+				return tf, false, err
+			}
+			tf.Nodes = append(tf.Nodes, r.skipDeclaration(pi, err, from))
+			_, _, _ = parse.OptionalWhitespace.Parse(pi)
+			continue
 		}
 		if ok {
 			tf.Nodes = append(tf.Nodes, tn)
@@ -127,7 +143,12 @@ outer:
 		var cn CSSTemplate
 		cn, ok, err = cssParser.Parse(pi)
 		if err != nil {
-			return tf, false, err
+			if r == nil {
+				return tf, false, err
+			}
+			tf.Nodes = append(tf.Nodes, r.skipDeclaration(pi, err, from))
+			_, _, _ = parse.OptionalWhitespace.Parse(pi)
+			continue
 		}
 		if ok {
 			tf.Nodes = append(tf.Nodes, cn)
@@ -139,7 +160,12 @@ outer:
 		var sn ScriptTemplate
 		sn, ok, err = scriptTemplateParser.Parse(pi)
 		if err != nil {
-			return tf, false, err
+			if r == nil {
+				return tf, false, err
+			}
+			tf.Nodes = append(tf.Nodes, r.skipDeclaration(pi, err, from))
+			_, _, _ = parse.OptionalWhitespace.Parse(pi)
+			continue
 		}
 		if ok {
 			tf.Nodes = append(tf.Nodes, sn)
@@ -149,7 +175,7 @@ outer:
 
 		// Anything that isn't template content is Go code.
 		code := new(strings.Builder)
-		from := pi.Position()
+		codeFrom := pi.Position()
 	inner:
 		for {
 			// Check to see if this line isn't Go code.
@@ -158,13 +184,12 @@ outer:
 			if l, ok, err = stringUntilNewLineOrEOF.Parse(pi); err != nil {
 				return
 			}
-			hasTemplatePrefix := strings.HasPrefix(l, "templ ") || strings.HasPrefix(l, "css ") || strings.HasPrefix(l, "script ")
-			if hasTemplatePrefix && strings.Contains(l, "(") {
+			if isTemplateDeclaration(l) {
 				// Unread the line.
 				pi.Seek(last)
 				// Take the code so far.
 				if code.Len() > 0 {
-					expr := NewExpression(strings.TrimSpace(code.String()), from, pi.Position())
+					expr := NewExpression(strings.TrimSpace(code.String()), codeFrom, pi.Position())
 					tf.Nodes = append(tf.Nodes, TemplateFileGoExpression{Expression: expr})
 				}
 				// Carry on parsing.
@@ -180,7 +205,7 @@ outer:
 			code.WriteString(newLine)
 			if _, isEOF, _ := parse.EOF[string]().Parse(pi); isEOF {
 				if code.Len() > 0 {
-					expr := NewExpression(strings.TrimSpace(code.String()), from, pi.Position())
+					expr := NewExpression(strings.TrimSpace(code.String()), codeFrom, pi.Position())
 					tf.Nodes = append(tf.Nodes, TemplateFileGoExpression{Expression: expr})
 				}
 				// Stop parsing.
@@ -191,3 +216,10 @@ outer:
 
 	return tf, true, nil
 }
+
+// isTemplateDeclaration returns true if the line starts a templ, css or script
+// declaration, rather than Go code.
+func isTemplateDeclaration(line string) bool {
+	hasTemplatePrefix := strings.HasPrefix(line, "templ ") || strings.HasPrefix(line, "css ") || strings.HasPrefix(line, "script ")
+	return hasTemplatePrefix && strings.Contains(line, "(")
+}
@@ -197,7 +197,7 @@ func (exp TemplateFileGoExpression) Write(w io.Writer, indent int) error {
 }
 
 func writeIndent(w io.Writer, level int, s ...string) (err error) {
-	indent := strings.Repeat("\t", level)
+	indent := strings.Repeat(formatOptions(w).indent(), level)
 	if _, err = io.WriteString(w, indent); err != nil {
 		return err
 	}
@@ -529,6 +529,7 @@ func (e Element) ChildNodes() []Node {
 }
 func (e Element) IsNode() bool { return true }
 func (e Element) Write(w io.Writer, indent int) error {
+	indentAttrs := e.IndentAttrs || e.openTagTooWide(w, indent)
 	if err := writeIndent(w, indent, "<", e.Name); err != nil {
 		return err
 	}
@@ -536,7 +537,7 @@ func (e Element) Write(w io.Writer, indent int) error {
 		a := e.Attributes[i]
 		// Only the conditional attributes get indented.
 		var attrIndent int
-		if e.IndentAttrs {
+		if indentAttrs {
 			if _, err := w.Write([]byte("\n")); err != nil {
 				return err
 			}
@@ -551,7 +552,7 @@ func (e Element) Write(w io.Writer, indent int) error {
 		}
 	}
 	var closeAngleBracketIndent int
-	if e.IndentAttrs {
+	if indentAttrs {
 		if _, err := w.Write([]byte("\n")); err != nil {
 			return err
 		}
@@ -801,14 +802,22 @@ func (ea ExpressionAttribute) formatExpression() (exp []string) {
 func (ea ExpressionAttribute) Write(w io.Writer, indent int) (err error) {
 	lines := ea.formatExpression()
 	if len(lines) == 1 {
-		return writeIndent(w, indent, ea.Name, `={ `, lines[0], ` }`)
+		// Expressions that gofmt can't format are written as they are, apart
+		// from their indentation.
+		opts := formatOptions(w)
+		expressionLines := strings.Split(lines[0], "\n")
+		for i := 1; i < len(expressionLines); i++ {
+			expressionLines[i] = opts.goIndent(expressionLines[i])
+		}
+		return writeIndent(w, indent, ea.Name, `={ `, strings.Join(expressionLines, "\n"), ` }`)
 	}
 
 	if err = writeIndent(w, indent, ea.Name, "={\n"); err != nil {
 		return err
 	}
+	opts := formatOptions(w)
 	for _, line := range lines {
-		if err = writeIndent(w, indent, line, "\n"); err != nil {
+		if err = writeIndent(w, indent, opts.goIndent(line), "\n"); err != nil {
 			return err
 		}
 	}
@@ -962,6 +971,7 @@ func (tee TemplElementExpression) Write(w io.Writer, indent int) error {
 	}
 	sourceLines := bytes.Split(source, []byte("\n"))
 	reformattedSourceLines := bytes.Split(reformattedSource, []byte("\n"))
+	opts := formatOptions(w)
 	for i := range sourceLines {
 		if i == 0 {
 			if err := writeIndent(w, indent, "@"+string(sourceLines[i])); err != nil {
@@ -978,7 +988,7 @@ func (tee TemplElementExpression) Write(w io.Writer, indent int) error {
 			}
 			continue
 		}
-		if err := writeIndent(w, indent, string(sourceLines[i])); err != nil {
+		if err := writeIndent(w, indent, opts.goIndent(string(sourceLines[i]))); err != nil {
 			return err
 		}
 	}
@@ -1159,9 +1169,45 @@ func (gc GoCode) Write(w io.Writer, indent int) error {
 	if !gc.Multiline {
 		return writeIndent(w, indent, `{{ `, string(source), ` }}`)
 	}
-	if err := writeIndent(w, indent, "{{"+string(source)+"\n"); err != nil {
+	opts := formatOptions(w)
+	if opts.Indent == "" {
+		if err := writeIndent(w, indent, "{{"+string(source)+"\n"); err != nil {
+			return err
+		}
+		return writeIndent(w, indent, "}}")
+	}
+	// gofmt indents each line of the code with the indentation of the first
+	// line. Remove it, and indent the code within the braces instead.
+	if err := writeIndent(w, indent, "{{\n"); err != nil {
 		return err
 	}
+	lines := strings.Split(strings.TrimLeft(strings.TrimRightFunc(string(source), unicode.IsSpace), "\n"), "\n")
+	prefix := lines[0][:len(lines[0])-len(strings.TrimLeft(lines[0], "\t"))]
+	code := make([]string, len(lines))
+	for i, line := range lines {
+		code[i] = strings.TrimPrefix(line, prefix)
+	}
+	// Indent all lines and re-format, to find the lines that gofmt doesn't
+	// indent, e.g. within multi-line strings. Those are written as they are.
+	reformattedSource, reformatErr := format.Source([]byte(strings.ReplaceAll(strings.Join(code, "\n"), "\n", "\n\t")))
+	reformattedLines := strings.Split(string(reformattedSource), "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			if _, err := io.WriteString(w, "\n"); err != nil {
+				return err
+			}
+			continue
+		}
+		if reformatErr == nil && i < len(reformattedLines) && reformattedLines[i] != code[i] {
+			if _, err := io.WriteString(w, line+"\n"); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := writeIndent(w, indent+1, opts.goIndent(code[i]), "\n"); err != nil {
+			return err
+		}
+	}
 	return writeIndent(w, indent, "}}")
 }
 
@@ -1209,6 +1255,24 @@ func (s ScriptTemplate) Write(w io.Writer, indent int) error {
 	return nil
 }
 
+// ErrorNode is code that couldn't be parsed. It's only present in files parsed
+// with ParseStringRecovering, and can appear within a template, or at the top
+// level of a file.
+type ErrorNode struct {
+	// Message describes the syntax error.
+	Message string
+	// Value is the code that was skipped.
+	Value string
+	Range Range
+}
+
+func (e ErrorNode) IsNode() bool             { return true }
+func (e ErrorNode) IsTemplateFileNode() bool { return true }
+func (e ErrorNode) Write(w io.Writer, indent int) error {
+	_, err := io.WriteString(w, e.Value)
+	return err
+}
+
 // formatFunctionArguments formats the function arguments, if possible.
 func formatFunctionArguments(expression string) string {
 	source := []byte(expression)
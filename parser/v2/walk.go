@@ -0,0 +1,97 @@
+package parser
+
+// A Visitor's Visit method is invoked for each node encountered by Walk.
+// If the result visitor w is not nil, Walk visits each of the children
+// of node with the visitor w, followed by a call of w.Visit(nil).
+type Visitor interface {
+	Visit(node any) (w Visitor)
+}
+
+// Walk traverses the tree in depth-first order, in the order that the nodes
+// appear in the template file. It starts by calling v.Visit(node); node must
+// not be nil. If the visitor w returned by v.Visit(node) is not nil, Walk is
+// invoked recursively with visitor w for each of the non-nil children of node,
+// followed by a call of w.Visit(nil).
+//
+// The node can be a TemplateFile, or any value within it:
+//
+//   - TemplateFile: Header, Package, then Nodes.
+//   - TemplateFileGoExpression, Package, ScriptTemplate, ErrorNode: no children.
+//   - CSSTemplate: Properties.
+//   - ConstantCSSProperty: no children.
+//   - ExpressionCSSProperty: Value.
+//   - HTMLTemplate: Children.
+//   - Element: Attributes, then Children.
+//   - RawElement: Attributes.
+//   - ConditionalAttribute: Then, then Else.
+//   - TemplElementExpression, ForExpression, CaseExpression: Children.
+//   - IfExpression: Then, ElseIfs, then Else.
+//   - ElseIfExpression: Then.
+//   - SwitchExpression: Cases.
+//
+// Other nodes and attributes don't have children.
+func Walk(v Visitor, node any) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+	switch n := node.(type) {
+	case TemplateFile:
+		walkList(v, n.Header)
+		Walk(v, n.Package)
+		walkList(v, n.Nodes)
+	case CSSTemplate:
+		walkList(v, n.Properties)
+	case ExpressionCSSProperty:
+		Walk(v, n.Value)
+	case HTMLTemplate:
+		walkList(v, n.Children)
+	case Element:
+		walkList(v, n.Attributes)
+		walkList(v, n.Children)
+	case RawElement:
+		walkList(v, n.Attributes)
+	case ConditionalAttribute:
+		walkList(v, n.Then)
+		walkList(v, n.Else)
+	case TemplElementExpression:
+		walkList(v, n.Children)
+	case IfExpression:
+		walkList(v, n.Then)
+		walkList(v, n.ElseIfs)
+		walkList(v, n.Else)
+	case ElseIfExpression:
+		walkList(v, n.Then)
+	case SwitchExpression:
+		walkList(v, n.Cases)
+	case CaseExpression:
+		walkList(v, n.Children)
+	case ForExpression:
+		walkList(v, n.Children)
+	}
+	v.Visit(nil)
+}
+
+func walkList[T any](v Visitor, list []T) {
+	for _, n := range list {
+		if any(n) != nil {
+			Walk(v, n)
+		}
+	}
+}
+
+type inspector func(any) bool
+
+func (f inspector) Visit(node any) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses the tree in depth-first order: It starts by calling
+// f(node); node must not be nil. If f returns true, Inspect invokes f
+// recursively for each of the non-nil children of node, followed by a
+// call of f(nil).
+func Inspect(node any, f func(any) bool) {
+	Walk(inspector(f), node)
+}
@@ -0,0 +1,226 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const walkTestTemplate = `// Header.
+
+package main
+
+css red() {
+	color: red;
+	background: { "blue" };
+}
+
+templ page(items []string, n int) {
+	<div class="a" if n > 0 { data-n={ "n" } } else { hidden }>
+		if n == 1 {
+			<p>one</p>
+		} else if n == 2 {
+			two
+		} else {
+			many
+		}
+		switch n {
+			case 1:
+				{ "x" }
+		}
+		for _, item := range items {
+			@item(item) {
+				<span>{ item }</span>
+			}
+		}
+		<script>var x = 1;</script>
+	</div>
+}
+`
+
+func TestInspect(t *testing.T) {
+	tf, err := ParseString(walkTestTemplate)
+	if err != nil {
+		t.Fatalf("failed to parse template: %v", err)
+	}
+	var actual []string
+	depth := 0
+	Inspect(tf, func(n any) bool {
+		if n == nil {
+			depth--
+			return false
+		}
+		switch n.(type) {
+		case Whitespace, Text:
+			// Whitespace and text nodes are walked, but make the test harder to read.
+		default:
+			actual = append(actual, strings.Repeat(" ", depth)+strings.TrimPrefix(fmt.Sprintf("%T", n), "parser."))
+		}
+		depth++
+		return true
+	})
+	expected := []string{
+		"TemplateFile",
+		" TemplateFileGoExpression",
+		" TemplateFileGoExpression",
+		" Package",
+		" CSSTemplate",
+		"  ConstantCSSProperty",
+		"  ExpressionCSSProperty",
+		"   StringExpression",
+		" HTMLTemplate",
+		"  Element",
+		"   ConstantAttribute",
+		"   ConditionalAttribute",
+		"    ExpressionAttribute",
+		"    BoolConstantAttribute",
+		"   IfExpression",
+		"    Element",
+		"    ElseIfExpression",
+		"   SwitchExpression",
+		"    CaseExpression",
+		"     StringExpression",
+		"   ForExpression",
+		"    TemplElementExpression",
+		"     Element",
+		"      StringExpression",
+		"   RawElement",
+	}
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestInspectSkipsChildren(t *testing.T) {
+	tf, err := ParseString(walkTestTemplate)
+	if err != nil {
+		t.Fatalf("failed to parse template: %v", err)
+	}
+	var count int
+	Inspect(tf, func(n any) bool {
+		if _, ok := n.(StringExpression); ok {
+			count++
+		}
+		// Don't walk into templates.
+		_, isTemplate := n.(HTMLTemplate)
+		return !isTemplate
+	})
+	if count != 1 {
+		t.Errorf("expected only the CSS string expression to be visited, got %d", count)
+	}
+}
+
+func TestApply(t *testing.T) {
+	t.Run("files are unchanged if nothing is modified", func(t *testing.T) {
+		fileNames, err := filepath.Glob("../../generator/test-*/template.templ")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, fileName := range fileNames {
+			src, err := os.ReadFile(fileName)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tf, err := ParseString(string(src))
+			if err != nil {
+				t.Fatalf("%s: failed to parse: %v", fileName, err)
+			}
+			applied := Apply(tf, func(*Cursor) bool { return true }, func(*Cursor) bool { return true })
+			if diff := cmp.Diff(tf, applied); diff != "" {
+				t.Errorf("%s: %s", fileName, diff)
+			}
+		}
+	})
+	t.Run("nodes can be replaced, deleted and inserted", func(t *testing.T) {
+		tf, err := ParseString(`package main
+
+templ page() {
+	<div class="a">
+		// Comment.
+		<p>Text</p>
+	</div>
+}
+`)
+		if err != nil {
+			t.Fatalf("failed to parse template: %v", err)
+		}
+		original := tf.Nodes[0].(HTMLTemplate).Children[1].(Element)
+		result := Apply(tf, func(c *Cursor) bool {
+			switch n := c.Node().(type) {
+			case ConstantAttribute:
+				n.Value = "b"
+				c.Replace(n)
+				c.InsertAfter(BoolConstantAttribute{Name: "hidden"})
+			case GoComment:
+				c.Delete()
+			case Element:
+				if n.Name == "p" {
+					c.InsertBefore(Element{Name: "hr"})
+				}
+			}
+			return true
+		}, nil).(TemplateFile)
+
+		var actual bytes.Buffer
+		if err := result.Write(&actual); err != nil {
+			t.Fatalf("failed to write template: %v", err)
+		}
+		expected := `package main
+
+templ page() {
+	<div class="b" hidden>
+		<hr/>
+		<p>Text</p>
+	</div>
+}
+`
+		if diff := cmp.Diff(expected, actual.String()); diff != "" {
+			t.Error(diff)
+		}
+		// The original tree isn't modified.
+		if original.Attributes[0].(ConstantAttribute).Value != "a" {
+			t.Error("expected the original tree to be unchanged")
+		}
+	})
+	t.Run("post can stop the traversal", func(t *testing.T) {
+		tf, err := ParseString(walkTestTemplate)
+		if err != nil {
+			t.Fatalf("failed to parse template: %v", err)
+		}
+		var visited int
+		Apply(tf, nil, func(c *Cursor) bool {
+			visited++
+			_, isCSS := c.Node().(CSSTemplate)
+			return !isCSS
+		})
+		// The two header expressions, package, two CSS properties, the string
+		// expression, then the CSS template.
+		if visited != 7 {
+			t.Errorf("expected 7 nodes to be visited, got %d", visited)
+		}
+	})
+	t.Run("replacing a node with the wrong type panics", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("expected a panic")
+			}
+		}()
+		tf, _ := ParseString(`package main
+
+templ page() {
+	<div class="a"></div>
+}
+`)
+		Apply(tf, func(c *Cursor) bool {
+			if _, ok := c.Node().(Element); ok {
+				c.Replace(ConstantAttribute{Name: "class", Value: "a"})
+			}
+			return true
+		}, nil)
+	})
+}
@@ -0,0 +1,93 @@
+package templ
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+)
+
+// StaticComponent is a component that always renders the same content.
+//
+// The generator creates static components for templates that don't contain
+// any Go expressions. If the ETag and Compression options are enabled,
+// ComponentHandler serves static components with a precomputed ETag, and a
+// precompressed body where the client supports it.
+type StaticComponent struct {
+	content []byte
+
+	etagOnce sync.Once
+	etag     string
+
+	gzipOnce sync.Once
+	gzipped  []byte
+
+	brotliOnce sync.Once
+	brotli     []byte
+}
+
+// NewStaticComponent creates a component that renders the content as-is.
+func NewStaticComponent(content []byte) *StaticComponent {
+	return &StaticComponent{
+		content: content,
+	}
+}
+
+// Render the content.
+func (sc *StaticComponent) Render(ctx context.Context, w io.Writer) (err error) {
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+	_, err = w.Write(sc.content)
+	return err
+}
+
+// Bytes returns the content of the component.
+func (sc *StaticComponent) Bytes() []byte {
+	return sc.content
+}
+
+// ETag returns a strong entity tag for the content of the component.
+func (sc *StaticComponent) ETag() string {
+	sc.etagOnce.Do(func() {
+		sc.etag = computeETag(sc.content)
+	})
+	return sc.etag
+}
+
+// Gzip returns the content of the component, compressed with gzip.
+func (sc *StaticComponent) Gzip() []byte {
+	sc.gzipOnce.Do(func() {
+		var buf bytes.Buffer
+		gw, _ := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+		// Writes to a bytes.Buffer don't fail.
+		_, _ = gw.Write(sc.content)
+		_ = gw.Close()
+		sc.gzipped = buf.Bytes()
+	})
+	return sc.gzipped
+}
+
+// Brotli returns the content of the component, compressed with brotli.
+func (sc *StaticComponent) Brotli() []byte {
+	sc.brotliOnce.Do(func() {
+		var buf bytes.Buffer
+		bw := brotli.NewWriterLevel(&buf, brotli.BestCompression)
+		// Writes to a bytes.Buffer don't fail.
+		_, _ = bw.Write(sc.content)
+		_ = bw.Close()
+		sc.brotli = buf.Bytes()
+	})
+	return sc.brotli
+}
+
+// computeETag returns a strong entity tag for the content.
+func computeETag(content []byte) string {
+	sum := sha256.Sum256(content)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
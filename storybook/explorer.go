@@ -0,0 +1,187 @@
+package storybook
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"reflect"
+	"strings"
+
+	"github.com/a-h/pathvars"
+	"github.com/a-h/templ"
+)
+
+// Explorer is a component explorer that runs without Node.js. It serves a UI
+// that lists the registered components, and renders them in a preview frame,
+// with controls for their arguments.
+//
+// To reload the explorer when templates change, run it behind the templ dev
+// proxy, e.g. templ generate --watch --proxy=http://localhost:60606.
+type Explorer struct {
+	// RoutePrefix is the prefix of HTTP routes, e.g. /prod/
+	RoutePrefix string
+	// Header is added to the head of component previews, e.g. to load CSS.
+	Header string
+	// Viewports are the sizes that previews can be displayed at. Defaults to
+	// DefaultViewports.
+	Viewports []Viewport
+	// Components in the order they were added.
+	Components []ExplorerComponent
+}
+
+// ExplorerComponent is a component registered with the Explorer.
+type ExplorerComponent struct {
+	Name    string
+	Args    []Arg
+	Handler http.Handler
+}
+
+// Viewport is a preview size. A zero width or height fills the available space.
+type Viewport struct {
+	Name          string
+	Width, Height int
+}
+
+// DefaultViewports are the viewport presets of the Explorer.
+var DefaultViewports = []Viewport{
+	{Name: "Responsive"},
+	{Name: "Mobile", Width: 375, Height: 667},
+	{Name: "Tablet", Width: 768, Height: 1024},
+	{Name: "Desktop", Width: 1280, Height: 800},
+}
+
+func NewExplorer() *Explorer {
+	return &Explorer{
+		Viewports: DefaultViewports,
+	}
+}
+
+// AddComponent registers a component. The component constructor is called with
+// the values of the args for each preview.
+func (e *Explorer) AddComponent(name string, componentConstructor interface{}, args ...Arg) {
+	e.Components = append(e.Components, ExplorerComponent{
+		Name:    name,
+		Args:    args,
+		Handler: newPreviewHandler(e, name, componentConstructor, args...),
+	})
+}
+
+var explorerPreviewMatcher = pathvars.NewExtractor("/preview/{name}")
+
+func (e *Explorer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	u := *r.URL
+	u.Path = "/" + strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, e.RoutePrefix), "/")
+	if values, ok := explorerPreviewMatcher.Extract(&u); ok {
+		c, found := e.component(values["name"])
+		if !found {
+			http.NotFound(w, r)
+			return
+		}
+		c.Handler.ServeHTTP(w, r)
+		return
+	}
+	if u.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	q := r.URL.Query()
+	var selected *ExplorerComponent
+	if len(e.Components) > 0 {
+		selected = &e.Components[0]
+	}
+	if name := q.Get("component"); name != "" {
+		c, found := e.component(name)
+		if !found {
+			http.NotFound(w, r)
+			return
+		}
+		selected = &c
+	}
+	templ.Handler(explorerPage(e, selected, q)).ServeHTTP(w, r)
+}
+
+func (e *Explorer) component(name string) (c ExplorerComponent, ok bool) {
+	for _, c := range e.Components {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return c, false
+}
+
+func (e *Explorer) viewports() []Viewport {
+	if len(e.Viewports) == 0 {
+		return DefaultViewports
+	}
+	return e.Viewports
+}
+
+// url returns the URL of an explorer route.
+func (e *Explorer) url(route string, q url.Values) templ.SafeURL {
+	u := path.Join("/", e.RoutePrefix, route)
+	if strings.HasSuffix(route, "/") && !strings.HasSuffix(u, "/") {
+		u += "/"
+	}
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+	return templ.SafeURL(u)
+}
+
+// previewURL returns the URL of the preview of the component, with the values
+// of its args.
+func (e *Explorer) previewURL(c *ExplorerComponent, q url.Values) templ.SafeURL {
+	values := url.Values{}
+	for _, arg := range c.Args {
+		values[arg.Name] = []string{argValue(arg, q)}
+	}
+	return e.url("/preview/"+url.PathEscape(c.Name), values)
+}
+
+// newPreviewHandler renders the component in an HTML page, using the same
+// reflection as NewHandler.
+func newPreviewHandler(e *Explorer, name string, f interface{}, args ...Arg) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		component, err := renderArgs(name, f, args, r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		templ.Handler(explorerPreview(e.Header, component)).ServeHTTP(w, r)
+	})
+}
+
+// argValue returns the value of the arg in the query, or its default value,
+// formatted as a form value.
+func argValue(arg Arg, q url.Values) string {
+	if values, ok := q[arg.Name]; ok && len(values) > 0 {
+		return values[0]
+	}
+	return formatArg(arg.Value)
+}
+
+// argInput returns the type of the input element of an arg, and its attributes.
+func argInput(arg Arg) (inputType string, attrs templ.Attributes) {
+	attrs = templ.Attributes{}
+	switch control := arg.Control.(type) {
+	case string:
+		switch control {
+		case "boolean":
+			return "checkbox", attrs
+		case "object":
+			return "textarea", attrs
+		}
+	case map[string]interface{}:
+		if control["type"] != "number" {
+			break
+		}
+		for _, k := range []string{"min", "max", "step"} {
+			if v, ok := control[k]; ok {
+				attrs[k] = fmt.Sprint(reflect.Indirect(reflect.ValueOf(v)).Interface())
+			}
+		}
+		return "number", attrs
+	}
+	return "text", attrs
+}
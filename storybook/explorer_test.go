@@ -0,0 +1,104 @@
+package storybook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/a-h/templ"
+)
+
+func greeting(name string, count int, loud bool) templ.Component {
+	return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		s := strings.Repeat("Hello "+name+". ", count)
+		if loud {
+			s = strings.ToUpper(s)
+		}
+		_, err := io.WriteString(w, "<p>"+strings.TrimSpace(s)+"</p>")
+		return err
+	})
+}
+
+func newTestExplorer() *Explorer {
+	e := NewExplorer()
+	e.RoutePrefix = "/components/"
+	e.Header = `<link rel="stylesheet" href="/styles.css"/>`
+	e.AddComponent("Greeting", greeting,
+		TextArg("name", "World"),
+		IntArg("count", 1, IntArgConf{Min: ptr(1), Max: ptr(3)}),
+		BooleanArg("loud", false),
+	)
+	return e
+}
+
+func ptr[T any](v T) *T { return &v }
+
+func TestExplorer(t *testing.T) {
+	tests := []struct {
+		name         string
+		url          string
+		expectedCode int
+		expected     []string
+	}{
+		{
+			name:         "the index lists the components, and previews the first one",
+			url:          "/components/",
+			expectedCode: http.StatusOK,
+			expected: []string{
+				`<a href="/components/?component=Greeting" aria-current="page">Greeting</a>`,
+				`src="/components/preview/Greeting?count=1&amp;loud=false&amp;name=World"`,
+				`<input id="arg-name" type="text" name="name" value="World">`,
+				`<input id="arg-count" type="number" name="count" value="1" max="3" min="1">`,
+				`<input id="arg-loud" type="checkbox" name="loud" value="true">`,
+				`data-width="375" data-height="667">Mobile</button>`,
+			},
+		},
+		{
+			name:         "arg values in the query are used by the controls",
+			url:          "/components/?component=Greeting&name=templ&loud=true&loud=false",
+			expectedCode: http.StatusOK,
+			expected: []string{
+				`<input id="arg-name" type="text" name="name" value="templ">`,
+				`<input id="arg-loud" type="checkbox" name="loud" value="true" checked>`,
+			},
+		},
+		{
+			name:         "previews render the component with the header",
+			url:          "/components/preview/Greeting?name=templ&count=2&loud=true&loud=false",
+			expectedCode: http.StatusOK,
+			expected: []string{
+				`<link rel="stylesheet" href="/styles.css"/>`,
+				`<body><p>HELLO TEMPL. HELLO TEMPL.</p></body>`,
+			},
+		},
+		{
+			name:         "unknown components are not found",
+			url:          "/components/preview/Unknown",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "unknown selected components are not found",
+			url:          "/components/?component=Unknown",
+			expectedCode: http.StatusNotFound,
+		},
+	}
+	e := newTestExplorer()
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.url, nil))
+			if w.Code != tt.expectedCode {
+				t.Errorf("expected status %d, got %d", tt.expectedCode, w.Code)
+			}
+			for _, expected := range tt.expected {
+				if !strings.Contains(w.Body.String(), expected) {
+					t.Errorf("expected body to contain %q, got:\n%s", expected, w.Body.String())
+				}
+			}
+		})
+	}
+}
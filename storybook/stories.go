@@ -0,0 +1,70 @@
+package storybook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+)
+
+// StoryPreview is a story of a component, and the URL that renders it.
+type StoryPreview struct {
+	Component string `json:"component"`
+	Story     string `json:"story"`
+	// URL is the path of the preview, with the story's arguments in the query.
+	URL string `json:"url"`
+}
+
+// Stories returns the stories of all of the components, sorted by component
+// name. Stories use the default values of the component's args, unless they
+// are overridden by the story.
+func (sh *Storybook) Stories() (stories []StoryPreview) {
+	names := make([]string, 0, len(sh.Config))
+	for name := range sh.Config {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		c := sh.Config[name]
+		for _, story := range c.Stories {
+			q := url.Values{}
+			for _, k := range c.Args.keys {
+				q.Set(k, formatArg(c.Args.internal[k]))
+			}
+			for _, k := range story.Args.keys {
+				q.Set(k, formatArg(story.Args.internal[k]))
+			}
+			u := path.Join("/", sh.RoutePrefix, "/storybook_preview/", url.PathEscape(name))
+			if len(q) > 0 {
+				u += "?" + q.Encode()
+			}
+			stories = append(stories, StoryPreview{Component: name, Story: story.Name, URL: u})
+		}
+	}
+	return stories
+}
+
+func (sh *Storybook) storiesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(sh.Stories()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// formatArg formats the value of an arg as a query string value that's read by
+// the arg's Get function.
+func formatArg(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case bool, int, float64:
+		return fmt.Sprint(v)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
@@ -39,6 +39,10 @@ type Storybook struct {
 	Header        string
 	Server        http.Server
 	Log           *zap.Logger
+	// Explorer serves the components instead of Storybook if UseExplorer is
+	// set, so that Node.js isn't required.
+	Explorer    *Explorer
+	UseExplorer bool
 }
 
 type StorybookConfig func(*Storybook)
@@ -55,6 +59,14 @@ func WithHeader(header string) StorybookConfig {
 	}
 }
 
+// WithExplorer serves the components with the Explorer, instead of installing
+// and building Storybook with npm.
+func WithExplorer() StorybookConfig {
+	return func(s *Storybook) {
+		s.UseExplorer = true
+	}
+}
+
 func New(conf ...StorybookConfig) *Storybook {
 	cfg := zap.NewProductionConfig()
 	cfg.EncoderConfig.EncodeTime = zapcore.RFC3339TimeEncoder
@@ -67,6 +79,7 @@ func New(conf ...StorybookConfig) *Storybook {
 		Config:   map[string]*Conf{},
 		Handlers: map[string]http.Handler{},
 		Log:      logger,
+		Explorer: NewExplorer(),
 	}
 	sh.StaticHandler = http.FileServer(http.Dir(path.Join(sh.Path, "storybook-static")))
 	sh.Server = http.Server{
@@ -85,6 +98,7 @@ func (sh *Storybook) AddComponent(name string, componentConstructor interface{},
 	sh.Config[name] = c
 	h := NewHandler(name, componentConstructor, args...)
 	sh.Handlers[name] = h
+	sh.Explorer.AddComponent(name, componentConstructor, args...)
 }
 
 var storybookPreviewMatcher = pathvars.NewExtractor("/storybook_preview/{name}")
@@ -93,6 +107,12 @@ func (sh *Storybook) Build(ctx context.Context) (err error) {
 	defer func() {
 		_ = sh.Log.Sync()
 	}()
+	if sh.UseExplorer {
+		sh.Log.Info("Using the component explorer, skipping Storybook installation.")
+		sh.Explorer.RoutePrefix = sh.RoutePrefix
+		sh.Explorer.Header = sh.Header
+		return
+	}
 	// Download Storybook to the directory required.
 	sh.Log.Info("Installing storybook.")
 	err = sh.installStorybook()
@@ -136,6 +156,14 @@ func (sh *Storybook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			sh.previewHandler(w, r)
 			return
 		}
+		if r.URL.Path == path.Join(sh.RoutePrefix, "/storybook_stories") {
+			sh.storiesHandler(w, r)
+			return
+		}
+		if sh.UseExplorer {
+			sh.Explorer.ServeHTTP(w, r)
+			return
+		}
 		sh.StaticHandler.ServeHTTP(w, r)
 	})
 	cors.Default().Handler(sbh).ServeHTTP(w, r)
@@ -273,12 +301,7 @@ func (sh *Storybook) buildStorybook() (err error) {
 
 func NewHandler(name string, f interface{}, args ...Arg) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		argv := make([]interface{}, len(args))
-		q := r.URL.Query()
-		for i, arg := range args {
-			argv[i] = arg.Get(q)
-		}
-		component, err := executeTemplate(name, f, argv)
+		component, err := renderArgs(name, f, args, r.URL.Query())
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -287,6 +310,16 @@ func NewHandler(name string, f interface{}, args ...Arg) http.Handler {
 	})
 }
 
+// renderArgs calls the component constructor with the values of the args in
+// the query.
+func renderArgs(name string, f interface{}, args []Arg, q url.Values) (templ.Component, error) {
+	argv := make([]interface{}, len(args))
+	for i, arg := range args {
+		argv[i] = arg.Get(q)
+	}
+	return executeTemplate(name, f, argv)
+}
+
 func executeTemplate(name string, fn interface{}, values []interface{}) (output templ.Component, err error) {
 	v := reflect.ValueOf(fn)
 	t := v.Type()
@@ -340,7 +373,7 @@ func (c *Conf) AddStory(name string, args ...Arg) {
 	}
 	c.Stories = append(c.Stories, Story{
 		Name: name,
-		Args: NewSortedMap(),
+		Args: m,
 	})
 }
 